@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ih-ingestion/internal/snowapply"
+)
+
+// runSnowflakeApplyCommand implementa `ingestion-cli snowflake-apply`: executa o script
+// DDL gerado (montado no job) no Snowflake com o driver Go, comando a comando, e grava
+// o resultado em JSON (stdout por padrão; os logs vão para stderr).
+func runSnowflakeApplyCommand(args []string) {
+	fs := flag.NewFlagSet("snowflake-apply", flag.ExitOnError)
+	file := fs.String("file", "/sql/script.sql", "script SQL a executar")
+	mode := fs.String("mode", snowapply.ModeIdempotent, "idempotent (autocommit, ignora 'already exists') ou transactional (só DML: rollback no primeiro erro; DDL é recusado)")
+	resultPath := fs.String("result", "-", "arquivo do resultado JSON (- = stdout)")
+	dryRun := fs.Bool("dry-run", false, "só lista os comandos do script, sem conectar no Snowflake")
+	_ = fs.Parse(args)
+
+	script, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatalf("snowflake-apply: lendo %s: %v", *file, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := snowapply.Options{Mode: *mode, DryRun: *dryRun}
+
+	var res *snowapply.Result
+	var applyErr error
+	if *dryRun {
+		res, applyErr = snowapply.Apply(ctx, nil, string(script), opts)
+	} else {
+		db, err := snowapply.Open()
+		if err != nil {
+			log.Fatalf("snowflake-apply: %v", err)
+		}
+		res, applyErr = snowapply.Apply(ctx, db, string(script), opts)
+		_ = db.Close()
+	}
+
+	if res != nil {
+		if err := writeApplyResult(*resultPath, res); err != nil {
+			log.Printf("snowflake-apply: gravando resultado: %v", err)
+		}
+		log.Printf("snowflake-apply: modo=%s executados=%d ignorados=%d ok=%v em %dms",
+			res.Mode, res.Executed, res.Skipped, res.OK, res.DurationMs)
+	}
+	if applyErr != nil {
+		log.Fatalf("snowflake-apply: %v", applyErr)
+	}
+}
+
+func writeApplyResult(path string, res *snowapply.Result) error {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
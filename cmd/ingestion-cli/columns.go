@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/model"
+)
+
+// sourceColumnProps monta column.exclude.list e as propriedades column.mask.* do
+// source connector a partir das regras de coluna das tabelas do grupo.
+// Colunas são qualificadas como <database>.<schema>.<tabela>.<coluna>, com cada parte escapada.
+func sourceColumnProps(dbNameUpper string, tables []tableMeta) (string, map[string]string) {
+	var excluded []string
+	masked := map[string][]string{}
+
+	for _, tm := range tables {
+		for _, c := range tm.ExcludedColumns {
+			excluded = append(excluded, qualifiedColumn(dbNameUpper, tm, c))
+		}
+		for _, m := range tm.MaskColumns {
+			var key string
+			switch m.Strategy {
+			case config.MaskHash:
+				key = fmt.Sprintf("column.mask.hash.%s.with.salt.%s", m.Algorithm, m.Salt)
+			case config.MaskRedact:
+				key = fmt.Sprintf("column.mask.with.%d.chars", m.Length)
+			default:
+				continue
+			}
+			masked[key] = append(masked[key], qualifiedColumn(dbNameUpper, tm, m.Name))
+		}
+	}
+
+	props := make(map[string]string, len(masked))
+	for k, cols := range masked {
+		sort.Strings(cols)
+		props[k] = strings.Join(cols, ",")
+	}
+	sort.Strings(excluded)
+
+	return strings.Join(excluded, ","), props
+}
+
+// qualifiedColumn monta a entrada de column.exclude.list/column.mask.*: o Debezium trata
+// cada item como regex, então cada parte é escapada (ex: o $ de Valor$Bruto ou o . de um nome).
+func qualifiedColumn(dbNameUpper string, tm tableMeta, column string) string {
+	parts := []string{dbNameUpper, tm.Schema, tm.Name, column}
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// renameColumns aplica a política de nomes às colunas que vão para o DDL e leva as
+// classificações (indexadas pelo nome de origem) para o nome no Snowflake. A ordem das
+// colunas é preservada. strict: rename de coluna inexistente é erro.
+// renames lista origem:destino das colunas cujo nome muda além de maiúsculas/minúsculas
+// (o default upper não precisa de mapeamento); vai para o transform renameColumns do sink.
+func renameColumns(cols []model.ColumnInfo, classified map[string][]string, naming config.Naming, strict bool) ([]model.ColumnInfo, map[string][]string, []string, error) {
+	if strict {
+		for from := range naming.Rename {
+			found := false
+			for _, c := range cols {
+				if strings.EqualFold(c.Name, strings.TrimSpace(from)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, nil, nil, fmt.Errorf("naming.rename: coluna %s não existe (ou foi excluída)", from)
+			}
+		}
+	}
+
+	out := make([]model.ColumnInfo, len(cols))
+	renamed := make(map[string][]string, len(classified))
+	var renames []string
+	for i, c := range cols {
+		target := naming.ColumnName(c.Name)
+		if classes, ok := classified[c.Name]; ok {
+			renamed[target] = classes
+		}
+		if !strings.EqualFold(c.Name, target) {
+			renames = append(renames, c.Name+":"+target)
+		}
+		c.Name = target
+		out[i] = c
+	}
+	return out, renamed, renames, nil
+}
+
+// renameTransform é o alias do transform ReplaceField$Value que o sink recebe quando a
+// política naming renomeia colunas.
+const renameTransform = "renameColumns"
+
+// checkSinkTransforms barra sinkConfig/connectorConfig.sink que redefinem transforms numa
+// tabela com colunas renomeadas: o override substituiria o renameColumns e o sink voltaria
+// a gravar com os nomes de origem.
+func checkSinkTransforms(tm tableMeta, overrides map[string]any) error {
+	if len(tm.ColumnRenames) == 0 {
+		return nil
+	}
+	_, inSinkConfig := tm.SinkConfig["transforms"]
+	_, inOverrides := overrides["transforms"]
+	if inSinkConfig || inOverrides {
+		return fmt.Errorf("%s.%s: naming renomeia colunas (%s) pelo transform %s do sink; sinkConfig/connectorConfig.sink não podem redefinir transforms",
+			tm.Schema, tm.Name, strings.Join(tm.ColumnRenames, ","), renameTransform)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/grouping"
+	"ih-ingestion/internal/repo"
+)
+
+// groupTables separa as tabelas por mode/size/cluster (na ordem em que aparecem) e agrupa
+// cada partição com a estratégia do alias: um source nunca mistura modos, tamanhos ou
+// clusters Kafka Connect.
+func groupTables(tables []tableMeta, strategy grouping.Strategy, limits grouping.Limits, related [][2]string) []sourceGroup {
+	var keys []string
+	parts := map[string][]tableMeta{}
+	for _, t := range tables {
+		k := t.Mode + "-" + t.Size + "|" + t.Cluster
+		if _, ok := parts[k]; !ok {
+			keys = append(keys, k)
+		}
+		parts[k] = append(parts[k], t)
+	}
+
+	var out []sourceGroup
+	for _, k := range keys {
+		part := parts[k]
+		items := make([]grouping.Item, len(part))
+		for i, t := range part {
+			items[i] = grouping.Item{
+				Key:      t.Schema + "." + t.Name,
+				Rows:     t.RowCount,
+				Changes:  t.Changes,
+				Affinity: t.Affinity,
+			}
+		}
+
+		for _, g := range strategy.Group(items, limits, related) {
+			sg := sourceGroup{
+				Mode:         part[0].Mode,
+				Size:         part[0].Size,
+				Cluster:      part[0].Cluster,
+				TotalRows:    g.Rows,
+				TotalChanges: g.Changes,
+			}
+			for _, i := range g.Items {
+				sg.Tables = append(sg.Tables, part[i])
+			}
+			out = append(out, sg)
+		}
+	}
+	return out
+}
+
+// groupFullness descreve a ocupação de cada grupo em relação aos limites do alias.
+func groupFullness(groups []sourceGroup, limits grouping.Limits) []string {
+	lines := make([]string, 0, len(groups))
+	for i, g := range groups {
+		parts := []string{
+			fullness("tabelas", int64(len(g.Tables)), int64(limits.MaxTables)),
+			fullness("linhas", g.TotalRows, limits.MaxRows),
+		}
+		if limits.MaxChanges > 0 || g.TotalChanges > 0 {
+			parts = append(parts, fullness("alterações/dia", g.TotalChanges, limits.MaxChanges))
+		}
+		cluster := g.Cluster
+		if cluster == "" {
+			cluster = "default"
+		}
+		lines = append(lines, fmt.Sprintf("grupo %d (%s-%s, cluster %s): %s",
+			i+1, g.Mode, g.Size, cluster, strings.Join(parts, ", ")))
+	}
+	return lines
+}
+
+func fullness(label string, used, limit int64) string {
+	if limit <= 0 {
+		return fmt.Sprintf("%s %d (sem limite)", label, used)
+	}
+	pct := float64(used) * 100 / float64(limit)
+	s := fmt.Sprintf("%s %d/%d (%.0f%%)", label, used, limit, pct)
+	if used > limit {
+		s += " ACIMA DO LIMITE"
+	}
+	return s
+}
+
+// windowStr mostra o período observado pela estimativa de alterações (vazio se não houver).
+func windowStr(w time.Duration) string {
+	if w <= 0 {
+		return ""
+	}
+	if w >= 48*time.Hour {
+		return fmt.Sprintf(", janela %.0fd", w.Hours()/24)
+	}
+	return fmt.Sprintf(", janela %.0fh", w.Hours())
+}
+
+// sinkBuffer são as propriedades de buffer da classe que o sinkConfig da tabela não sobrescreve.
+func sinkBuffer(class config.SizeClass, sinkConfig map[string]string) map[string]string {
+	out := class.SinkBuffer()
+	for k := range sinkConfig {
+		delete(out, k)
+	}
+	return out
+}
+
+// previousSizes lê as classes registradas pela geração anterior da wave (manifesto
+// ausente = primeira geração).
+func previousSizes(path string) (map[string]string, error) {
+	m, err := repo.LoadWaveManifest(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("classes da geração anterior: %w", err)
+	}
+	return m.Sizes, nil
+}
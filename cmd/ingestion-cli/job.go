@@ -0,0 +1,17 @@
+package main
+
+import (
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/model"
+)
+
+// applyJobSettings preenche o container do job (imagem, comando, securityContext)
+// a partir das configurações já resolvidas com config.ResolveJobSettings.
+func applyJobSettings(cfg *model.SnowflakeJobConfig, j config.JobSettings) {
+	cfg.Image = j.Image
+	cfg.ImagePullPolicy = j.ImagePullPolicy
+	cfg.Command = j.Command
+	cfg.Args = j.Args
+	cfg.SecurityContext = j.SecurityContext
+	cfg.PodSecurityContext = j.PodSecurityContext
+}
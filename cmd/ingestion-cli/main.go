@@ -1,36 +1,73 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/joho/godotenv"
 
+	"ih-ingestion/internal/capacity"
 	"ih-ingestion/internal/config"
 	"ih-ingestion/internal/generator"
 	"ih-ingestion/internal/gitops"
+	"ih-ingestion/internal/governance"
+	"ih-ingestion/internal/grouping"
 	"ih-ingestion/internal/kustomize"
 	"ih-ingestion/internal/model"
 	"ih-ingestion/internal/repo"
+	"ih-ingestion/internal/snowflake"
 	"ih-ingestion/internal/sqlserver"
-	"ih-ingestion/internal/templates"
+	"ih-ingestion/internal/validate"
 )
 
+// tasksMax do source: o conector SQL Server usa uma task por database
+const defaultTasksMax = 1
+
 type tableMeta struct {
 	Name        string
 	Schema      string
 	RowCount    int64
+	Changes     int64  // alterações estimadas por dia (grouping change-volume)
+	ChangesFrom string // origem da estimativa (config, cdc, index, none)
+	Affinity    string // tabelas com a mesma affinity ficam no mesmo source
 	BusinessDDL string
+
+	// efetivos (override da tabela ou valor da execução)
+	Mode         string
+	Size         string
+	TargetSchema string
+	TargetTable  string
+	Stage        string
+	SinkConfig   map[string]string
+
+	ColumnRenames []string // origem:destino das colunas renomeadas pela política naming
+
+	ConnectorConfig config.ConnectorConfig // connectorConfig da tabela (sink; source junto com as demais do grupo)
+
+	ExcludedColumns []string            // nomes reais (column.exclude.list)
+	MaskColumns     []config.ColumnMask // já resolvidas (column.mask.*)
+	Classifications map[string][]string // coluna real -> classificações
+	Grants          config.Grants       // grants só desta tabela
+
+	Dest    config.SnowflakeDest // database, landing zone e secrets do destino
+	Cluster string               // chave do cluster Kafka Connect ("" = default)
 }
 
 type sourceGroup struct {
-	Tables    []tableMeta
-	TotalRows int64
+	Mode         string
+	Size         string
+	Cluster      string
+	Tables       []tableMeta
+	TotalRows    int64
+	TotalChanges int64
 }
 
 func main() {
@@ -45,21 +82,60 @@ func main() {
 	envPath := filepath.Join(execDir, ".env")
 	_ = godotenv.Load(envPath)
 
+	// Subcomandos
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "promote":
+			runPromoteCommand(os.Args[2:], execDir)
+			return
+		case "snowflake-apply":
+			runSnowflakeApplyCommand(os.Args[2:])
+			return
+		case "templates":
+			runTemplatesCommand(os.Args[2:])
+			return
+		case "validate":
+			runValidateCommand(os.Args[2:])
+			return
+		}
+	}
+
 	// Flags
 	configFlag := flag.String("config", "", "caminho para arquivo YAML de ingestão (vários bancos/tabelas). Se vazio, tenta ingestion.yaml ao lado do binário")
 	schema := flag.String("schema", "dbo", "schema da tabela de origem (modo single)")
 	table := flag.String("table", "", "nome da tabela de origem (modo single)")
 	group := flag.String("group", "grupo1", "nome lógico do grupo/wave de tabelas (ex: grupo1)")
 	mode := flag.String("mode", "online", "modo: online ou batch (usado em nomes de connectors/arquivos)")
-	size := flag.String("size", "m", "tamanho: p/m/g (usado em nomes de connectors/arquivos)")
+	size := flag.String("size", "m", "tamanho: p/m/g (nomes e recursos dos connectors; sizing.auto classifica as tabelas sem size)")
 	outDirFlag := flag.String("out", "./apps", "no modo GitOps: subpasta apps/ dentro do repo. No modo local: pasta base onde serão criadas source/sink/jobs.")
 	dryRun := flag.Bool("dry-run", false, "se verdadeiro, não grava arquivos nem faz git push; apenas mostra o que seria feito")
+	templatesDir := flag.String("templates-dir", "", "diretório com templates que substituem os embutidos (<nome>.yaml.tmpl; ver `templates dump`). Sobrescreve templatesDir do YAML")
+	envFlag := flag.String("env", "", "ambiente alvo: development, homolog ou production (default: IH_ENV; obrigatório se IH_ENV não estiver definido)")
+	var sets stringList
+	flag.Var(&sets, "set", "sobrescreve um valor do profile do ambiente (chave=valor, ex: snowflake.role=MY_ROLE). Pode repetir.")
 
 	maxTablesPerSource := flag.Int("max-tables-per-source", 0, "máximo de tabelas por source connector (0 = ilimitado, pode ser sobrescrito por alias no YAML)")
 	maxRowsPerSource := flag.Int64("max-rows-per-source", 0, "máximo de linhas totais por source connector (0 = ignorar rowcount, pode ser sobrescrito por alias no YAML)")
 
 	flag.Parse()
 
+	if !config.IsValidMode(*mode) {
+		log.Fatalf("flag -mode inválida: %s (use %s)", *mode, strings.Join(config.ValidModes, "/"))
+	}
+	if !config.IsValidSize(*size) {
+		log.Fatalf("flag -size inválida: %s (use %s)", *size, strings.Join(config.ValidSizes, "/"))
+	}
+
+	envName, err := config.ResolveEnvName(*envFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	overrides, err := config.ParseOverrides(sets)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Resolve configPath: flag > ingestion.yaml ao lado do binário
 	finalConfigPath := *configFlag
 	if finalConfigPath == "" {
@@ -69,27 +145,15 @@ func main() {
 		}
 	}
 
-	// Carrega config GitOps (se existir)
-	gitCfg, err := gitops.LoadConfigFromEnv()
-	if err != nil {
-		log.Fatalf("erro carregando configuração de GitOps: %v", err)
-	}
+	gitCfg, gitEnabled := loadGitOps()
 
-	// IH_GITOPS_ENABLED controla explicitamente o modo
-	gitopsFlag := strings.ToLower(strings.TrimSpace(os.Getenv("IH_GITOPS_ENABLED")))
-	var gitEnabled bool
-
-	switch gitopsFlag {
-	case "true", "1", "yes", "y":
-		if gitCfg == nil {
-			log.Fatalf("IH_GITOPS_ENABLED=true mas GIT_REPO_URL não está configurado (GitOps não pode ser usado)")
+	// Provider de PR/MR (opcional, via GIT_PR_PROVIDER)
+	var prProvider gitops.PullRequestProvider
+	if gitEnabled {
+		prProvider, err = gitops.NewPullRequestProvider(gitCfg)
+		if err != nil {
+			log.Fatalf("erro configurando abertura de PR: %v", err)
 		}
-		gitEnabled = true
-	case "false", "0", "no", "n":
-		gitEnabled = false
-	default:
-		// modo automático: se há GIT_REPO_URL, usa GitOps; senão, modo local/out
-		gitEnabled = (gitCfg != nil)
 	}
 
 	// ---------------------
@@ -97,11 +161,19 @@ func main() {
 	// ---------------------
 	if finalConfigPath != "" {
 		var baseDir string
-		var repoPath, branchName string
+		var gitRepo *gitops.Repo
+
+		// log.Fatalf não executa defers: libera o lock do checkout antes de sair
+		fatalf := func(format string, args ...any) {
+			if err := gitRepo.Close(); err != nil {
+				log.Printf("aviso: %v", err)
+			}
+			log.Fatalf(format, args...)
+		}
 
 		if gitEnabled && !*dryRun {
-			// Prepara repo Git (clone/update + branch)
-			repoPath, branchName, err = gitops.PrepareRepo(gitCfg, execDir, *group)
+			// Prepara repo Git (lock + clone/update + branch)
+			gitRepo, err = gitops.PrepareRepo(gitCfg, execDir, *group)
 			if err != nil {
 				log.Fatalf("erro preparando repositório GitOps: %v", err)
 			}
@@ -110,7 +182,7 @@ func main() {
 			if filepath.IsAbs(*outDirFlag) {
 				baseDir = *outDirFlag
 			} else {
-				baseDir = filepath.Join(repoPath, *outDirFlag) // normalmente repo/apps
+				baseDir = filepath.Join(gitRepo.Path, *outDirFlag) // normalmente repo/apps
 			}
 		} else {
 			// Sem GitOps: baseDir relativo à pasta do executável (se não for absoluto)
@@ -122,21 +194,56 @@ func main() {
 		}
 
 		log.Printf(
-			"Iniciando modo config: configPath=%s group=%s mode=%s size=%s baseDir=%s dryRun=%v maxTablesPerSource(flag)=%d maxRowsPerSource(flag)=%d gitEnabled=%v",
-			finalConfigPath, *group, *mode, *size, baseDir, *dryRun, *maxTablesPerSource, *maxRowsPerSource, gitEnabled,
+			"Iniciando modo config: configPath=%s env=%s group=%s mode=%s size=%s baseDir=%s dryRun=%v maxTablesPerSource(flag)=%d maxRowsPerSource(flag)=%d gitEnabled=%v",
+			finalConfigPath, envName, *group, *mode, *size, baseDir, *dryRun, *maxTablesPerSource, *maxRowsPerSource, gitEnabled,
 		)
 
-		if err := runFromConfig(finalConfigPath, *group, *mode, *size, baseDir, *dryRun, *maxTablesPerSource, *maxRowsPerSource, gitEnabled); err != nil {
-			log.Fatalf("erro no modo config: %v", err)
+		generate := func() (*gitops.WaveSummary, error) {
+			generator.ResetTracking()
+			return runFromConfig(finalConfigPath, envName, overrides, *group, *mode, *size, baseDir, *templatesDir, *dryRun, *maxTablesPerSource, *maxRowsPerSource, gitEnabled)
+		}
+
+		summary, err := generate()
+		if err != nil {
+			fatalf("erro no modo config: %v", err)
 		}
 
 		// Se GitOps estiver habilitado e não for dry-run, faz commit/push
 		if gitEnabled && !*dryRun {
+			// Rebase com conflito em arquivo gerado: regenera sobre a base nova
+			regenerate := func() ([]string, error) {
+				s, err := generate()
+				if err != nil {
+					return nil, err
+				}
+				summary = s
+				return generator.TrackedPaths(), nil
+			}
+
 			msg := fmt.Sprintf("Ingestion wave %s", *group)
-			if err := gitops.CommitAndPush(repoPath, branchName, msg); err != nil {
-				log.Fatalf("erro ao fazer commit/push GitOps: %v", err)
+			pushed, err := gitRepo.CommitAndPush(msg, generator.TrackedPaths(), regenerate)
+			if err != nil {
+				fatalf("erro ao fazer commit/push GitOps: %v", err)
+			}
+			log.Printf("GitOps concluído com sucesso. Branch: %s", gitRepo.Branch)
+
+			// PR/MR da branch da wave para BaseBranch
+			if prProvider != nil && pushed {
+				pr := gitops.BuildPullRequest(gitCfg, gitRepo.Branch, *summary)
+				prURL, err := prProvider.CreatePullRequest(context.Background(), pr)
+				switch {
+				case errors.Is(err, gitops.ErrPullRequestExists):
+					log.Printf("PR já existente para a branch %s (%s); push atualizou o PR aberto", gitRepo.Branch, prProvider.Name())
+				case err != nil:
+					fatalf("erro ao abrir PR (%s): %v", prProvider.Name(), err)
+				default:
+					log.Printf("PR aberto (%s): %s", prProvider.Name(), prURL)
+				}
+			}
+
+			if err := gitRepo.Close(); err != nil {
+				log.Printf("aviso: %v", err)
 			}
-			log.Printf("GitOps concluído com sucesso. Branch: %s", branchName)
 		}
 
 		return
@@ -158,13 +265,58 @@ func main() {
 	log.Printf("Iniciando modo single: schema=%s table=%s group=%s mode=%s size=%s outDir=%s dryRun=%v",
 		*schema, *table, *group, *mode, *size, outBaseDir, *dryRun)
 
-	if err := runSingleTable(*schema, *table, *group, *mode, *size, outBaseDir, *dryRun); err != nil {
+	if err := runSingleTable(envName, overrides, *schema, *table, *group, *mode, *size, outBaseDir, *templatesDir, *dryRun); err != nil {
 		log.Fatalf("erro no modo single: %v", err)
 	}
 }
 
+// stringList acumula flags repetidas (ex: -set a=1 -set b=2).
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// loadGitOps carrega a config GitOps (se existir) e decide se o modo GitOps está ativo.
+func loadGitOps() (*gitops.Config, bool) {
+	gitCfg, err := gitops.LoadConfigFromEnv()
+	if err != nil {
+		log.Fatalf("erro carregando configuração de GitOps: %v", err)
+	}
+
+	// IH_GITOPS_ENABLED controla explicitamente o modo
+	gitopsFlag := strings.ToLower(strings.TrimSpace(os.Getenv("IH_GITOPS_ENABLED")))
+
+	switch gitopsFlag {
+	case "true", "1", "yes", "y":
+		if gitCfg == nil {
+			log.Fatalf("IH_GITOPS_ENABLED=true mas GIT_REPO_URL não está configurado (GitOps não pode ser usado)")
+		}
+		return gitCfg, true
+	case "false", "0", "no", "n":
+		return gitCfg, false
+	default:
+		// modo automático: se há GIT_REPO_URL, usa GitOps; senão, modo local/out
+		return gitCfg, gitCfg != nil
+	}
+}
+
 // Modo antigo / single: usa SQLSERVER_HOST/USER/PASSWORD/DATABASE
-func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool) error {
+func runSingleTable(envName string, overrides map[string]string, schema, table, group, mode, size, outDir, templatesDir string, dryRun bool) error {
+	// sem ingestion.yaml: profile só por flags -set e envs
+	profile, err := config.ResolveProfile(nil, envName, overrides)
+	if err != nil {
+		return err
+	}
+
+	tpls, err := loadTemplates(templatesDir)
+	if err != nil {
+		return err
+	}
+
 	db, dbName, err := sqlserver.NewFromEnv()
 	if err != nil {
 		return fmt.Errorf("conectando no SQL Server: %w", err)
@@ -176,20 +328,25 @@ func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool
 		return fmt.Errorf("lendo colunas: %w", err)
 	}
 
-	businessDDL := sqlserver.BuildBusinessColumnsDDL(cols)
-
-	clusterName := config.GetEnvOrDefault("CONNECT_CLUSTER_NAME", "inthub-prd")
-	schemaRegistryURL := config.GetEnvOrDefault(
-		"SCHEMA_REGISTRY_URL",
-		"http://schema-registry-ih.kafka-admin:8081",
-	)
-
 	dbNameLower := strings.ToLower(dbName)
 	dbNameUpper := strings.ToUpper(dbName)
 	schemaLower := strings.ToLower(schema)
 	tableUpper := strings.ToUpper(table)
 	tableLower := strings.ToLower(table)
 
+	if err := snowflake.LintTable(snowflake.TableTarget{
+		Origin: fmt.Sprintf("%s.%s", schema, table),
+		Schema: dbNameUpper,
+		Table:  tableUpper,
+		Stage:  tableUpper,
+	}, cols); err != nil {
+		return err
+	}
+	businessDDL := sqlserver.BuildBusinessColumnsDDL(cols)
+
+	clusterName := profile.ClusterName
+	schemaRegistryURL := profile.SchemaRegistryURL
+
 	// Source
 	sourceName := fmt.Sprintf(
 		"source-debeziumsqlserver-%s-%s-%s-%s-%s",
@@ -209,11 +366,15 @@ func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool
 	}
 	port := config.GetEnvOrDefault("SQLSERVER_PORT", "1433")
 	dbSecret := config.GetEnvOrDefault("SQLSERVER_SECRET_NAME", "sqlserver-origem-sqlcrmp")
-	shBootstrap := config.GetEnvOrDefault("SCHEMA_HISTORY_BOOTSTRAP_SERVERS", "kafka01:9092,kafka02:9092,kafka03:9092")
+	shBootstrap := profile.SchemaHistoryBootstrapServers
+
+	// sem ingestion.yaml: recursos da classe -size pelos defaults
+	class := config.ResolveSizing(config.Sizing{}).Class(size)
 
 	sourceCfg := model.SourceConfig{
 		Name:                          sourceName,
 		ClusterName:                   clusterName,
+		TasksMax:                      defaultTasksMax,
 		DatabaseHost:                  host,
 		DatabasePort:                  port,
 		DatabaseSecret:                dbSecret,
@@ -223,16 +384,15 @@ func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool
 		SchemaHistoryBootstrapServers: shBootstrap,
 		SchemaHistoryTopic:            schemaHistoryTopic,
 		SchemaRegistryURL:             schemaRegistryURL,
+		TopicPartitions:               class.Partitions,
+		SnapshotMaxThreads:            class.SnapshotMaxThreads,
 	}
 
 	// Sink / Snowflake
-	snowJdbc := config.GetEnvOrDefault(
-		"SNOWFLAKE_JDBC_URL",
-		"jdbc:snowflake://seuaccount.snowflakecomputing.com?schema=CRMB001D&db=LZ_SQL_IH_PRD&warehouse=WH_IH_PROD&CLIENT_SESSION_KEEP_ALIVE=TRUE&tracing=WARNING",
-	)
-	snowUserSecret := config.GetEnvOrDefault("SNOWFLAKE_USER_SECRET", "snowflake-creds")
-	snowPassSecret := config.GetEnvOrDefault("SNOWFLAKE_PASSWORD_SECRET", "snowflake-creds")
-	logicalDB := config.GetEnvOrDefault("SNOWFLAKE_DB_LOGICAL", "lz-sql-ih-prd")
+	snowJdbc := profile.SnowflakeJDBCURL
+	snowUserSecret := profile.SnowflakeUserSecret
+	snowPassSecret := profile.SnowflakePasswordSecret
+	logicalDB := profile.SnowflakeLogical
 
 	topicName := fmt.Sprintf(
 		"%s.%s.%s.%s",
@@ -252,6 +412,7 @@ func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool
 	sinkCfg := model.SinkConfig{
 		Name:                    sinkName,
 		ClusterName:             clusterName,
+		TasksMax:                class.SinkTasksMax,
 		TopicName:               topicName,
 		SnowflakeURL:            snowJdbc,
 		SnowflakeUserSecret:     snowUserSecret,
@@ -259,28 +420,36 @@ func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool
 		Stage:                   tableUpper,
 		Table:                   tableUpper,
 		Schema:                  dbNameUpper,
+		SchemaRegistryURL:       schemaRegistryURL,
+		Buffer:                  class.SinkBuffer(),
 	}
 
 	// Job Snowflake
 	jobName := fmt.Sprintf("lz-sql-ih-%s-%s-v1", dbNameLower, tableLower)
 	sqlConfigMapName := fmt.Sprintf("lz-sql-ih-%s-%s-sql", dbNameLower, tableLower)
 
-	connCfgMap := config.GetEnvOrDefault("SNOWFLAKE_CONN_CONFIGMAP", "lz-sql-ih-connection")
-	role := config.GetEnvOrDefault("SNOWFLAKE_ROLE", "SNFLK_INTEGRATION_HUB_ROLE")
-	sfDatabase := config.GetEnvOrDefault("SNOWFLAKE_DATABASE", "LZ_SQL_IH")
+	credsSecret := profile.SnowflakeCredsSecret
+	role := profile.SnowflakeRole
+	sfDatabase := profile.SnowflakeDatabase
 
 	jobCfg := model.SnowflakeJobConfig{
-		JobName:             jobName,
-		ConnectionConfigMap: connCfgMap,
-		SqlConfigMapName:    sqlConfigMapName,
-		Role:                role,
-		Database:            sfDatabase,
-		Schema:              dbNameUpper,
-		TableIngest:         fmt.Sprintf("%s_INGEST", tableUpper),
-		TableFinal:          tableUpper,
-		StageName:           tableUpper,
-		BusinessColumnsDDL:  businessDDL,
+		JobName:            jobName,
+		CredentialsSecret:  credsSecret,
+		SqlConfigMapName:   sqlConfigMapName,
+		Role:               role,
+		Database:           sfDatabase,
+		Schema:             dbNameUpper,
+		TableIngest:        fmt.Sprintf("%s_INGEST", tableUpper),
+		TableFinal:         tableUpper,
+		StageName:          tableUpper,
+		BusinessColumnsDDL: businessDDL,
 	}
+	// sem ingestion.yaml: container default (imagem do profile, se houver)
+	jobSettings, err := config.ResolveJobSettings(config.JobSettings{}, profile)
+	if err != nil {
+		return err
+	}
+	applyJobSettings(&jobCfg, jobSettings)
 
 	// Paths
 	srcPath := fmt.Sprintf("%s/source-%s-%s.yaml", outDir, dbNameLower, tableLower)
@@ -298,13 +467,13 @@ func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool
 	}
 
 	// Render
-	if err := generator.RenderToFile(templates.SourceTemplate, sourceCfg, srcPath); err != nil {
+	if err := writeSource(tpls, sourceCfg, nil, srcPath); err != nil {
 		return fmt.Errorf("gerando source: %w", err)
 	}
-	if err := generator.RenderToFile(templates.SinkTemplate, sinkCfg, sinkPath); err != nil {
+	if err := writeSink(tpls, sinkCfg, nil, sinkPath); err != nil {
 		return fmt.Errorf("gerando sink: %w", err)
 	}
-	if err := generator.RenderToFile(templates.SnowflakeJobTemplate, jobCfg, jobPath); err != nil {
+	if err := writeJob(tpls, jobCfg, jobSettings, jobPath); err != nil {
 		return fmt.Errorf("gerando job: %w", err)
 	}
 
@@ -317,77 +486,112 @@ func runSingleTable(schema, table, group, mode, size, outDir string, dryRun bool
 //   - no GitOps: caminho da pasta apps dentro do repo
 //   - no modo local: pasta base (ex: ./out)
 func runFromConfig(
-	configPath, group, mode, size, baseDir string,
+	configPath, envName string,
+	overrides map[string]string,
+	group, mode, size, baseDir, templatesDir string,
 	dryRun bool,
 	maxTablesPerSourceFlag int,
 	maxRowsPerSourceFlag int64,
 	useArgoLayout bool,
-) error {
+) (*gitops.WaveSummary, error) {
 	cfgYaml, err := config.LoadIngestionConfig(configPath)
 	if err != nil {
-		return fmt.Errorf("carregando config YAML: %w", err)
+		return nil, fmt.Errorf("carregando config YAML: %w", err)
 	}
 
 	if err := config.ValidateIngestionConfig(cfgYaml); err != nil {
-		return fmt.Errorf("ingestion.yaml inválido: %w", err)
+		return nil, fmt.Errorf("ingestion.yaml inválido: %w", err)
 	}
 
-	if err := config.ValidateEnvForAliases(cfgYaml); err != nil {
-		return fmt.Errorf("validação de envs: %w", err)
+	if err := config.ValidateEnvForAliases(cfgYaml, envName); err != nil {
+		return nil, fmt.Errorf("validação de envs: %w", err)
 	}
 
-	clusterName := config.GetEnvOrDefault("CONNECT_CLUSTER_NAME", "inthub-prd")
-	snowJdbc := config.GetEnvOrDefault(
-		"SNOWFLAKE_JDBC_URL",
-		"jdbc:snowflake://seuaccount.snowflakecomputing.com?schema=CRMB001D&db=LZ_SQL_IH_PRD&warehouse=WH_IH_PROD&CLIENT_SESSION_KEEP_ALIVE=TRUE&tracing=WARNING",
-	)
-	snowUserSecret := config.GetEnvOrDefault("SNOWFLAKE_USER_SECRET", "snowflake-creds")
-	snowPassSecret := config.GetEnvOrDefault("SNOWFLAKE_PASSWORD_SECRET", "snowflake-creds")
-	logicalDB := config.GetEnvOrDefault("SNOWFLAKE_DB_LOGICAL", "lz-sql-ih-prd")
-
-	connCfgMap := config.GetEnvOrDefault("SNOWFLAKE_CONN_CONFIGMAP", "lz-sql-ih-connection")
-	role := config.GetEnvOrDefault("SNOWFLAKE_ROLE", "SNFLK_INTEGRATION_HUB_ROLE")
-	sfDatabase := config.GetEnvOrDefault("SNOWFLAKE_DATABASE", "LZ_SQL_IH")
-	shBootstrap := config.GetEnvOrDefault("SCHEMA_HISTORY_BOOTSTRAP_SERVERS", "kafka01:9092,kafka02:9092,kafka03:9092")
-	schemaRegistryURL := config.GetEnvOrDefault(
-		"SCHEMA_REGISTRY_URL",
-		"http://schema-registry-ih.kafka-admin:8081",
-	)
+	profile, err := config.ResolveProfile(cfgYaml, envName, overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	logicalDB := profile.SnowflakeLogical
 
-	envName := config.GetEnvOrDefault("IH_ENV", "production")
+	// templates: -templates-dir > templatesDir do YAML (relativo ao ingestion.yaml)
+	if templatesDir == "" && cfgYaml.TemplatesDir != "" {
+		templatesDir = cfgYaml.TemplatesDir
+		if !filepath.IsAbs(templatesDir) {
+			templatesDir = filepath.Join(filepath.Dir(configPath), templatesDir)
+		}
+	}
+	tpls, err := loadTemplates(templatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	jobSettings, err := config.ResolveJobSettings(cfgYaml.Job, profile)
+	if err != nil {
+		return nil, err
+	}
+	sizing := config.ResolveSizing(cfgYaml.Sizing)
+	groupConnectorConfig := cfgYaml.Groups[group].ConnectorConfig
+	role := profile.SnowflakeRole
+	shBootstrap := profile.SchemaHistoryBootstrapServers
+	schemaRegistryURL := profile.SchemaRegistryURL
 
 	layout := repo.NewLayout(baseDir, envName, "debeziumsqlserver", logicalDB, useArgoLayout)
 
-	// 🔒 Segurança de layout:
-	// - GitOps (ArgoStyle=true): roots DEVEM existir (apps/<...>), senão erro
-	// - Local/out (ArgoStyle=false): roots são criados se não existirem
-	if layout.ArgoStyle {
-		rootMap := map[string]string{
-			"sourceRoot": layout.SourceRoot(),
-			"sinkRoot":   layout.SinkRoot(),
-			"jobRoot":    layout.JobRoot(),
-		}
+	summary := &gitops.WaveSummary{Group: group, Env: envName}
+	manifest := &repo.WaveManifest{Group: group, Env: envName, SnowflakeLogical: logicalDB, Sizes: map[string]string{}}
 
-		for name, p := range rootMap {
-			fi, err := os.Stat(p)
-			if err != nil {
-				return fmt.Errorf("layout inválido: diretório base %s não encontrado (%s): %w", name, p, err)
-			}
-			if !fi.IsDir() {
-				return fmt.Errorf("layout inválido: %s existe mas não é diretório: %s", name, p)
-			}
+	if err := checkLayoutRoots(layout, dryRun); err != nil {
+		return nil, err
+	}
+
+	// sizing.auto: a classe de uma tabela já gerada fica fixada (está nos nomes)
+	prevSizes, err := previousSizes(layout.WaveManifestPath(group))
+	if err != nil {
+		return nil, err
+	}
+
+	// capacidade dos clusters Kafka Connect: connectors já no repo + os gerados agora
+	tracker := capacity.NewTracker()
+	defaultCluster, err := profile.ConnectCluster("")
+	if err != nil {
+		return nil, err
+	}
+	if err := trackCluster(tracker, defaultCluster, layout); err != nil {
+		return nil, err
+	}
+
+	// landing zones (logical) e clusters usados pelas tabelas: cada par tem suas raízes
+	layouts := map[string]repo.Layout{logicalDB + "|": layout}
+	layoutFor := func(logical, clusterKey string) (repo.Layout, error) {
+		if l, ok := layouts[logical+"|"+clusterKey]; ok {
+			return l, nil
 		}
-	} else if !dryRun {
-		// modo local: garante que as raízes existam
-		for _, root := range []string{layout.SourceRoot(), layout.SinkRoot(), layout.JobRoot()} {
-			if err := os.MkdirAll(root, 0o755); err != nil {
-				return fmt.Errorf("criando diretório base %s: %w", root, err)
-			}
+		l := repo.NewLayout(baseDir, envName, "debeziumsqlserver", logical, useArgoLayout).WithCluster(clusterKey)
+		if err := checkLayoutRoots(l, dryRun); err != nil {
+			return l, err
+		}
+		cluster, err := profile.ConnectCluster(clusterKey)
+		if err != nil {
+			return l, err
+		}
+		if err := trackCluster(tracker, cluster, l); err != nil {
+			return l, err
 		}
+		layouts[logical+"|"+clusterKey] = l
+		if logical != logicalDB && !slices.Contains(manifest.Logicals, logical) {
+			manifest.Logicals = append(manifest.Logicals, logical)
+		}
+		if clusterKey != "" && !slices.Contains(manifest.Clusters, clusterKey) {
+			manifest.Clusters = append(manifest.Clusters, clusterKey)
+		}
+		return l, nil
 	}
 
 	totalTables := 0
 	totalSources := 0
+	bundleRoots := map[string]bool{} // raízes cujo kustomization foi atualizado
+	targets := config.TargetSet{}    // destino Snowflake -> tabela de origem, entre todos os aliases
 
 	for _, srv := range cfgYaml.SqlServers {
 		dbNameLower := strings.ToLower(srv.Database)
@@ -402,19 +606,56 @@ func runFromConfig(
 		if srv.MaxRowsPerSource > 0 {
 			effMaxRows = srv.MaxRowsPerSource
 		}
+		limits := grouping.Limits{MaxTables: effMaxTables, MaxRows: effMaxRows, MaxChanges: srv.MaxChangesPerSource}
+		strategy, err := grouping.Get(srv.Grouping)
+		if err != nil {
+			return nil, fmt.Errorf("alias %s: %w", srv.Alias, err)
+		}
 
-		log.Printf("[alias=%s] database=%s schemaDefault=%s tables=%d maxTables=%d maxRows=%d",
-			srv.Alias, dbNameUpper, srv.Schema, len(srv.Tables), effMaxTables, effMaxRows)
+		summary.Aliases = append(summary.Aliases, srv.Alias)
 
 		// Conecta por alias
-		db, err := sqlserver.NewFromAlias(srv.Alias, srv.Database)
+		db, err := sqlserver.NewFromAlias(srv.Alias, srv.Database, profile)
 		if err != nil {
-			return fmt.Errorf("conectando alias %s: %w", srv.Alias, err)
+			return nil, fmt.Errorf("conectando alias %s: %w", srv.Alias, err)
 		}
 
+		// Expande regras include/exclude contra sys.tables
+		tables, discoveries, err := config.ResolveTables(srv, func(schema string, cdcOnly bool) ([]string, error) {
+			return sqlserver.ListTables(db, schema, cdcOnly)
+		})
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("descobrindo tabelas (%s): %w", srv.Alias, err)
+		}
+		for _, d := range discoveries {
+			log.Printf("[alias=%s] descoberta schema=%s include=%v exclude=%v cdcOnly=%v -> %d tabela(s): %s",
+				srv.Alias, d.Schema, d.Include, d.Exclude, d.CDCOnly, len(d.Tables), strings.Join(d.Tables, ", "))
+			manifest.Discovery = append(manifest.Discovery, repo.DiscoveryRecord{
+				Alias:   srv.Alias,
+				Schema:  d.Schema,
+				Include: d.Include,
+				Exclude: d.Exclude,
+				CDCOnly: d.CDCOnly,
+				Tables:  d.Tables,
+			})
+		}
+		if len(tables) == 0 {
+			db.Close()
+			return nil, fmt.Errorf("alias %s: nenhuma tabela após resolver tables (regras include/exclude não casaram)", srv.Alias)
+		}
+		// o ValidateConfig só enxerga as tabelas listadas por nome; as descobertas são checadas aqui
+		if err := claimTargets(targets, profile, cfgYaml.Naming, srv, tables); err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		log.Printf("[alias=%s] database=%s schemaDefault=%s tables=%d maxTables=%d maxRows=%d",
+			srv.Alias, dbNameUpper, srv.Schema, len(tables), effMaxTables, effMaxRows)
+
 		// Monta metadados de cada tabela (DDL + rowcount)
 		var metas []tableMeta
-		for _, t := range srv.Tables {
+		for _, t := range tables {
 			schemaName := srv.Schema
 			if strings.TrimSpace(t.Schema) != "" {
 				schemaName = strings.TrimSpace(t.Schema)
@@ -423,133 +664,276 @@ func runFromConfig(
 			cols, err := sqlserver.LoadColumns(db, schemaName, t.Name)
 			if err != nil {
 				db.Close()
-				return fmt.Errorf("lendo colunas %s.%s (%s): %w", schemaName, t.Name, srv.Alias, err)
+				return nil, fmt.Errorf("lendo colunas %s.%s (%s): %w", schemaName, t.Name, srv.Alias, err)
+			}
+
+			masks := make([]config.ColumnMask, 0, len(t.MaskColumns))
+			for _, m := range t.MaskColumns {
+				masks = append(masks, config.ResolveMask(m, srv.MaskSalt))
+			}
+			// tabela descoberta por regra: coluna ausente é ignorada
+			cols, excludedCols, appliedMasks, err := sqlserver.ApplyColumnRules(cols, t.ExcludeColumns, masks, !t.Discovered)
+			if err != nil {
+				db.Close()
+				return nil, fmt.Errorf("regras de coluna de %s.%s (%s): %w", schemaName, t.Name, srv.Alias, err)
+			}
+			if len(excludedCols) > 0 || len(appliedMasks) > 0 {
+				log.Printf("[alias=%s] %s.%s: colunas excluídas=%v mascaradas=%d", srv.Alias, schemaName, t.Name, excludedCols, len(appliedMasks))
+			}
+			classified, err := governance.ResolveColumns(cols, t.Classifications, !t.Discovered)
+			if err != nil {
+				db.Close()
+				return nil, fmt.Errorf("classificações de %s.%s (%s): %w", schemaName, t.Name, srv.Alias, err)
+			}
+			// nomes no Snowflake pela política naming (classificações seguem a coluna renomeada)
+			naming := config.ResolveNaming(cfgYaml.Naming, srv.Naming, t.Naming)
+			cols, classified, renames, err := renameColumns(cols, classified, naming, !t.Discovered)
+			if err != nil {
+				db.Close()
+				return nil, fmt.Errorf("nomes de %s.%s (%s): %w", schemaName, t.Name, srv.Alias, err)
+			}
+
+			dest := profile.SnowflakeDest(srv, t)
+			targetSchema := firstNonEmpty(dest.Schema, dbNameUpper)
+			targetTable := strings.ToUpper(firstNonEmpty(t.TargetTable, naming.TableName(t.Name)))
+			stage := strings.ToUpper(firstNonEmpty(t.Stage, targetTable))
+			if err := snowflake.LintTable(snowflake.TableTarget{
+				Origin: fmt.Sprintf("%s:%s.%s", srv.Alias, schemaName, t.Name),
+				Schema: targetSchema,
+				Table:  targetTable,
+				Stage:  stage,
+			}, cols); err != nil {
+				db.Close()
+				return nil, err
 			}
 			businessDDL := sqlserver.BuildBusinessColumnsDDL(cols)
 
+			// sizing.auto classifica tabelas sem size pelas métricas
+			autoSize := sizing.Auto && strings.TrimSpace(t.Size) == ""
+
+			// balanced distribui por linhas mesmo sem maxRows
 			var rowCount int64
-			if effMaxRows > 0 {
+			if effMaxRows > 0 || strategy.Name() == grouping.Balanced || autoSize {
 				rowCount, err = sqlserver.GetTableRowCount(db, schemaName, t.Name)
 				if err != nil {
 					db.Close()
-					return fmt.Errorf("obtendo rowcount de %s.%s (%s): %w", schemaName, t.Name, srv.Alias, err)
+					return nil, fmt.Errorf("obtendo rowcount de %s.%s (%s): %w", schemaName, t.Name, srv.Alias, err)
 				}
 			}
 
+			// alterações/dia: changeVolume do YAML ou estimativa pelas estatísticas do SQL Server
+			changes := sqlserver.ChangeEstimate{PerDay: t.ChangeVolume, Source: sqlserver.ChangeSourceConfig}
+			if t.ChangeVolume == 0 {
+				changes = sqlserver.ChangeEstimate{Source: sqlserver.ChangeSourceNone}
+				if limits.MaxChanges > 0 || strategy.Name() == grouping.ChangeVolume || autoSize {
+					changes, err = sqlserver.EstimateChanges(db, schemaName, t.Name)
+					if err != nil {
+						log.Printf("[alias=%s] WARN sem estimativa de alterações para %s.%s (changeVolume=0): %v", srv.Alias, schemaName, t.Name, err)
+					}
+				}
+			}
+			tableKey := fmt.Sprintf("%s:%s.%s", srv.Alias, schemaName, t.Name)
+			tableSize, sizeFrom := firstNonEmpty(t.Size, size), "size da tabela"
+			switch {
+			case autoSize:
+				tableSize, sizeFrom = sizing.Classify(config.TableMetrics{
+					Rows:          rowCount,
+					ChangesPerDay: changes.PerDay,
+					RowBytes:      sqlserver.EstimateRowBytes(cols),
+				})
+				// cruzar um limite depois da primeira geração renomearia arquivos, connectors
+				// e tópico (novo snapshot): mantém a classe registrada e só avisa
+				if prev, ok := prevSizes[tableKey]; ok {
+					if prev != tableSize {
+						log.Printf("[alias=%s] WARN %s.%s: métricas indicam size=%s (%s), mantida a classe %s da geração anterior; para mudar, declare size na tabela",
+							srv.Alias, schemaName, t.Name, tableSize, sizeFrom, prev)
+					}
+					tableSize, sizeFrom = prev, "classe da geração anterior (manifesto da wave)"
+				}
+			case strings.TrimSpace(t.Size) == "":
+				sizeFrom = "flag -size"
+			}
+			manifest.Sizes[tableKey] = tableSize
+			log.Printf("[alias=%s] %s.%s: rows=%d alterações/dia=%d (origem=%s%s) size=%s (%s)",
+				srv.Alias, schemaName, t.Name, rowCount, changes.PerDay, changes.Source, windowStr(changes.Window), tableSize, sizeFrom)
+
 			metas = append(metas, tableMeta{
-				Name:        t.Name,
-				Schema:      schemaName,
-				RowCount:    rowCount,
-				BusinessDDL: businessDDL,
+				Name:         t.Name,
+				Schema:       schemaName,
+				RowCount:     rowCount,
+				Changes:      changes.PerDay,
+				ChangesFrom:  changes.Source,
+				Affinity:     t.Affinity,
+				BusinessDDL:  businessDDL,
+				Mode:         firstNonEmpty(t.Mode, mode),
+				Size:         tableSize,
+				TargetSchema: targetSchema,
+				TargetTable:  targetTable,
+				Stage:        stage,
+				Dest:         dest,
+				Cluster:      config.ConnectClusterFor(srv, t),
+				SinkConfig:   t.SinkConfig,
+
+				ColumnRenames:   renames,
+				ConnectorConfig: t.ConnectorConfig,
+
+				ExcludedColumns: excludedCols,
+				MaskColumns:     appliedMasks,
+				Classifications: classified,
+				Grants:          t.Grants,
 			})
 			totalTables++
 		}
 
-		groups := groupTablesIntoSources(metas, effMaxTables, effMaxRows)
-		log.Printf("[alias=%s] grupos de source criados: %d (maxTables=%d, maxRows=%d)",
-			srv.Alias, len(groups), effMaxTables, effMaxRows)
+		// affinity: FKs entre tabelas do database mantêm as tabelas no mesmo source
+		var related [][2]string
+		if strategy.Name() == grouping.Affinity {
+			related, err = sqlserver.ListForeignKeys(db)
+			if err != nil {
+				db.Close()
+				return nil, fmt.Errorf("lendo FKs (%s): %w", srv.Alias, err)
+			}
+		}
+
+		groups := groupTables(metas, strategy, limits, related)
+		log.Printf("[alias=%s] grupos de source criados: %d (grouping=%s maxTables=%d, maxRows=%d, maxChanges=%d)",
+			srv.Alias, len(groups), strategy.Name(), effMaxTables, effMaxRows, limits.MaxChanges)
+		for _, line := range groupFullness(groups, limits) {
+			log.Printf("[alias=%s] %s", srv.Alias, line)
+		}
 
 		dbDefaultSchemaLower := strings.ToLower(strings.TrimSpace(srv.Schema))
 		if dbDefaultSchemaLower == "" {
 			dbDefaultSchemaLower = "dbo"
 		}
 
-		// diretórios reais (por banco), com base no layout
-		sourceDir := layout.SourceDBDir(dbNameLower, dbDefaultSchemaLower)
-		sinkDir := layout.SinkDBDir(dbNameLower)
-		jobDir := layout.JobDBDir(dbNameLower)
+		// diretórios reais por banco: source no cluster do grupo; sinks/jobs na landing zone
+		// e no cluster de cada tabela
+		sourceKustomFiles := map[string][]string{} // diretório -> arquivos
+		sinkKustomFiles := map[string][]string{}   // diretório -> arquivos
+		jobKustomFiles := map[string][]string{}
 
-		if !dryRun {
-			// Aqui MkdirAll só cria a pasta do banco (bkbl001d, crmb001d, etc),
-			// pois os roots já foram validados/criados antes.
-			if err := os.MkdirAll(sourceDir, 0o755); err != nil {
+		// numeração por mode/size: cada combinação tem seus próprios arquivos/tópicos
+		groupCounters := map[string]int{}
+
+		for _, g := range groups {
+			totalSources++
+			groupCounters[g.Mode+"-"+g.Size]++
+			groupIndex := groupCounters[g.Mode+"-"+g.Size]
+
+			sl, err := layoutFor(logicalDB, g.Cluster)
+			if err != nil {
 				db.Close()
-				return fmt.Errorf("criando sourceDir %s: %w", sourceDir, err)
+				return nil, err
 			}
-			if err := os.MkdirAll(sinkDir, 0o755); err != nil {
-				db.Close()
-				return fmt.Errorf("criando sinkDir %s: %w", sinkDir, err)
+			sourceDir := sl.SourceDBDir(dbNameLower, dbDefaultSchemaLower)
+			if !dryRun {
+				// Aqui MkdirAll só cria a pasta do banco (bkbl001d, crmb001d, etc),
+				// pois os roots já foram validados/criados antes.
+				if err := os.MkdirAll(sourceDir, 0o755); err != nil {
+					db.Close()
+					return nil, fmt.Errorf("criando sourceDir %s: %w", sourceDir, err)
+				}
 			}
-			if err := os.MkdirAll(jobDir, 0o755); err != nil {
+			cluster, err := profile.ConnectCluster(g.Cluster)
+			if err != nil {
 				db.Close()
-				return fmt.Errorf("criando jobDir %s: %w", jobDir, err)
+				return nil, err
 			}
-		}
-
-		sourceKustomFiles := []string{}
-		sinkKustomFiles := []string{}
-		jobKustomFiles := []string{}
-
-		for gi, g := range groups {
-			totalSources++
-			groupIndex := gi + 1
 
 			// Nome do arquivo source dentro da pasta do banco
 			// Ex: grupo1-online-m-001.yaml
-			sourceFileName := fmt.Sprintf("%s-%s-%s-%03d.yaml", group, mode, size, groupIndex)
+			sourceFileName := fmt.Sprintf("%s-%s-%s-%03d.yaml", group, g.Mode, g.Size, groupIndex)
 			srcPath := filepath.Join(sourceDir, sourceFileName)
 
 			// Nome lógico do connector source
 			sourceName := fmt.Sprintf(
 				"source-debeziumsqlserver-%s-%s-%s-%s-%s-%03d",
-				dbNameLower, dbDefaultSchemaLower, group, mode, size, groupIndex,
+				dbNameLower, dbDefaultSchemaLower, group, g.Mode, g.Size, groupIndex,
 			)
 
 			topicPrefix := fmt.Sprintf(
 				"source_debeziumsqlserver_%s_%s_%s_%s_%s",
-				dbNameLower, dbDefaultSchemaLower, group, mode, size,
+				dbNameLower, dbDefaultSchemaLower, group, g.Mode, g.Size,
 			)
 
 			schemaHistoryTopic := fmt.Sprintf("sh_%s_%03d", topicPrefix, groupIndex)
 
-			upperAlias := strings.ToUpper(srv.Alias)
-			hostEnvName := fmt.Sprintf("SQLSERVER_%s_HOST", upperAlias)
-			portEnvName := fmt.Sprintf("SQLSERVER_%s_PORT", upperAlias)
-
-			host, err := config.RequireEnv(hostEnvName)
+			host, err := profile.SqlServerHost(srv.Alias)
 			if err != nil {
 				db.Close()
-				return fmt.Errorf("%s não configurado: %w", hostEnvName, err)
+				return nil, fmt.Errorf("host do alias %s não configurado: %w", srv.Alias, err)
 			}
-			port := config.GetEnvOrDefault(portEnvName, "1433")
+			port := profile.SqlServerPort(srv.Alias)
 
 			includeParts := make([]string, 0, len(g.Tables))
 			for _, tm := range g.Tables {
 				includeParts = append(includeParts, fmt.Sprintf("%s.%s", tm.Schema, tm.Name))
 			}
 			tableIncludeList := strings.Join(includeParts, ",")
+			columnExcludeList, columnMasks := sourceColumnProps(dbNameUpper, g.Tables)
 
 			sourceCfg := model.SourceConfig{
 				Name:                          sourceName,
-				ClusterName:                   clusterName,
+				ClusterName:                   cluster.Name,
+				TasksMax:                      defaultTasksMax,
 				DatabaseHost:                  host,
 				DatabasePort:                  port,
 				DatabaseSecret:                srv.SecretName,
 				DatabaseNameUpper:             dbNameUpper,
 				TopicPrefix:                   topicPrefix,
 				TableIncludeList:              tableIncludeList,
+				ColumnExcludeList:             columnExcludeList,
+				ColumnMasks:                   columnMasks,
 				SchemaHistoryBootstrapServers: shBootstrap,
 				SchemaHistoryTopic:            schemaHistoryTopic,
 				SchemaRegistryURL:             schemaRegistryURL,
+				TopicPartitions:               sizing.Class(g.Size).Partitions,
+				SnapshotMaxThreads:            sizing.Class(g.Size).SnapshotMaxThreads,
 			}
 
+			// connectorConfig.source: global < wave < alias < tabelas do grupo
+			tableSourceConfig := map[string]map[string]any{}
+			for _, tm := range g.Tables {
+				if len(tm.ConnectorConfig.Source) > 0 {
+					tableSourceConfig[tm.Schema+"."+tm.Name] = tm.ConnectorConfig.Source
+				}
+			}
+			tablesSource, err := config.MergeTableSourceConfig(tableSourceConfig)
+			if err != nil {
+				db.Close()
+				return nil, fmt.Errorf("source %s (%s): %w", sourceName, srv.Alias, err)
+			}
+			sourceOverrides := config.MergeConnectorConfig(
+				cfgYaml.ConnectorConfig.Source, groupConnectorConfig.Source, srv.ConnectorConfig.Source, tablesSource)
+
 			logPrefix := fmt.Sprintf("[alias=%s grp=%02d db=%s]", srv.Alias, groupIndex, dbNameUpper)
-			log.Printf("%s source=%s (tables=%d, totalRows=%d) -> %s",
-				logPrefix, sourceName, len(g.Tables), g.TotalRows, srcPath)
+			log.Printf("%s source=%s (mode=%s size=%s tables=%d, totalRows=%d, totalChanges=%d) -> %s",
+				logPrefix, sourceName, g.Mode, g.Size, len(g.Tables), g.TotalRows, g.TotalChanges, srcPath)
 			for _, tm := range g.Tables {
-				log.Printf("%s   table=%s.%s rows=%d", logPrefix, tm.Schema, strings.ToUpper(tm.Name), tm.RowCount)
+				log.Printf("%s   table=%s.%s rows=%d changes/dia=%d (%s)", logPrefix, tm.Schema, strings.ToUpper(tm.Name), tm.RowCount, tm.Changes, tm.ChangesFrom)
+			}
+
+			if err := tracker.Place(g.Cluster, srcPath, sourceCfg.TasksMax); err != nil {
+				db.Close()
+				return nil, err
 			}
 
 			if !dryRun {
-				if err := generator.RenderToFile(templates.SourceTemplate, sourceCfg, srcPath); err != nil {
+				if err := writeSource(tpls, sourceCfg, sourceOverrides, srcPath); err != nil {
 					db.Close()
-					return fmt.Errorf("gerando source group %d (%s): %w", groupIndex, srv.Alias, err)
+					return nil, fmt.Errorf("gerando source group %d (%s): %w", groupIndex, srv.Alias, err)
 				}
 			} else {
 				log.Printf("%s DRY-RUN: source NÃO gravado (apenas preview)", logPrefix)
 			}
 
-			sourceKustomFiles = append(sourceKustomFiles, sourceFileName)
+			sourceKustomFiles[sourceDir] = append(sourceKustomFiles[sourceDir], sourceFileName)
+			summary.Sources = append(summary.Sources, sourceName)
+			if err := addManifestFile(manifest, layout, srcPath); err != nil {
+				db.Close()
+				return nil, err
+			}
 
 			// sinks + jobs por tabela
 			for _, tm := range g.Tables {
@@ -562,30 +946,58 @@ func runFromConfig(
 					topicPrefix, dbNameUpper, strings.ToUpper(schemaName), tableUpper,
 				)
 
+				tl, err := layoutFor(tm.Dest.Logical, tm.Cluster)
+				if err != nil {
+					db.Close()
+					return nil, err
+				}
+				sinkDir := tl.SinkDBDir(dbNameLower)
+				jobDir := tl.JobDBDir(dbNameLower)
+				if !dryRun {
+					for _, dir := range []string{sinkDir, jobDir} {
+						if err := os.MkdirAll(dir, 0o755); err != nil {
+							db.Close()
+							return nil, fmt.Errorf("criando %s: %w", dir, err)
+						}
+					}
+				}
+
 				sinkName := fmt.Sprintf(
 					"sink-jdbcsnowflake-%s-%s-%s-%s-%s-%s",
-					logicalDB,
+					tm.Dest.Logical,
 					dbNameLower,
 					tableLower,
-					mode,
-					size,
+					tm.Mode,
+					tm.Size,
 					"v1",
 				)
 
 				// Exemplo: bkbl001d-clientes-online-m.yaml
-				sinkFileName := fmt.Sprintf("%s-%s-%s-%s.yaml", dbNameLower, tableLower, mode, size)
+				sinkFileName := fmt.Sprintf("%s-%s-%s-%s.yaml", dbNameLower, tableLower, tm.Mode, tm.Size)
 				sinkPath := filepath.Join(sinkDir, sinkFileName)
 
 				sinkCfg := model.SinkConfig{
 					Name:                    sinkName,
-					ClusterName:             clusterName,
+					ClusterName:             cluster.Name,
+					TasksMax:                sizing.Class(tm.Size).SinkTasksMax,
 					TopicName:               topicName,
-					SnowflakeURL:            snowJdbc,
-					SnowflakeUserSecret:     snowUserSecret,
-					SnowflakePasswordSecret: snowPassSecret,
-					Stage:                   tableUpper,
-					Table:                   tableUpper,
-					Schema:                  dbNameUpper,
+					SnowflakeURL:            tm.Dest.JDBCURL,
+					SnowflakeUserSecret:     tm.Dest.UserSecret,
+					SnowflakePasswordSecret: tm.Dest.PasswordSecret,
+					Stage:                   tm.Stage,
+					Table:                   tm.TargetTable,
+					Schema:                  tm.TargetSchema,
+					SchemaRegistryURL:       schemaRegistryURL,
+					Buffer:                  sinkBuffer(sizing.Class(tm.Size), tm.SinkConfig),
+					ExtraConfig:             tm.SinkConfig,
+					ColumnRenames:           strings.Join(tm.ColumnRenames, ","),
+				}
+
+				sinkOverrides := config.MergeConnectorConfig(
+					cfgYaml.ConnectorConfig.Sink, groupConnectorConfig.Sink, srv.ConnectorConfig.Sink, tm.ConnectorConfig.Sink)
+				if err := checkSinkTransforms(tm, sinkOverrides); err != nil {
+					db.Close()
+					return nil, err
 				}
 
 				jobName := fmt.Sprintf("lz-sql-ih-%s-%s-v1", dbNameLower, tableLower)
@@ -594,123 +1006,231 @@ func runFromConfig(
 				jobFileName := fmt.Sprintf("%s-%s.yaml", dbNameLower, tableLower)
 				jobPath := filepath.Join(jobDir, jobFileName)
 
+				tableIngest := fmt.Sprintf("%s_INGEST", tm.TargetTable)
+				governanceSQL, err := governance.BuildSQL(cfgYaml.Governance, []string{tableIngest, tm.TargetTable}, tm.Classifications)
+				if err != nil {
+					db.Close()
+					return nil, fmt.Errorf("governança de %s.%s (%s): %w", schemaName, tm.Name, srv.Alias, err)
+				}
+
+				grantsSQL := governance.BuildGrantsSQL(governance.Objects{
+					Database:    tm.Dest.Database,
+					Schema:      tm.TargetSchema,
+					IngestTable: tableIngest,
+					FinalTable:  tm.TargetTable,
+					Stage:       tm.Stage,
+				}, config.MergeGrants(cfgYaml.Grants, srv.Grants), tm.Grants)
+				ownerCheckSQL := governance.BuildOwnerCheckSQL(role, config.MergeGrants(cfgYaml.Grants, srv.Grants), tm.Grants)
+
 				jobCfg := model.SnowflakeJobConfig{
-					JobName:             jobName,
-					ConnectionConfigMap: connCfgMap,
-					SqlConfigMapName:    sqlConfigMapName,
-					Role:                role,
-					Database:            sfDatabase,
-					Schema:              dbNameUpper,
-					TableIngest:         fmt.Sprintf("%s_INGEST", tableUpper),
-					TableFinal:          tableUpper,
-					StageName:           tableUpper,
-					BusinessColumnsDDL:  tm.BusinessDDL,
+					JobName:            jobName,
+					CredentialsSecret:  tm.Dest.CredentialsSecret,
+					SqlConfigMapName:   sqlConfigMapName,
+					Role:               role,
+					Database:           tm.Dest.Database,
+					Schema:             tm.TargetSchema,
+					TableIngest:        tableIngest,
+					TableFinal:         tm.TargetTable,
+					StageName:          tm.Stage,
+					BusinessColumnsDDL: tm.BusinessDDL,
+					GovernanceSQL:      governanceSQL,
+					GrantsSQL:          grantsSQL,
+					OwnerCheckSQL:      ownerCheckSQL,
 				}
 
+				applyJobSettings(&jobCfg, jobSettings)
+
 				log.Printf("%s sink=%s job=%s table=%s.%s -> %s , %s",
 					logPrefix, sinkName, jobName, schemaName, tableUpper, sinkPath, jobPath)
 
+				if err := tracker.Place(tm.Cluster, sinkPath, sinkCfg.TasksMax); err != nil {
+					db.Close()
+					return nil, err
+				}
+
 				if dryRun {
 					log.Printf("%s DRY-RUN: sink/job NÃO gravados (apenas preview)", logPrefix)
 				} else {
-					if err := generator.RenderToFile(templates.SinkTemplate, sinkCfg, sinkPath); err != nil {
+					if err := writeSink(tpls, sinkCfg, sinkOverrides, sinkPath); err != nil {
 						db.Close()
-						return fmt.Errorf("gerando sink (%s.%s): %w", schemaName, tm.Name, err)
+						return nil, fmt.Errorf("gerando sink (%s.%s): %w", schemaName, tm.Name, err)
 					}
-					if err := generator.RenderToFile(templates.SnowflakeJobTemplate, jobCfg, jobPath); err != nil {
+					if err := writeJob(tpls, jobCfg, jobSettings, jobPath); err != nil {
 						db.Close()
-						return fmt.Errorf("gerando job (%s.%s): %w", schemaName, tm.Name, err)
+						return nil, fmt.Errorf("gerando job (%s.%s): %w", schemaName, tm.Name, err)
 					}
 				}
 
-				sinkKustomFiles = append(sinkKustomFiles, sinkFileName)
-				jobKustomFiles = append(jobKustomFiles, jobFileName)
+				sinkKustomFiles[sinkDir] = append(sinkKustomFiles[sinkDir], sinkFileName)
+				jobKustomFiles[jobDir] = append(jobKustomFiles[jobDir], jobFileName)
+				summary.Tables = append(summary.Tables, fmt.Sprintf("%s:%s.%s", srv.Alias, schemaName, tm.Name))
+				summary.Topics = append(summary.Topics, topicName)
+				if err := addManifestFile(manifest, layout, sinkPath, jobPath); err != nil {
+					db.Close()
+					return nil, err
+				}
 			}
 		}
 
 		if !dryRun {
-			// Source com namespace strimzi
-			if err := kustomize.UpdateKustomization(sourceDir, sourceKustomFiles, "strimzi"); err != nil {
-				db.Close()
-				return fmt.Errorf("atualizando kustomization do source em %s: %w", sourceDir, err)
+			// Namespaces vêm do profile (source default strimzi; sink e jobs sem namespace).
+			// A pasta do banco entra no kustomization da raiz (SourceRoot/SinkRoot/JobRoot).
+			for _, dir := range sortedDirs(sourceKustomFiles) {
+				if err := kustomize.UpdateKustomization(dir, sourceKustomFiles[dir], profile.SourceNamespace); err != nil {
+					db.Close()
+					return nil, fmt.Errorf("atualizando kustomization do source em %s: %w", dir, err)
+				}
+				if err := kustomize.LinkDir(dir); err != nil {
+					db.Close()
+					return nil, fmt.Errorf("incluindo %s no kustomization do source: %w", dir, err)
+				}
+				bundleRoots[filepath.Dir(dir)] = true
 			}
-			// Sink e Jobs sem namespace (como nos teus exemplos)
-			if err := kustomize.UpdateKustomization(sinkDir, sinkKustomFiles, ""); err != nil {
-				db.Close()
-				return fmt.Errorf("atualizando kustomization do sink em %s: %w", sinkDir, err)
+			for _, dir := range sortedDirs(sinkKustomFiles) {
+				if err := kustomize.UpdateKustomization(dir, sinkKustomFiles[dir], profile.SinkNamespace); err != nil {
+					db.Close()
+					return nil, fmt.Errorf("atualizando kustomization do sink em %s: %w", dir, err)
+				}
+				if err := kustomize.LinkDir(dir); err != nil {
+					db.Close()
+					return nil, fmt.Errorf("incluindo %s no kustomization do sink: %w", dir, err)
+				}
+				bundleRoots[filepath.Dir(dir)] = true
 			}
-			if err := kustomize.UpdateKustomization(jobDir, jobKustomFiles, ""); err != nil {
-				db.Close()
-				return fmt.Errorf("atualizando kustomization dos jobs em %s: %w", jobDir, err)
+			for _, dir := range sortedDirs(jobKustomFiles) {
+				if err := kustomize.UpdateKustomization(dir, jobKustomFiles[dir], profile.JobNamespace); err != nil {
+					db.Close()
+					return nil, fmt.Errorf("atualizando kustomization dos jobs em %s: %w", dir, err)
+				}
+				if err := kustomize.LinkDir(dir); err != nil {
+					db.Close()
+					return nil, fmt.Errorf("incluindo %s no kustomization dos jobs: %w", dir, err)
+				}
+				bundleRoots[filepath.Dir(dir)] = true
 			}
 		} else {
-			log.Printf("[alias=%s] DRY-RUN: kustomization.yaml NÃO atualizado. sourceDir=%s sinkDir=%s jobDir=%s",
-				srv.Alias, sourceDir, sinkDir, jobDir)
+			log.Printf("[alias=%s] DRY-RUN: kustomization.yaml NÃO atualizado. sourceDirs=%v sinkDirs=%v jobDirs=%v",
+				srv.Alias, sortedDirs(sourceKustomFiles), sortedDirs(sinkKustomFiles), sortedDirs(jobKustomFiles))
 		}
 
 		db.Close()
 	}
 
+	for _, line := range tracker.Report() {
+		log.Printf("capacidade Kafka Connect: %s", line)
+	}
+
+	if !dryRun {
+		if err := validate.CheckBundles(bundleRoots); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest.Aliases = summary.Aliases
+	manifest.Tables = summary.Tables
+	manifest.Sources = summary.Sources
+	manifest.Topics = summary.Topics
+
 	if dryRun {
 		log.Printf("DRY-RUN concluído. Sources simulados: %d | Tabelas processadas: %d", totalSources, totalTables)
 	} else {
+		// manifesto da wave: base para o comando promote
+		data, err := manifest.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("serializando manifesto da wave: %w", err)
+		}
+		if err := generator.WriteFile(layout.WaveManifestPath(group), data); err != nil {
+			return nil, fmt.Errorf("gravando manifesto da wave: %w", err)
+		}
+
 		log.Printf("Arquivos gerados sob baseDir=%s (modo config). Sources: %d | Tabelas: %d", baseDir, totalSources, totalTables)
 	}
 
-	return nil
+	return summary, nil
 }
 
-// Agrupa as tabelas em grupos (cada grupo vira 1 source connector)
-func groupTablesIntoSources(tables []tableMeta, maxTables int, maxRows int64) []sourceGroup {
-	if len(tables) == 0 {
-		return nil
-	}
+// checkLayoutRoots garante as raízes do layout:
+//   - GitOps (ArgoStyle=true): roots DEVEM existir (apps/<...>), senão erro
+//   - Local/out (ArgoStyle=false): roots são criados se não existirem
+func checkLayoutRoots(layout repo.Layout, dryRun bool) error {
+	if layout.ArgoStyle {
+		rootMap := map[string]string{
+			"sourceRoot": layout.SourceRoot(),
+			"sinkRoot":   layout.SinkRoot(),
+			"jobRoot":    layout.JobRoot(),
+		}
 
-	// Sem limites: tudo em um único source
-	if maxTables <= 0 && maxRows <= 0 {
-		g := sourceGroup{}
-		for _, t := range tables {
-			g.Tables = append(g.Tables, t)
-			g.TotalRows += t.RowCount
+		for name, p := range rootMap {
+			fi, err := os.Stat(p)
+			if err != nil {
+				return fmt.Errorf("layout inválido: diretório base %s não encontrado (%s): %w", name, p, err)
+			}
+			if !fi.IsDir() {
+				return fmt.Errorf("layout inválido: %s existe mas não é diretório: %s", name, p)
+			}
+		}
+	} else if !dryRun {
+		for _, root := range []string{layout.SourceRoot(), layout.SinkRoot(), layout.JobRoot()} {
+			if err := os.MkdirAll(root, 0o755); err != nil {
+				return fmt.Errorf("criando diretório base %s: %w", root, err)
+			}
 		}
-		return []sourceGroup{g}
 	}
+	return nil
+}
 
-	// Ordena por rowcount desc (tabelas maiores primeiro)
-	sort.Slice(tables, func(i, j int) bool {
-		return tables[i].RowCount > tables[j].RowCount
-	})
-
-	var groups []sourceGroup
+// trackCluster registra o cluster no controle de capacidade com os connectors já
+// presentes nas pastas source/sink do cluster no layout.
+func trackCluster(t *capacity.Tracker, c config.ConnectCluster, l repo.Layout) error {
+	limits := capacity.Limits{MaxConnectors: c.MaxConnectors, MaxTasks: c.MaxTasks}
+	return t.Scan(c.Key, c.Name, limits, l.ConnectorRoots()...)
+}
 
+// claimTargets registra o destino Snowflake de cada tabela do alias (mesma resolução do
+// loop de geração) e lista, de uma vez, as que colidem com outra tabela da execução.
+func claimTargets(targets config.TargetSet, profile config.EnvProfile, naming config.Naming, srv config.SqlServerEntry, tables []config.TableEntry) error {
+	var problems []string
 	for _, t := range tables {
-		placed := false
+		dest := profile.SnowflakeDest(srv, t)
+		schema := firstNonEmpty(dest.Schema, strings.ToUpper(srv.Database))
+		table := firstNonEmpty(t.TargetTable, config.ResolveNaming(naming, srv.Naming, t.Naming).TableName(t.Name))
+		origin := fmt.Sprintf("%s:%s.%s", srv.Alias, firstNonEmpty(strings.TrimSpace(t.Schema), srv.Schema), t.Name)
+		if err := targets.Claim(dest.Database, schema, table, origin); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", origin, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("tabelas com o mesmo destino Snowflake:\n- %s", strings.Join(problems, "\n- "))
+	}
+	return nil
+}
 
-		for gi := range groups {
-			// limite de tabelas
-			if maxTables > 0 && len(groups[gi].Tables) >= maxTables {
-				continue
-			}
-			// limite de linhas
-			if maxRows > 0 && groups[gi].TotalRows+t.RowCount > maxRows {
-				continue
-			}
+func sortedDirs(m map[string][]string) []string {
+	dirs := make([]string, 0, len(m))
+	for d := range m {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
 
-			groups[gi].Tables = append(groups[gi].Tables, t)
-			groups[gi].TotalRows += t.RowCount
-			placed = true
-			break
+// addManifestFile registra artefatos gerados no manifesto da wave (relativos a BaseDir)
+func addManifestFile(m *repo.WaveManifest, layout repo.Layout, paths ...string) error {
+	for _, p := range paths {
+		rel, err := layout.RelPath(p)
+		if err != nil {
+			return fmt.Errorf("registrando %s no manifesto da wave: %w", p, err)
 		}
+		m.Files = append(m.Files, rel)
+	}
+	return nil
+}
 
-		// se não coube em nenhum grupo existente, cria um novo
-		if !placed {
-			g := sourceGroup{
-				Tables:    []tableMeta{t},
-				TotalRows: t.RowCount,
-			}
-			groups = append(groups, g)
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
 		}
 	}
-
-	return groups
+	return ""
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/generator"
+	"ih-ingestion/internal/gitops"
+	"ih-ingestion/internal/promote"
+	"ih-ingestion/internal/repo"
+)
+
+// runPromoteCommand implementa `ingestion-cli promote`: copia os artefatos de uma wave
+// de um ambiente para o seguinte (development → homolog → production), reescrevendo
+// os valores específicos de ambiente. No GitOps, gera uma branch e um PR por promoção.
+func runPromoteCommand(args []string, execDir string) {
+	fs := flag.NewFlagSet("promote", flag.ExitOnError)
+	group := fs.String("group", "", "nome da wave a promover (ex: grupo1)")
+	from := fs.String("from", "development", "ambiente de origem")
+	to := fs.String("to", "", "ambiente de destino (default: próximo da cadeia development → homolog → production)")
+	outDirFlag := fs.String("out", "./apps", "no modo GitOps: subpasta apps/ dentro do repo. No modo local: pasta base com source/sink/jobs.")
+	configFlag := fs.String("config", "", "ingestion.yaml com os profiles dos ambientes. Se vazio, tenta ingestion.yaml ao lado do binário")
+	dryRun := fs.Bool("dry-run", false, "se verdadeiro, não grava arquivos nem faz git push; apenas mostra o que seria feito")
+	_ = fs.Parse(args)
+
+	if *group == "" {
+		log.Fatal("promote: flag -group é obrigatória")
+	}
+
+	target := *to
+	if target == "" {
+		next, err := config.NextEnv(*from)
+		if err != nil {
+			log.Fatalf("promote: %v", err)
+		}
+		target = next
+	}
+	if err := checkPromotionOrder(*from, target); err != nil {
+		log.Fatalf("promote: %v", err)
+	}
+
+	// profiles: ingestion.yaml (se houver) + envs
+	var cfgYaml *config.IngestionConfig
+	configPath := *configFlag
+	if configPath == "" {
+		candidate := filepath.Join(execDir, "ingestion.yaml")
+		if _, err := os.Stat(candidate); err == nil {
+			configPath = candidate
+		}
+	}
+	if configPath != "" {
+		var err error
+		cfgYaml, err = config.LoadIngestionConfig(configPath)
+		if err != nil {
+			log.Fatalf("promote: carregando %s: %v", configPath, err)
+		}
+	}
+	fromProfile, err := config.ResolveProfile(cfgYaml, *from, nil)
+	if err != nil {
+		log.Fatalf("promote: %v", err)
+	}
+	toProfile, err := config.ResolveProfile(cfgYaml, target, nil)
+	if err != nil {
+		log.Fatalf("promote: %v", err)
+	}
+
+	gitCfg, gitEnabled := loadGitOps()
+
+	var prProvider gitops.PullRequestProvider
+	if gitEnabled {
+		prProvider, err = gitops.NewPullRequestProvider(gitCfg)
+		if err != nil {
+			log.Fatalf("erro configurando abertura de PR: %v", err)
+		}
+	}
+
+	var gitRepo *gitops.Repo
+	fatalf := func(format string, args ...any) {
+		if err := gitRepo.Close(); err != nil {
+			log.Printf("aviso: %v", err)
+		}
+		log.Fatalf(format, args...)
+	}
+
+	baseDir := *outDirFlag
+	if gitEnabled && !*dryRun {
+		branchSuffix := fmt.Sprintf("promote-%s-%s", *group, target)
+		gitRepo, err = gitops.PrepareRepo(gitCfg, execDir, branchSuffix)
+		if err != nil {
+			log.Fatalf("erro preparando repositório GitOps: %v", err)
+		}
+		if !filepath.IsAbs(baseDir) {
+			baseDir = filepath.Join(gitRepo.Path, baseDir)
+		}
+	} else if !filepath.IsAbs(baseDir) {
+		baseDir = filepath.Join(execDir, baseDir)
+	}
+
+	opts := promote.Options{
+		Group:          *group,
+		BaseDir:        baseDir,
+		SourceProvider: "debeziumsqlserver",
+		ArgoStyle:      gitEnabled,
+		DryRun:         *dryRun,
+		Config:         cfgYaml,
+	}
+
+	log.Printf("Iniciando promote: group=%s %s → %s baseDir=%s dryRun=%v gitEnabled=%v",
+		*group, *from, target, baseDir, *dryRun, gitEnabled)
+
+	run := func() (*repo.WaveManifest, error) {
+		generator.ResetTracking()
+		return promote.Promote(opts, fromProfile, toProfile)
+	}
+
+	manifest, err := run()
+	if err != nil {
+		fatalf("erro na promoção: %v", err)
+	}
+	log.Printf("Promoção %s → %s da wave %s: %d arquivo(s)", *from, target, *group, len(manifest.Files))
+
+	if !gitEnabled || *dryRun {
+		return
+	}
+
+	regenerate := func() ([]string, error) {
+		m, err := run()
+		if err != nil {
+			return nil, err
+		}
+		manifest = m
+		return generator.TrackedPaths(), nil
+	}
+
+	msg := fmt.Sprintf("Promote wave %s: %s -> %s", *group, *from, target)
+	pushed, err := gitRepo.CommitAndPush(msg, generator.TrackedPaths(), regenerate)
+	if err != nil {
+		fatalf("erro ao fazer commit/push GitOps: %v", err)
+	}
+	log.Printf("GitOps concluído com sucesso. Branch: %s", gitRepo.Branch)
+
+	if prProvider != nil && pushed {
+		summary := gitops.WaveSummary{
+			Group:   *group,
+			Env:     target,
+			From:    *from,
+			Aliases: manifest.Aliases,
+			Tables:  manifest.Tables,
+			Sources: manifest.Sources,
+			Topics:  manifest.Topics,
+		}
+		pr := gitops.BuildPullRequest(gitCfg, gitRepo.Branch, summary)
+		prURL, err := prProvider.CreatePullRequest(context.Background(), pr)
+		switch {
+		case errors.Is(err, gitops.ErrPullRequestExists):
+			log.Printf("PR já existente para a branch %s (%s); push atualizou o PR aberto", gitRepo.Branch, prProvider.Name())
+		case err != nil:
+			fatalf("erro ao abrir PR (%s): %v", prProvider.Name(), err)
+		default:
+			log.Printf("PR aberto (%s): %s", prProvider.Name(), prURL)
+		}
+	}
+
+	if err := gitRepo.Close(); err != nil {
+		log.Printf("aviso: %v", err)
+	}
+}
+
+// checkPromotionOrder só permite promover "para frente" na cadeia de ambientes.
+func checkPromotionOrder(from, to string) error {
+	idx := map[string]int{}
+	for i, e := range config.EnvChain {
+		idx[e] = i
+	}
+
+	fi, ok := idx[from]
+	if !ok {
+		return fmt.Errorf("ambiente de origem desconhecido: %s", from)
+	}
+	ti, ok := idx[to]
+	if !ok {
+		return fmt.Errorf("ambiente de destino desconhecido: %s", to)
+	}
+	if ti <= fi {
+		return fmt.Errorf("promoção deve seguir a cadeia %v (%s → %s não permitido)", config.EnvChain, from, to)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/generator"
+	"ih-ingestion/internal/manifest"
+	"ih-ingestion/internal/model"
+	"ih-ingestion/internal/templates"
+)
+
+// Os manifestos saem das structs tipadas de internal/manifest; um template do
+// -templates-dir substitui a struct do recurso correspondente. Nos dois casos o
+// connectorConfig é mesclado na árvore YAML e o arquivo passa pelo round-trip antes de gravar.
+
+func writeSource(tpls *templates.Set, c model.SourceConfig, overrides map[string]any, path string) error {
+	var data []byte
+	var err error
+	if tpls.IsOverridden(templates.Source) {
+		data, err = execute(tpls.Source, c)
+	} else {
+		data, err = manifest.Marshal(manifest.Source(c))
+	}
+	if err != nil {
+		return err
+	}
+	return writeConnector(data, overrides, path)
+}
+
+func writeSink(tpls *templates.Set, c model.SinkConfig, overrides map[string]any, path string) error {
+	var data []byte
+	var err error
+	if tpls.IsOverridden(templates.Sink) {
+		data, err = execute(tpls.Sink, c)
+	} else {
+		data, err = manifest.Marshal(manifest.Sink(c))
+	}
+	if err != nil {
+		return err
+	}
+	return writeConnector(data, overrides, path)
+}
+
+// writeJob renderiza o script.sql (template script) e grava o Job + ConfigMap.
+func writeJob(tpls *templates.Set, c model.SnowflakeJobConfig, j config.JobSettings, path string) error {
+	script, err := execute(tpls.Script, c)
+	if err != nil {
+		return fmt.Errorf("script.sql: %w", err)
+	}
+	c.Script = string(script)
+
+	var data []byte
+	if tpls.IsOverridden(templates.Job) {
+		data, err = execute(tpls.Job, c)
+	} else {
+		job, cm := manifest.SnowflakeJob(c, j)
+		data, err = manifest.Marshal(job, cm)
+	}
+	if err != nil {
+		return err
+	}
+	return writeManifest(data, path)
+}
+
+func writeConnector(data []byte, overrides map[string]any, path string) error {
+	data, err := generator.MergeConnectorConfig(data, overrides)
+	if err != nil {
+		return fmt.Errorf("mesclando connectorConfig: %w", err)
+	}
+	return writeManifest(data, path)
+}
+
+func writeManifest(data []byte, path string) error {
+	if err := manifest.Verify(data); err != nil {
+		return fmt.Errorf("manifesto %s inválido: %w", path, err)
+	}
+	return generator.WriteFile(path, data)
+}
+
+func execute(t *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
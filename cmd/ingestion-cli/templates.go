@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"ih-ingestion/internal/templates"
+)
+
+// runTemplatesCommand implementa `ingestion-cli templates dump`: grava os templates
+// embutidos como ponto de partida para o -templates-dir.
+func runTemplatesCommand(args []string) {
+	if len(args) == 0 || args[0] != "dump" {
+		fmt.Fprintln(os.Stderr, "uso: ingestion-cli templates dump [-out ./templates] [-force]")
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("templates dump", flag.ExitOnError)
+	out := fs.String("out", "./templates", "diretório onde gravar os templates embutidos")
+	force := fs.Bool("force", false, "sobrescreve arquivos existentes")
+	_ = fs.Parse(args[1:])
+
+	written, err := templates.Dump(*out, *force)
+	for _, p := range written {
+		log.Printf("template gravado: %s", p)
+	}
+	if err != nil {
+		log.Fatalf("templates dump: %v", err)
+	}
+	log.Printf("use -templates-dir %s (ou templatesDir no ingestion.yaml); apague os arquivos que não quiser sobrescrever", *out)
+}
+
+// loadTemplates carrega os templates da geração (embutidos + overrides de dir).
+func loadTemplates(dir string) (*templates.Set, error) {
+	set, err := templates.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(set.Overridden) > 0 {
+		log.Printf("templates de %s: %s (demais embutidos)", dir, strings.Join(set.Overridden, ", "))
+	}
+	return set, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"ih-ingestion/internal/validate"
+)
+
+// runValidateCommand implementa `ingestion-cli validate`: confere offline os arquivos
+// gerados contra os schemas embutidos (KafkaConnector, KafkaTopic, Job, ConfigMap e
+// Kustomization) e resolve as árvores de kustomization.
+func runValidateCommand(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	outDirFlag := fs.String("out", "./apps", "pasta a validar (apps/ do repo GitOps ou a pasta base do modo local). Pastas extras podem vir como argumentos")
+	build := fs.Bool("build", false, "roda o `kustomize build` de cada pasta (precisa ter kustomization.yaml) e imprime o bundle no stdout")
+	_ = fs.Parse(args)
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = []string{*outDirFlag}
+	}
+
+	failed := false
+	for _, dir := range dirs {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			log.Fatalf("validate: pasta %s não encontrada", dir)
+		}
+		var res validate.Result
+		if *build {
+			var bundle []byte
+			bundle, res = validate.Build(dir)
+			os.Stdout.Write(bundle)
+		} else {
+			var err error
+			res, err = validate.Dir(dir)
+			if err != nil {
+				log.Fatalf("validate: %v", err)
+			}
+		}
+		for _, p := range res.Problems {
+			log.Printf("ERRO %s", p)
+		}
+		if *build {
+			log.Printf("validate -build %s: %d recurso(s) no bundle, %d validados, %d problema(s)",
+				dir, res.Bundle, res.Resources, len(res.Problems))
+		} else {
+			log.Printf("validate %s: %d arquivo(s), %d recurso(s) validados, %d árvore(s) de kustomization, %d problema(s)",
+				dir, res.Files, res.Resources, res.Kustomizations, len(res.Problems))
+		}
+		if len(res.Problems) > 0 {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,187 @@
+package capacity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Limits é a capacidade de um cluster Kafka Connect (0 = sem limite).
+type Limits struct {
+	MaxConnectors int
+	MaxTasks      int
+}
+
+// Usage é o que um cluster já tem ou terá após a geração.
+type Usage struct {
+	Connectors int
+	Tasks      int
+}
+
+type cluster struct {
+	name   string
+	limits Limits
+	files  map[string]int // caminho do KafkaConnector -> tasksMax
+}
+
+// Tracker soma os connectors de cada cluster: os que já estão no repo (Scan) e os que a
+// geração vai gravar (Place). Um arquivo regravado conta uma vez, com o tasksMax novo.
+type Tracker struct {
+	clusters map[string]*cluster
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{clusters: map[string]*cluster{}}
+}
+
+// Scan registra o cluster e conta os KafkaConnector já existentes sob roots.
+// Roots inexistentes são ignorados. Chamadas repetidas só acrescentam roots.
+func (t *Tracker) Scan(key, name string, limits Limits, roots ...string) error {
+	c, ok := t.clusters[key]
+	if !ok {
+		c = &cluster{name: name, limits: limits, files: map[string]int{}}
+		t.clusters[key] = c
+	}
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || !isManifest(path) {
+				return nil
+			}
+			tasks, ok, err := connectorTasks(path)
+			if err != nil {
+				return err
+			}
+			if ok {
+				c.files[filepath.Clean(path)] = tasks
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("lendo connectors do cluster %s em %s: %w", name, root, err)
+		}
+	}
+	return nil
+}
+
+// Place conta um connector que será gravado em path e falha se o cluster estourar a capacidade.
+func (t *Tracker) Place(key, path string, tasks int) error {
+	c, ok := t.clusters[key]
+	if !ok {
+		return fmt.Errorf("cluster %q não registrado para controle de capacidade", key)
+	}
+
+	path = filepath.Clean(path)
+	prev, existed := c.files[path]
+	c.files[path] = tasks
+
+	u := c.usage()
+	var over []string
+	if c.limits.MaxConnectors > 0 && u.Connectors > c.limits.MaxConnectors {
+		over = append(over, fmt.Sprintf("%d connectors (máx %d)", u.Connectors, c.limits.MaxConnectors))
+	}
+	if c.limits.MaxTasks > 0 && u.Tasks > c.limits.MaxTasks {
+		over = append(over, fmt.Sprintf("%d tasks (máx %d)", u.Tasks, c.limits.MaxTasks))
+	}
+	if len(over) > 0 {
+		if existed {
+			c.files[path] = prev
+		} else {
+			delete(c.files, path)
+		}
+		return fmt.Errorf("cluster %s sem capacidade para %s: ficaria com %s", c.name, filepath.Base(path), strings.Join(over, " e "))
+	}
+	return nil
+}
+
+// Report resume o uso por cluster (chave lógica ordenada, default primeiro).
+func (t *Tracker) Report() []string {
+	keys := make([]string, 0, len(t.clusters))
+	for k := range t.clusters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		c := t.clusters[k]
+		u := c.usage()
+		out = append(out, fmt.Sprintf("%s: %d connector(s) [%s], %d task(s) [%s]",
+			c.name, u.Connectors, limitStr(c.limits.MaxConnectors), u.Tasks, limitStr(c.limits.MaxTasks)))
+	}
+	return out
+}
+
+func (c *cluster) usage() Usage {
+	var u Usage
+	for _, tasks := range c.files {
+		u.Connectors++
+		u.Tasks += tasks
+	}
+	return u
+}
+
+func limitStr(n int) string {
+	if n <= 0 {
+		return "sem limite"
+	}
+	return fmt.Sprintf("máx %d", n)
+}
+
+func isManifest(path string) bool {
+	base := filepath.Base(path)
+	if base == "kustomization.yaml" || base == "kustomization.yml" {
+		return false
+	}
+	ext := filepath.Ext(base)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// connectorTasks lê um arquivo e soma o tasksMax dos KafkaConnector (default 1).
+func connectorTasks(path string) (int, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	total, found := 0, false
+	for {
+		var doc struct {
+			Kind string `yaml:"kind"`
+			Spec struct {
+				TasksMax int `yaml:"tasksMax"`
+			} `yaml:"spec"`
+		}
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return 0, false, fmt.Errorf("%s: %w", path, err)
+		}
+		if doc.Kind != "KafkaConnector" {
+			continue
+		}
+		found = true
+		if doc.Spec.TasksMax > 0 {
+			total += doc.Spec.TasksMax
+		} else {
+			total++
+		}
+	}
+	return total, found, nil
+}
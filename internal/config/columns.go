@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Estratégias de mascaramento de coluna (maskColumns)
+const (
+	MaskHash   = "hash"   // column.mask.hash.<algoritmo>.with.salt.<salt>
+	MaskRedact = "redact" // column.mask.with.<n>.chars
+)
+
+// Tamanho em caracteres do hash hexadecimal gerado pelo Debezium, por algoritmo.
+var HashHexLength = map[string]int{
+	"MD5":     32,
+	"SHA-1":   40,
+	"SHA-256": 64,
+	"SHA-512": 128,
+}
+
+const (
+	DefaultHashAlgorithm = "SHA-256"
+	DefaultRedactLength  = 8
+)
+
+// O salt vai no nome da propriedade do connector: só caracteres seguros.
+var saltPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ColumnMask mascara uma coluna na origem (Debezium), mantendo-a no Snowflake.
+type ColumnMask struct {
+	Name      string `yaml:"name"`
+	Strategy  string `yaml:"strategy"`            // hash ou redact
+	Algorithm string `yaml:"algorithm,omitempty"` // hash: MD5, SHA-1, SHA-256 (default), SHA-512
+	Salt      string `yaml:"salt,omitempty"`      // hash: default maskSalt do alias
+	Length    int    `yaml:"length,omitempty"`    // redact: quantidade de '*' (default 8)
+}
+
+// ResolveMask preenche os defaults da máscara (algoritmo, salt do alias, tamanho).
+func ResolveMask(m ColumnMask, aliasSalt string) ColumnMask {
+	m.Strategy = strings.ToLower(strings.TrimSpace(m.Strategy))
+	switch m.Strategy {
+	case MaskHash:
+		m.Algorithm = strings.ToUpper(firstNonEmpty(m.Algorithm, DefaultHashAlgorithm))
+		m.Salt = firstNonEmpty(m.Salt, aliasSalt)
+	case MaskRedact:
+		if m.Length <= 0 {
+			m.Length = DefaultRedactLength
+		}
+	}
+	return m
+}
+
+// validateColumnRules valida excludeColumns/maskColumns de uma entrada de tables.
+func validateColumnRules(ctx string, t TableEntry, aliasSalt string) []string {
+	var problems []string
+	seen := map[string]string{}
+
+	for _, c := range t.ExcludeColumns {
+		name := strings.ToUpper(strings.TrimSpace(c))
+		if name == "" {
+			problems = append(problems, ctx+": excludeColumns com nome vazio")
+			continue
+		}
+		if prev, ok := seen[name]; ok {
+			problems = append(problems, fmt.Sprintf("%s: coluna %s repetida (%s e excludeColumns)", ctx, c, prev))
+		}
+		seen[name] = "excludeColumns"
+	}
+
+	for _, raw := range t.MaskColumns {
+		m := ResolveMask(raw, aliasSalt)
+		name := strings.ToUpper(strings.TrimSpace(m.Name))
+		if name == "" {
+			problems = append(problems, ctx+": maskColumns com name vazio")
+			continue
+		}
+		if prev, ok := seen[name]; ok {
+			problems = append(problems, fmt.Sprintf("%s: coluna %s repetida (%s e maskColumns)", ctx, m.Name, prev))
+		}
+		seen[name] = "maskColumns"
+
+		switch m.Strategy {
+		case MaskHash:
+			if _, ok := HashHexLength[m.Algorithm]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: maskColumns[%s]: algoritmo %q não suportado", ctx, m.Name, m.Algorithm))
+			}
+			if m.Salt == "" {
+				problems = append(problems, fmt.Sprintf("%s: maskColumns[%s]: hash exige salt (na coluna ou maskSalt no alias)", ctx, m.Name))
+			} else if !saltPattern.MatchString(m.Salt) {
+				problems = append(problems, fmt.Sprintf("%s: maskColumns[%s]: salt só aceita letras, dígitos, '_' e '-'", ctx, m.Name))
+			}
+		case MaskRedact:
+		default:
+			problems = append(problems, fmt.Sprintf("%s: maskColumns[%s]: strategy %q inválida (use %s ou %s)", ctx, m.Name, raw.Strategy, MaskHash, MaskRedact))
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var clusterKeyPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// ConnectClusterKeys lista as chaves de connectCluster citadas nos aliases e tabelas.
+func (c *IngestionConfig) ConnectClusterKeys() []string {
+	seen := map[string]bool{}
+	for _, srv := range c.SqlServers {
+		if k := strings.TrimSpace(srv.ConnectCluster); k != "" {
+			seen[k] = true
+		}
+		for _, t := range srv.Tables {
+			if k := strings.TrimSpace(t.ConnectCluster); k != "" {
+				seen[k] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConnectClusterFor retorna a chave do cluster de uma tabela (tabela > alias; "" = default).
+func ConnectClusterFor(srv SqlServerEntry, t TableEntry) string {
+	return firstNonEmpty(t.ConnectCluster, srv.ConnectCluster)
+}
+
+func validateConnectCluster(ctx, key string) []string {
+	if key == "" || clusterKeyPattern.MatchString(key) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: connectCluster %q inválido (minúsculas, dígitos e -)", ctx, key)}
+}
+
+func validateConnectProfile(ctx string, c ConnectProfile) []string {
+	var problems []string
+	if c.MaxConnectors < 0 || c.MaxTasks < 0 {
+		problems = append(problems, fmt.Sprintf("%s: maxConnectors/maxTasks não podem ser negativos", ctx))
+	}
+	keys := make([]string, 0, len(c.Clusters))
+	for k := range c.Clusters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cp := c.Clusters[k]
+		problems = append(problems, validateConnectCluster(ctx+".clusters", k)...)
+		if cp.MaxConnectors < 0 || cp.MaxTasks < 0 {
+			problems = append(problems, fmt.Sprintf("%s.clusters.%s: maxConnectors/maxTasks não podem ser negativos", ctx, k))
+		}
+	}
+	return problems
+}
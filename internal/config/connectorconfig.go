@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConnectorConfig são propriedades mescladas no spec.config dos KafkaConnector gerados
+// (connectorConfig: no ingestion.yaml). Valores null removem a chave do manifesto.
+// As propriedades do Kafka Connect são planas: um map aninhado é só outra forma de
+// escrever as chaves com ponto (buffer: {flush.time: 60} = buffer.flush.time: 60).
+type ConnectorConfig struct {
+	Source map[string]any `yaml:"source,omitempty"` // source Debezium
+	Sink   map[string]any `yaml:"sink,omitempty"`   // sink Snowflake
+}
+
+// GroupEntry configura uma wave (-group) inteira.
+type GroupEntry struct {
+	ConnectorConfig ConnectorConfig `yaml:"connectorConfig,omitempty"`
+}
+
+// MergeConnectorConfig achata e mescla os níveis em ordem (o último prevalece, chave a
+// chave). Um null de um nível mais específico é mantido, para remover a chave do template
+// na renderização.
+func MergeConnectorConfig(levels ...map[string]any) map[string]any {
+	var out map[string]any
+	for _, l := range levels {
+		if len(l) == 0 {
+			continue
+		}
+		if out == nil {
+			out = map[string]any{}
+		}
+		flat, _ := flattenConfig(l)
+		for k, v := range flat {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// flattenConfig troca maps aninhados pelas chaves com ponto equivalentes. Devolve também
+// os problemas encontrados: chave vazia e a mesma chave escrita nas duas formas.
+func flattenConfig(m map[string]any) (map[string]any, []string) {
+	out := map[string]any{}
+	var problems []string
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for _, k := range sortedKeys(m) {
+			key := prefix + k
+			if strings.TrimSpace(k) == "" {
+				problems = append(problems, fmt.Sprintf("%s: chave vazia", strings.TrimSuffix(prefix, ".")))
+				continue
+			}
+			if sub, ok := m[k].(map[string]any); ok {
+				if len(sub) == 0 {
+					problems = append(problems, fmt.Sprintf("%s: mapa vazio", key))
+				}
+				walk(key+".", sub)
+				continue
+			}
+			if _, dup := out[key]; dup {
+				problems = append(problems, fmt.Sprintf("%s: definida duas vezes (aninhada e com ponto)", key))
+			}
+			out[key] = m[k]
+		}
+	}
+	walk("", m)
+	return out, problems
+}
+
+// MergeTableSourceConfig junta o connectorConfig.source das tabelas de um mesmo source
+// connector: tabelas podem repetir uma chave com o mesmo valor, mas não com valores diferentes.
+func MergeTableSourceConfig(tables map[string]map[string]any) (map[string]any, error) {
+	out := map[string]any{}
+	owner := map[string]string{}
+	var conflicts []string
+	for _, name := range sortedKeys(tables) {
+		flat, _ := flattenConfig(tables[name])
+		for k, v := range flat {
+			if prev, ok := out[k]; ok && !reflect.DeepEqual(prev, v) {
+				conflicts = append(conflicts, fmt.Sprintf("%s (%s=%v, %s=%v)", k, owner[k], prev, name, v))
+				continue
+			}
+			out[k] = v
+			owner[k] = name
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("connectorConfig.source com valores diferentes em tabelas do mesmo source: %s", strings.Join(conflicts, "; "))
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func validateConnectorConfig(ctx string, c ConnectorConfig) []string {
+	var problems []string
+	for _, part := range []struct {
+		name string
+		m    map[string]any
+	}{{"source", c.Source}, {"sink", c.Sink}} {
+		prefix := "connectorConfig." + part.name
+		if ctx != "" {
+			prefix = ctx + "." + prefix
+		}
+		flat, flatProblems := flattenConfig(part.m)
+		for _, p := range flatProblems {
+			if !strings.HasPrefix(p, ":") {
+				p = "." + p // problema numa chave (p começa pelo caminho dela)
+			}
+			problems = append(problems, prefix+p)
+		}
+		for _, k := range sortedKeys(flat) {
+			if _, isList := flat[k].([]any); isList {
+				problems = append(problems, fmt.Sprintf("%s.%s: listas não são aceitas (use uma string separada por vírgulas)", prefix, k))
+			}
+		}
+	}
+	return problems
+}
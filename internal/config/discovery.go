@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TableLister lista as tabelas de um schema no banco (ex: sqlserver.ListTables).
+type TableLister func(schema string, cdcOnly bool) ([]string, error)
+
+// Discovery é o resultado de uma regra include/exclude de tables.
+type Discovery struct {
+	Schema  string   `yaml:"schema"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude,omitempty"`
+	CDCOnly bool     `yaml:"cdcOnly,omitempty"`
+	Tables  []string `yaml:"tables"`
+}
+
+// IsDiscoveryRule indica se a entrada de tables é uma regra (include/exclude) e não uma tabela.
+func (t TableEntry) IsDiscoveryRule() bool {
+	return len(t.Include) > 0
+}
+
+// ResolveTables expande as regras include/exclude do alias contra o banco.
+// Tabelas listadas por nome vêm primeiro e prevalecem sobre as descobertas;
+// uma tabela casada por mais de uma regra fica com a primeira. As regras
+// herdam mode/size/targetSchema/sinkConfig da entrada para cada tabela encontrada.
+func ResolveTables(srv SqlServerEntry, list TableLister) ([]TableEntry, []Discovery, error) {
+	defaultSchema := strings.TrimSpace(srv.Schema)
+	if defaultSchema == "" {
+		defaultSchema = "dbo"
+	}
+
+	seen := map[string]bool{}
+	key := func(schema, table string) string {
+		return strings.ToUpper(schema) + "." + strings.ToUpper(table)
+	}
+
+	var out []TableEntry
+	for _, t := range srv.Tables {
+		if t.IsDiscoveryRule() {
+			continue
+		}
+		schema := firstNonEmpty(t.Schema, defaultSchema)
+		seen[key(schema, t.Name)] = true
+		out = append(out, t)
+	}
+
+	var discoveries []Discovery
+	for _, rule := range srv.Tables {
+		if !rule.IsDiscoveryRule() {
+			continue
+		}
+		schema := firstNonEmpty(rule.Schema, defaultSchema)
+		cdcOnly := srv.CDCOnly || rule.CDCOnly
+
+		names, err := list(schema, cdcOnly)
+		if err != nil {
+			return nil, nil, fmt.Errorf("listando tabelas do schema %s: %w", schema, err)
+		}
+
+		d := Discovery{Schema: schema, Include: rule.Include, Exclude: rule.Exclude, CDCOnly: cdcOnly}
+		for _, name := range names {
+			ok, err := matchTable(name, rule.Include, rule.Exclude)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !ok || seen[key(schema, name)] {
+				continue
+			}
+			seen[key(schema, name)] = true
+
+			t := rule
+			t.Name = name
+			t.Schema = schema
+			t.Include, t.Exclude, t.CDCOnly = nil, nil, false
+			t.Discovered = true
+			out = append(out, t)
+			d.Tables = append(d.Tables, name)
+		}
+		sort.Strings(d.Tables)
+		discoveries = append(discoveries, d)
+	}
+
+	return out, discoveries, nil
+}
+
+// matchTable: casa algum include e nenhum exclude.
+func matchTable(name string, include, exclude []string) (bool, error) {
+	in := false
+	for _, p := range include {
+		ok, err := matchPattern(p, name)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			in = true
+			break
+		}
+	}
+	if !in {
+		return false, nil
+	}
+	for _, p := range exclude {
+		ok, err := matchPattern(p, name)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchPattern compara sem diferenciar maiúsculas. Padrões entre barras são
+// regex (ex: /^tmp_\d+$/); os demais são glob (*, ?, [..]).
+func matchPattern(pattern, name string) (bool, error) {
+	if isRegexPattern(pattern) {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return false, fmt.Errorf("regex inválida %s: %w", pattern, err)
+		}
+		return re.MatchString(name), nil
+	}
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	if err != nil {
+		return false, fmt.Errorf("glob inválido %q: %w", pattern, err)
+	}
+	return ok, nil
+}
+
+func isRegexPattern(p string) bool {
+	return len(p) >= 2 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/")
+}
+
+// validatePatterns compila os padrões de uma regra (usado na validação do YAML).
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("padrão vazio")
+		}
+		if _, err := matchPattern(p, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Governance configura tags e masking policies do Snowflake por classificação de coluna.
+type Governance struct {
+	TagSchema       string                    `yaml:"tagSchema,omitempty"` // ex: GOVERNANCE.TAGS (default: schema da tabela)
+	Classifications map[string]Classification `yaml:"classifications,omitempty"`
+}
+
+// Classification descreve como uma classificação (ex: pii.cpf) vira tag e política.
+type Classification struct {
+	Tag           string `yaml:"tag,omitempty"`           // default: primeiro segmento em maiúsculas (pii.cpf -> PII)
+	Value         string `yaml:"value,omitempty"`         // default: restante (pii.cpf -> cpf) ou o próprio nome
+	MaskingPolicy string `yaml:"maskingPolicy,omitempty"` // política já existente no Snowflake (opcional)
+}
+
+// ResolveClassification aplica os defaults de tag/valor a partir do nome da classificação.
+func (g Governance) ResolveClassification(name string) (Classification, bool) {
+	c, ok := g.Classifications[name]
+	if !ok {
+		return Classification{}, false
+	}
+	head, rest, found := strings.Cut(name, ".")
+	if c.Tag == "" {
+		c.Tag = strings.ToUpper(strings.ReplaceAll(head, "-", "_"))
+	}
+	if c.Value == "" {
+		if found {
+			c.Value = rest
+		} else {
+			c.Value = name
+		}
+	}
+	return c, true
+}
+
+// validateClassifications confere se as classificações das colunas foram declaradas
+// em governance.classifications e se cada coluna tem no máximo uma masking policy.
+func validateClassifications(ctx string, t TableEntry, g Governance) []string {
+	var problems []string
+
+	columns := make([]string, 0, len(t.Classifications))
+	for col := range t.Classifications {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	excluded := map[string]bool{}
+	for _, c := range t.ExcludeColumns {
+		excluded[strings.ToUpper(strings.TrimSpace(c))] = true
+	}
+
+	for _, col := range columns {
+		if excluded[strings.ToUpper(col)] {
+			problems = append(problems, fmt.Sprintf("%s: coluna %s está em excludeColumns e em classifications", ctx, col))
+		}
+		var policies []string
+		for _, name := range t.Classifications[col] {
+			c, ok := g.ResolveClassification(name)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: coluna %s: classificação %q não declarada em governance.classifications", ctx, col, name))
+				continue
+			}
+			if c.MaskingPolicy != "" {
+				policies = append(policies, c.MaskingPolicy)
+			}
+		}
+		if len(policies) > 1 {
+			problems = append(problems, fmt.Sprintf("%s: coluna %s teria mais de uma masking policy (%s)", ctx, col, strings.Join(policies, ", ")))
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Grants define roles de leitura/escrita e o owner dos objetos gerados no Snowflake.
+// Pode ser declarado no topo do ingestion.yaml (todo o database), por alias (schemas do
+// alias) ou por tabela. Roles são somadas entre os níveis; o owner mais específico vence.
+type Grants struct {
+	Readers []string `yaml:"readers,omitempty"` // SELECT na tabela final
+	Writers []string `yaml:"writers,omitempty"` // DML em _INGEST/final e READ/WRITE no stage
+	Owner   string   `yaml:"owner,omitempty"`   // GRANT OWNERSHIP ... COPY CURRENT GRANTS
+}
+
+var rolePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// MergeGrants combina níveis do mais geral para o mais específico.
+func MergeGrants(levels ...Grants) Grants {
+	var out Grants
+	for _, g := range levels {
+		out.Readers = appendUnique(out.Readers, g.Readers...)
+		out.Writers = appendUnique(out.Writers, g.Writers...)
+		if strings.TrimSpace(g.Owner) != "" {
+			out.Owner = strings.TrimSpace(g.Owner)
+		}
+	}
+	return out
+}
+
+// IsEmpty indica se não há nenhum grant configurado.
+func (g Grants) IsEmpty() bool {
+	return len(g.Readers) == 0 && len(g.Writers) == 0 && g.Owner == ""
+}
+
+func validateGrants(ctx string, g Grants) []string {
+	var problems []string
+	check := func(kind, role string) {
+		if !rolePattern.MatchString(strings.TrimSpace(role)) {
+			problems = append(problems, fmt.Sprintf("%s: grants.%s: role %q inválida", ctx, kind, role))
+		}
+	}
+	for _, r := range g.Readers {
+		check("readers", r)
+	}
+	for _, r := range g.Writers {
+		check("writers", r)
+	}
+	if g.Owner != "" {
+		check("owner", g.Owner)
+	}
+	return problems
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		dup := false
+		for _, it := range list {
+			if strings.EqualFold(it, v) {
+				dup = true
+				break
+			}
+		}
+		if v != "" && !dup {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -6,24 +6,96 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"ih-ingestion/internal/grouping"
 )
 
 type TableEntry struct {
-	Name   string `yaml:"name"`
+	Name   string `yaml:"name,omitempty"`
 	Schema string `yaml:"schema,omitempty"`
+
+	// Descoberta (no lugar de name): padrões glob ou /regex/ contra sys.tables do schema
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+	CDCOnly bool     `yaml:"cdcOnly,omitempty"` // só tabelas com CDC habilitado
+
+	// Overrides por tabela (vazio = valor da execução / derivado do nome)
+	Mode         string            `yaml:"mode,omitempty"`         // online ou batch (default: -mode)
+	Size         string            `yaml:"size,omitempty"`         // p/m/g (default: -size)
+	TargetSchema string            `yaml:"targetSchema,omitempty"` // schema no Snowflake (default: database em maiúsculas)
+	TargetTable  string            `yaml:"targetTable,omitempty"`  // tabela final no Snowflake (default: nome pela política naming)
+	Stage        string            `yaml:"stage,omitempty"`        // stage do sink (default: tabela final)
+	SinkConfig   map[string]string `yaml:"sinkConfig,omitempty"`   // propriedades extras do sink connector (legado: prefira connectorConfig.sink)
+
+	ConnectorConfig ConnectorConfig `yaml:"connectorConfig,omitempty"` // spec.config do sink e do source desta tabela
+
+	// Colunas fora da replicação (column.exclude.list) ou mascaradas na origem (column.mask.*)
+	ExcludeColumns []string     `yaml:"excludeColumns,omitempty"`
+	MaskColumns    []ColumnMask `yaml:"maskColumns,omitempty"`
+
+	// Classificações por coluna (ex: Cpf: [pii.cpf]) -> tags e masking policies no Snowflake
+	Classifications map[string][]string `yaml:"classifications,omitempty"`
+
+	Grants Grants `yaml:"grants,omitempty"` // somente esta tabela (sem future grants)
+
+	Naming Naming `yaml:"naming,omitempty"` // nomes no Snowflake (rename de colunas só aqui)
+
+	Snowflake SnowflakeTarget `yaml:"snowflake,omitempty"` // database/schema/landing zone/secrets desta tabela
+
+	ConnectCluster string `yaml:"connectCluster,omitempty"` // cluster Kafka Connect do source e do sink
+
+	// Agrupamento em sources (grouping do alias)
+	Affinity     string `yaml:"affinity,omitempty"`     // tabelas com a mesma affinity ficam no mesmo source
+	ChangeVolume int64  `yaml:"changeVolume,omitempty"` // alterações estimadas por dia (grouping change-volume)
+
+	Discovered bool `yaml:"-"` // veio de uma regra include (colunas ausentes são ignoradas)
+}
+
+// Valores aceitos em mode/size (por tabela e nas flags)
+var (
+	ValidModes = []string{"online", "batch"}
+	ValidSizes = []string{"p", "m", "g"}
+)
+
+// sinkReservedKeys já são geradas pelo template do sink e não podem ser repetidas em sinkConfig.
+var sinkReservedKeys = map[string]bool{
+	"topics": true, "url": true, "user": true, "password": true,
+	"stage": true, "table": true, "schema": true,
+	"key.converter": true, "key.converter.schema.registry.url": true, "key.converter.schemas.enable": true,
+	"value.converter": true, "value.converter.schema.registry.url": true, "value.converter.schemas.enable": true,
 }
 
 type SqlServerEntry struct {
-	Alias              string       `yaml:"alias"`
-	Database           string       `yaml:"database"`
-	Schema             string       `yaml:"schema"`     // schema default
-	SecretName         string       `yaml:"secretName"` // nome do secret usado no connector
-	MaxTablesPerSource int          `yaml:"maxTablesPerSource,omitempty"`
-	MaxRowsPerSource   int64        `yaml:"maxRowsPerSource,omitempty"`
-	Tables             []TableEntry `yaml:"tables"`
+	Alias               string          `yaml:"alias"`
+	Database            string          `yaml:"database"`
+	Schema              string          `yaml:"schema"`     // schema default
+	SecretName          string          `yaml:"secretName"` // nome do secret usado no connector
+	MaxTablesPerSource  int             `yaml:"maxTablesPerSource,omitempty"`
+	MaxRowsPerSource    int64           `yaml:"maxRowsPerSource,omitempty"`
+	MaxChangesPerSource int64           `yaml:"maxChangesPerSource,omitempty"` // alterações/dia somadas por source
+	Grouping            string          `yaml:"grouping,omitempty"`            // first-fit (default), balanced, affinity ou change-volume
+	CDCOnly             bool            `yaml:"cdcOnly,omitempty"`             // vale para todas as regras include do alias
+	MaskSalt            string          `yaml:"maskSalt,omitempty"`            // salt default das colunas com strategy hash
+	Grants              Grants          `yaml:"grants,omitempty"`              // schemas de destino do alias
+	Naming              Naming          `yaml:"naming,omitempty"`              // política de nomes das tabelas do alias
+	Snowflake           SnowflakeTarget `yaml:"snowflake,omitempty"`           // destino Snowflake do alias (default: profile)
+	ConnectCluster      string          `yaml:"connectCluster,omitempty"`      // chave em profiles.<env>.connect.clusters (default: connect.clusterName)
+	ConnectorConfig     ConnectorConfig `yaml:"connectorConfig,omitempty"`     // spec.config dos connectors do alias
+	Tables              []TableEntry    `yaml:"tables"`
 }
 
 type IngestionConfig struct {
+	Profiles     map[string]ProfileEntry `yaml:"profiles,omitempty"` // por ambiente (development, homolog, production)
+	Governance   Governance              `yaml:"governance,omitempty"`
+	Grants       Grants                  `yaml:"grants,omitempty"`       // todo o database Snowflake
+	Job          JobSettings             `yaml:"job,omitempty"`          // container do job Snowflake
+	Naming       Naming                  `yaml:"naming,omitempty"`       // política de nomes no Snowflake
+	Sizing       Sizing                  `yaml:"sizing,omitempty"`       // classes de tamanho p/m/g
+	TemplatesDir string                  `yaml:"templatesDir,omitempty"` // overrides dos templates (relativo ao ingestion.yaml; -templates-dir prevalece)
+
+	ConnectorConfig ConnectorConfig       `yaml:"connectorConfig,omitempty"` // spec.config de todos os connectors
+	Groups          map[string]GroupEntry `yaml:"groups,omitempty"`          // por wave (-group)
+
 	SqlServers []SqlServerEntry `yaml:"sqlservers"`
 }
 
@@ -48,10 +120,38 @@ func ValidateIngestionConfig(cfg *IngestionConfig) error {
 	if len(cfg.SqlServers) == 0 {
 		problems = append(problems, "nenhum sqlserver definido em sqlservers")
 	}
+	problems = append(problems, validateGrants("grants", cfg.Grants)...)
+	problems = append(problems, validateNaming("naming", cfg.Naming, false)...)
+	problems = append(problems, validateSizing("sizing", cfg.Sizing)...)
+	problems = append(problems, validateConnectorConfig("", cfg.ConnectorConfig)...)
+	for _, g := range sortedKeys(cfg.Groups) {
+		problems = append(problems, validateConnectorConfig("groups."+g, cfg.Groups[g].ConnectorConfig)...)
+	}
+	for _, env := range profileNames(cfg) {
+		problems = append(problems, validateConnectProfile(fmt.Sprintf("profiles.%s.connect", env), cfg.Profiles[env].Connect)...)
+		for alias, sp := range cfg.Profiles[env].SqlServers {
+			problems = append(problems, validateSnowflakeTarget(fmt.Sprintf("profiles.%s.sqlservers.%s", env, alias), sp.Snowflake)...)
+		}
+	}
+	switch cfg.Job.ImagePullPolicy {
+	case "", "Always", "IfNotPresent", "Never":
+	default:
+		problems = append(problems, fmt.Sprintf("job.imagePullPolicy %q inválido (Always, IfNotPresent ou Never)", cfg.Job.ImagePullPolicy))
+	}
+	if len(cfg.Job.Args) > 0 && len(cfg.Job.Command) == 0 {
+		problems = append(problems, "job.args exige job.command (os args default são do snowflake-apply)")
+	}
 
 	seenAliases := map[string]bool{}
 	seenTables := map[string]bool{} // alias|database|schema|table
 
+	// destinos Snowflake por ambiente: profiles.<env>.sqlservers.<alias>.snowflake muda o destino
+	targetProfiles := yamlProfiles(cfg)
+	seenTargets := make([]TargetSet, len(targetProfiles))
+	for i := range seenTargets {
+		seenTargets[i] = TargetSet{}
+	}
+
 	for i, srv := range cfg.SqlServers {
 		ctx := fmt.Sprintf("sqlservers[%d] (alias=%s)", i, srv.Alias)
 
@@ -74,6 +174,11 @@ func ValidateIngestionConfig(cfg *IngestionConfig) error {
 		if strings.TrimSpace(srv.SecretName) == "" {
 			problems = append(problems, ctx+": secretName vazio")
 		}
+		problems = append(problems, validateGrants(ctx, srv.Grants)...)
+		problems = append(problems, validateNaming(ctx, srv.Naming, false)...)
+		problems = append(problems, validateSnowflakeTarget(ctx, srv.Snowflake)...)
+		problems = append(problems, validateConnectCluster(ctx, srv.ConnectCluster)...)
+		problems = append(problems, validateConnectorConfig(ctx, srv.ConnectorConfig)...)
 
 		if len(srv.Tables) == 0 {
 			problems = append(problems, ctx+": nenhuma tabela configurada em tables")
@@ -85,6 +190,12 @@ func ValidateIngestionConfig(cfg *IngestionConfig) error {
 		if srv.MaxRowsPerSource < 0 {
 			problems = append(problems, ctx+": maxRowsPerSource não pode ser negativo")
 		}
+		if srv.MaxChangesPerSource < 0 {
+			problems = append(problems, ctx+": maxChangesPerSource não pode ser negativo")
+		}
+		if !grouping.IsValid(srv.Grouping) {
+			problems = append(problems, fmt.Sprintf("%s: grouping %q inválido (use %s)", ctx, srv.Grouping, strings.Join(grouping.Names, ", ")))
+		}
 
 		defaultSchema := "dbo"
 		if strings.TrimSpace(srv.Schema) != "" {
@@ -92,8 +203,28 @@ func ValidateIngestionConfig(cfg *IngestionConfig) error {
 		}
 
 		for j, t := range srv.Tables {
+			problems = append(problems, validateColumnRules(fmt.Sprintf("%s.tables[%d]", ctx, j), t, srv.MaskSalt)...)
+			problems = append(problems, validateClassifications(fmt.Sprintf("%s.tables[%d]", ctx, j), t, cfg.Governance)...)
+			problems = append(problems, validateGrants(fmt.Sprintf("%s.tables[%d]", ctx, j), t.Grants)...)
+			problems = append(problems, validateNaming(fmt.Sprintf("%s.tables[%d]", ctx, j), t.Naming, true)...)
+			problems = append(problems, validateSnowflakeTarget(fmt.Sprintf("%s.tables[%d]", ctx, j), t.Snowflake)...)
+			problems = append(problems, validateConnectCluster(fmt.Sprintf("%s.tables[%d]", ctx, j), t.ConnectCluster)...)
+			problems = append(problems, validateConnectorConfig(fmt.Sprintf("%s.tables[%d]", ctx, j), t.ConnectorConfig)...)
+			if t.ChangeVolume < 0 {
+				problems = append(problems, fmt.Sprintf("%s.tables[%d]: changeVolume não pode ser negativo", ctx, j))
+			}
+			if t.TargetSchema != "" && t.Snowflake.Schema != "" && !strings.EqualFold(t.TargetSchema, t.Snowflake.Schema) {
+				problems = append(problems, fmt.Sprintf("%s.tables[%d]: targetSchema e snowflake.schema divergentes (use um dos dois)", ctx, j))
+			}
+			if t.IsDiscoveryRule() {
+				problems = append(problems, validateDiscoveryRule(fmt.Sprintf("%s.tables[%d]", ctx, j), t)...)
+				continue
+			}
+			if len(t.Exclude) > 0 || t.CDCOnly {
+				problems = append(problems, fmt.Sprintf("%s.tables[%d]: exclude/cdcOnly exigem include", ctx, j))
+			}
 			if strings.TrimSpace(t.Name) == "" {
-				problems = append(problems, fmt.Sprintf("%s.tables[%d]: name vazio", ctx, j))
+				problems = append(problems, fmt.Sprintf("%s.tables[%d]: name vazio (ou use include)", ctx, j))
 				continue
 			}
 			schema := strings.TrimSpace(t.Schema)
@@ -101,6 +232,19 @@ func ValidateIngestionConfig(cfg *IngestionConfig) error {
 				schema = defaultSchema
 			}
 
+			tctx := fmt.Sprintf("%s.tables[%d] (%s)", ctx, j, t.Name)
+			if t.Mode != "" && !contains(ValidModes, t.Mode) {
+				problems = append(problems, fmt.Sprintf("%s: mode %q inválido (use %s)", tctx, t.Mode, strings.Join(ValidModes, "/")))
+			}
+			if t.Size != "" && !contains(ValidSizes, t.Size) {
+				problems = append(problems, fmt.Sprintf("%s: size %q inválido (use %s)", tctx, t.Size, strings.Join(ValidSizes, "/")))
+			}
+			for k := range t.SinkConfig {
+				if sinkReservedKeys[k] {
+					problems = append(problems, fmt.Sprintf("%s: sinkConfig.%s já é gerado pelo CLI (use targetSchema/targetTable/stage para nomes)", tctx, k))
+				}
+			}
+
 			key := fmt.Sprintf("%s|%s|%s|%s",
 				strings.ToUpper(alias),
 				strings.ToUpper(srv.Database),
@@ -113,6 +257,29 @@ func ValidateIngestionConfig(cfg *IngestionConfig) error {
 			} else {
 				seenTables[key] = true
 			}
+
+			// mesmo cálculo da geração; database vazio = o do profile vindo de env/-set
+			// (igual para todas as tabelas que não sobrescrevem)
+			table := firstNonEmpty(t.TargetTable, ResolveNaming(cfg.Naming, srv.Naming, t.Naming).TableName(t.Name))
+			origin := fmt.Sprintf("%s:%s.%s", alias, schema, t.Name)
+			collisions := map[string][]string{} // erro -> ambientes em que ocorre
+			var order []string
+			for k, p := range targetProfiles {
+				dest := p.SnowflakeDest(srv, t)
+				if err := seenTargets[k].Claim(dest.Database, firstNonEmpty(dest.Schema, srv.Database), table, origin); err != nil {
+					if _, ok := collisions[err.Error()]; !ok {
+						order = append(order, err.Error())
+					}
+					collisions[err.Error()] = append(collisions[err.Error()], p.Env)
+				}
+			}
+			for _, msg := range order {
+				if envs := collisions[msg]; len(envs) == 1 && envs[0] == "" {
+					problems = append(problems, fmt.Sprintf("%s: %s", tctx, msg))
+				} else {
+					problems = append(problems, fmt.Sprintf("%s: %s (profiles: %s)", tctx, msg, strings.Join(envs, ", ")))
+				}
+			}
 		}
 	}
 
@@ -123,8 +290,10 @@ func ValidateIngestionConfig(cfg *IngestionConfig) error {
 	return nil
 }
 
-// Valida se existem envs mínimas para cada alias declarado no YAML
-func ValidateEnvForAliases(cfg *IngestionConfig) error {
+// Valida se existem as credenciais de cada alias declarado no YAML
+// (aceita o sufixo do ambiente, ex: SQLSERVER_<ALIAS>_USER_<ENV>).
+// O host vem do profile e é validado em ResolveProfile.
+func ValidateEnvForAliases(cfg *IngestionConfig, env string) error {
 	var problems []string
 
 	for _, srv := range cfg.SqlServers {
@@ -135,13 +304,12 @@ func ValidateEnvForAliases(cfg *IngestionConfig) error {
 		upper := strings.ToUpper(alias)
 
 		keys := []string{
-			"SQLSERVER_" + upper + "_HOST",
 			"SQLSERVER_" + upper + "_USER",
 			"SQLSERVER_" + upper + "_PASSWORD",
 		}
 
 		for _, k := range keys {
-			if os.Getenv(k) == "" {
+			if envValue(k, env, "") == "" {
 				problems = append(problems, fmt.Sprintf("%s não definida (alias=%s)", k, srv.Alias))
 			}
 		}
@@ -153,3 +321,56 @@ func ValidateEnvForAliases(cfg *IngestionConfig) error {
 
 	return nil
 }
+
+// validateDiscoveryRule valida uma entrada include/exclude de tables.
+func validateDiscoveryRule(ctx string, t TableEntry) []string {
+	var problems []string
+	if strings.TrimSpace(t.Name) != "" {
+		problems = append(problems, ctx+": name e include são exclusivos")
+	}
+	if t.TargetTable != "" || t.Stage != "" {
+		problems = append(problems, ctx+": targetTable/stage não se aplicam a regras include (valem por tabela)")
+	}
+	if t.Mode != "" && !contains(ValidModes, t.Mode) {
+		problems = append(problems, fmt.Sprintf("%s: mode %q inválido (use %s)", ctx, t.Mode, strings.Join(ValidModes, "/")))
+	}
+	if t.Size != "" && !contains(ValidSizes, t.Size) {
+		problems = append(problems, fmt.Sprintf("%s: size %q inválido (use %s)", ctx, t.Size, strings.Join(ValidSizes, "/")))
+	}
+	for k := range t.SinkConfig {
+		if sinkReservedKeys[k] {
+			problems = append(problems, fmt.Sprintf("%s: sinkConfig.%s já é gerado pelo CLI", ctx, k))
+		}
+	}
+	if err := validatePatterns(t.Include); err != nil {
+		problems = append(problems, fmt.Sprintf("%s: include: %v", ctx, err))
+	}
+	if err := validatePatterns(t.Exclude); err != nil {
+		problems = append(problems, fmt.Sprintf("%s: exclude: %v", ctx, err))
+	}
+	return problems
+}
+
+// IsValidMode indica se mode é online ou batch.
+func IsValidMode(mode string) bool { return contains(ValidModes, mode) }
+
+// IsValidSize indica se size é p, m ou g.
+func IsValidSize(size string) bool { return contains(ValidSizes, size) }
+
+func contains(list []string, v string) bool {
+	for _, it := range list {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JobSettings configura o container do Job Snowflake (job: no ingestion.yaml).
+// Campos vazios usam os defaults endurecidos de DefaultJobSettings; securityContext
+// e podSecurityContext, quando informados, substituem o default inteiro.
+type JobSettings struct {
+	Image              string           `yaml:"image,omitempty"` // sobrescrito por profiles.<env>.kubernetes.jobImage
+	ImagePullPolicy    string           `yaml:"imagePullPolicy,omitempty"`
+	Command            []string         `yaml:"command,omitempty"`
+	Args               []string         `yaml:"args,omitempty"`
+	SecurityContext    *SecurityContext `yaml:"securityContext,omitempty"`
+	PodSecurityContext *SecurityContext `yaml:"podSecurityContext,omitempty"`
+}
+
+// SecurityContext é o subconjunto usado do securityContext do Kubernetes
+// (serve tanto para o container quanto para o pod).
+type SecurityContext struct {
+	RunAsUser                *int64          `yaml:"runAsUser,omitempty"`
+	RunAsGroup               *int64          `yaml:"runAsGroup,omitempty"`
+	RunAsNonRoot             *bool           `yaml:"runAsNonRoot,omitempty"`
+	FSGroup                  *int64          `yaml:"fsGroup,omitempty"` // só no pod
+	AllowPrivilegeEscalation *bool           `yaml:"allowPrivilegeEscalation,omitempty"`
+	ReadOnlyRootFilesystem   *bool           `yaml:"readOnlyRootFilesystem,omitempty"`
+	Privileged               *bool           `yaml:"privileged,omitempty"`
+	Capabilities             *Capabilities   `yaml:"capabilities,omitempty"`
+	SeccompProfile           *SeccompProfile `yaml:"seccompProfile,omitempty"`
+}
+
+type Capabilities struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"`
+}
+
+type SeccompProfile struct {
+	Type string `yaml:"type"`
+}
+
+// Usuário não-root da imagem default (distroless nonroot).
+const jobUID int64 = 65532
+
+// DefaultJobSettings: sem root, sem escalada de privilégio e com root FS somente
+// leitura (o resultado JSON vai para um emptyDir em /tmp). Não há imagem default:
+// a imagem do CLI (build/ingestion-cli/Dockerfile) é publicada com tag de versão e
+// cada instalação aponta para a sua (job.image ou kubernetes.jobImage).
+func DefaultJobSettings() JobSettings {
+	t, f := true, false
+	uid := jobUID
+	return JobSettings{
+		ImagePullPolicy: "IfNotPresent",
+		Command:         []string{"/ingestion-cli", "snowflake-apply"},
+		Args: []string{
+			"-file", "/sql/script.sql",
+			"-mode", "idempotent",
+		},
+		SecurityContext: &SecurityContext{
+			RunAsNonRoot:             &t,
+			RunAsUser:                &uid,
+			RunAsGroup:               &uid,
+			AllowPrivilegeEscalation: &f,
+			ReadOnlyRootFilesystem:   &t,
+			Privileged:               &f,
+			Capabilities:             &Capabilities{Drop: []string{"ALL"}},
+		},
+		PodSecurityContext: &SecurityContext{
+			RunAsNonRoot:   &t,
+			FSGroup:        &uid,
+			SeccompProfile: &SeccompProfile{Type: "RuntimeDefault"},
+		},
+	}
+}
+
+// ResolveJobSettings aplica os defaults e a imagem do ambiente (kubernetes.jobImage).
+// A imagem é obrigatória e precisa de tag fixa ou digest: com IfNotPresent, uma tag
+// móvel (latest ou sem tag) deixa cada nó rodando a versão que já tinha em cache.
+func ResolveJobSettings(j JobSettings, profile EnvProfile) (JobSettings, error) {
+	d := DefaultJobSettings()
+	if profile.JobImage != "" {
+		j.Image = profile.JobImage
+	}
+	if j.Image == "" {
+		return j, fmt.Errorf("imagem do job não definida: informe job.image no ingestion.yaml ou profiles.<env>.kubernetes.jobImage (K8S_JOB_IMAGE)")
+	}
+	if !pinnedImage(j.Image) {
+		return j, fmt.Errorf("imagem do job %q sem versão fixa: use uma tag de versão ou digest (ex: ingestion-cli:1.4.0), não latest", j.Image)
+	}
+	if j.ImagePullPolicy == "" {
+		j.ImagePullPolicy = d.ImagePullPolicy
+	}
+	if len(j.Command) == 0 {
+		j.Command = d.Command
+		if len(j.Args) == 0 {
+			j.Args = d.Args
+		}
+	}
+	if j.SecurityContext == nil {
+		j.SecurityContext = d.SecurityContext
+	}
+	if j.PodSecurityContext == nil {
+		j.PodSecurityContext = d.PodSecurityContext
+	}
+	return j, nil
+}
+
+// pinnedImage diz se a referência tem digest ou uma tag diferente de latest.
+// A tag vem depois do último ':' que não faz parte do host (registry:porta/repo).
+func pinnedImage(ref string) bool {
+	if strings.Contains(ref, "@") {
+		return true
+	}
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	i := strings.LastIndex(name, ":")
+	return i >= 0 && name[i+1:] != "" && name[i+1:] != "latest"
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Estilos de nome no Snowflake
+const (
+	NamingUpper = "upper" // ClienteId -> CLIENTEID (comportamento do Snowflake sem aspas)
+	NamingSnake = "snake" // ClienteId -> CLIENTE_ID
+)
+
+var validNamingStyles = []string{NamingUpper, NamingSnake}
+
+// Naming define como nomes do SQL Server viram nomes no Snowflake (tabela final, _INGEST,
+// stage e colunas). Pode ser declarado no topo do ingestion.yaml, por alias ou por tabela;
+// o nível mais específico vence campo a campo. rename só vale por tabela.
+type Naming struct {
+	Style        string            `yaml:"style,omitempty"`        // upper (default) ou snake
+	StripAccents *bool             `yaml:"stripAccents,omitempty"` // Descrição -> DESCRICAO
+	Rename       map[string]string `yaml:"rename,omitempty"`       // coluna de origem -> nome no Snowflake
+}
+
+// ResolveNaming combina os níveis do mais geral para o mais específico.
+func ResolveNaming(levels ...Naming) Naming {
+	var out Naming
+	for _, n := range levels {
+		if strings.TrimSpace(n.Style) != "" {
+			out.Style = strings.TrimSpace(n.Style)
+		}
+		if n.StripAccents != nil {
+			out.StripAccents = n.StripAccents
+		}
+		if len(n.Rename) > 0 {
+			out.Rename = n.Rename
+		}
+	}
+	if out.Style == "" {
+		out.Style = NamingUpper
+	}
+	return out
+}
+
+// TableName aplica a política ao nome de uma tabela (rename não se aplica).
+func (n Naming) TableName(name string) string {
+	name = strings.TrimSpace(name)
+	if n.StripAccents != nil && *n.StripAccents {
+		name = stripAccents(name)
+	}
+	if n.Style == NamingSnake {
+		name = snakeCase(name)
+	}
+	return strings.ToUpper(name)
+}
+
+// ColumnName aplica rename (sem diferenciar maiúsculas) ou a política ao nome da coluna.
+func (n Naming) ColumnName(name string) string {
+	for from, to := range n.Rename {
+		if strings.EqualFold(strings.TrimSpace(from), strings.TrimSpace(name)) {
+			return strings.ToUpper(strings.TrimSpace(to))
+		}
+	}
+	return n.TableName(name)
+}
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+	"Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"Ç", "C", "Ñ", "N",
+)
+
+func stripAccents(s string) string {
+	return accentReplacer.Replace(s)
+}
+
+var nonWord = regexp.MustCompile(`[^A-Za-z0-9_$\p{L}]+`)
+var underscores = regexp.MustCompile(`_{2,}`)
+
+// snakeCase separa palavras por "_": ClienteId -> Cliente_Id, HTTPStatus -> HTTP_Status,
+// "Data Nasc" -> Data_Nasc. Espaços e pontuação viram "_".
+func snakeCase(s string) string {
+	s = nonWord.ReplaceAllString(s, "_")
+
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(r)
+	}
+
+	return strings.Trim(underscores.ReplaceAllString(b.String(), "_"), "_")
+}
+
+func validateNaming(ctx string, n Naming, allowRename bool) []string {
+	var problems []string
+	if n.Style != "" && !contains(validNamingStyles, n.Style) {
+		problems = append(problems, fmt.Sprintf("%s: naming.style %q inválido (use %s)", ctx, n.Style, strings.Join(validNamingStyles, "/")))
+	}
+	if len(n.Rename) > 0 && !allowRename {
+		problems = append(problems, fmt.Sprintf("%s: naming.rename só pode ser usado por tabela", ctx))
+	}
+	froms := make([]string, 0, len(n.Rename))
+	for from := range n.Rename {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+
+	targets := map[string]string{}
+	for _, from := range froms {
+		to := n.Rename[from]
+		if strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+			problems = append(problems, fmt.Sprintf("%s: naming.rename com coluna vazia (%q: %q)", ctx, from, to))
+			continue
+		}
+		key := strings.ToUpper(strings.TrimSpace(to))
+		if prev, ok := targets[key]; ok {
+			problems = append(problems, fmt.Sprintf("%s: naming.rename: %s e %s viram %s", ctx, prev, from, key))
+		} else {
+			targets[key] = from
+		}
+	}
+	return problems
+}
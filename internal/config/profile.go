@@ -0,0 +1,438 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Cadeia de promoção entre ambientes (development → homolog → production).
+var EnvChain = []string{"development", "homolog", "production"}
+
+// NextEnv retorna o ambiente seguinte na cadeia de promoção.
+func NextEnv(env string) (string, error) {
+	for i, e := range EnvChain {
+		if e == env {
+			if i == len(EnvChain)-1 {
+				return "", fmt.Errorf("ambiente %s é o último da cadeia de promoção", env)
+			}
+			return EnvChain[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("ambiente desconhecido: %s (use %s)", env, strings.Join(EnvChain, ", "))
+}
+
+// ResolveEnvName devolve o ambiente alvo: flag -env, senão IH_ENV. Sem nenhum dos
+// dois é erro, para não gerar artefatos de production por omissão.
+func ResolveEnvName(flagValue string) (string, error) {
+	if env := strings.TrimSpace(flagValue); env != "" {
+		return env, nil
+	}
+	if env := strings.TrimSpace(os.Getenv("IH_ENV")); env != "" {
+		return env, nil
+	}
+	return "", fmt.Errorf("ambiente alvo não definido: use -env ou IH_ENV (%s)", strings.Join(EnvChain, ", "))
+}
+
+// ==== profiles: no ingestion.yaml ====
+
+type ConnectProfile struct {
+	ClusterName   string `yaml:"clusterName,omitempty"`   // label strimzi.io/cluster (cluster default)
+	MaxConnectors int    `yaml:"maxConnectors,omitempty"` // capacidade do cluster default (0 = sem limite)
+	MaxTasks      int    `yaml:"maxTasks,omitempty"`
+
+	// clusters adicionais por chave lógica (connectCluster no alias/tabela)
+	Clusters map[string]ConnectClusterProfile `yaml:"clusters,omitempty"`
+}
+
+type ConnectClusterProfile struct {
+	Name          string `yaml:"name,omitempty"` // nome do KafkaConnect neste ambiente
+	MaxConnectors int    `yaml:"maxConnectors,omitempty"`
+	MaxTasks      int    `yaml:"maxTasks,omitempty"`
+}
+
+type KafkaProfile struct {
+	SchemaHistoryBootstrapServers string `yaml:"schemaHistoryBootstrapServers,omitempty"`
+}
+
+type SchemaRegistryProfile struct {
+	URL string `yaml:"url,omitempty"`
+}
+
+type SnowflakeProfile struct {
+	JDBCURL           string `yaml:"jdbcUrl,omitempty"`
+	UserSecret        string `yaml:"userSecret,omitempty"`
+	PasswordSecret    string `yaml:"passwordSecret,omitempty"`
+	Logical           string `yaml:"logical,omitempty"`           // ex: lz-sql-ih-dev (pasta/nome do sink)
+	CredentialsSecret string `yaml:"credentialsSecret,omitempty"` // Secret com SNOWFLAKE_ACCOUNT/USER/PASSWORD/WAREHOUSE usado pelo job
+	Role              string `yaml:"role,omitempty"`
+	Database          string `yaml:"database,omitempty"`
+}
+
+type KubernetesProfile struct {
+	SourceNamespace string `yaml:"sourceNamespace,omitempty"` // default: strimzi
+	SinkNamespace   string `yaml:"sinkNamespace,omitempty"`
+	JobNamespace    string `yaml:"jobNamespace,omitempty"`
+	JobImage        string `yaml:"jobImage,omitempty"` // imagem do job neste ambiente (ex: registry interno)
+}
+
+type SqlServerProfile struct {
+	Host      string          `yaml:"host,omitempty"`
+	Port      string          `yaml:"port,omitempty"`
+	Snowflake SnowflakeTarget `yaml:"snowflake,omitempty"` // destino do alias neste ambiente
+}
+
+// ProfileEntry é o perfil de um ambiente em profiles.<env>.
+type ProfileEntry struct {
+	Connect        ConnectProfile              `yaml:"connect,omitempty"`
+	Kafka          KafkaProfile                `yaml:"kafka,omitempty"`
+	SchemaRegistry SchemaRegistryProfile       `yaml:"schemaRegistry,omitempty"`
+	Snowflake      SnowflakeProfile            `yaml:"snowflake,omitempty"`
+	Kubernetes     KubernetesProfile           `yaml:"kubernetes,omitempty"`
+	SqlServers     map[string]SqlServerProfile `yaml:"sqlservers,omitempty"` // por alias
+}
+
+// EnvProfile é o perfil resolvido de um ambiente: os valores que mudam de um
+// ambiente para outro (cluster, Kafka, schema registry, Snowflake, Kubernetes e hosts SQL Server).
+type EnvProfile struct {
+	Env string
+
+	ClusterName                   string
+	SchemaRegistryURL             string
+	SchemaHistoryBootstrapServers string
+
+	SnowflakeJDBCURL        string
+	SnowflakeUserSecret     string
+	SnowflakePasswordSecret string
+	SnowflakeLogical        string
+	SnowflakeCredsSecret    string
+	SnowflakeRole           string
+	SnowflakeDatabase       string
+
+	SourceNamespace string
+	SinkNamespace   string
+	JobNamespace    string
+	JobImage        string
+
+	sqlServerHosts map[string]string // alias (upper) -> host
+	sqlServerPorts map[string]string // alias (upper) -> porta
+
+	sqlServerTargets map[string]SnowflakeTarget // alias (upper) -> destino Snowflake neste ambiente
+
+	clusters map[string]ConnectCluster // chave lógica ("" = default) -> cluster neste ambiente
+}
+
+// ConnectCluster é um cluster Kafka Connect (Strimzi) resolvido para o ambiente.
+type ConnectCluster struct {
+	Key           string // chave lógica em connectCluster ("" = cluster default)
+	Name          string // label strimzi.io/cluster
+	MaxConnectors int    // 0 = sem limite
+	MaxTasks      int
+}
+
+// profileField liga uma chave do perfil (usada no -set) à variável de ambiente e ao campo do YAML.
+type profileField struct {
+	key      string
+	env      string
+	required bool
+	def      string
+	yaml     func(e *ProfileEntry) string
+	set      func(p *EnvProfile, v string)
+}
+
+var profileFields = []profileField{
+	{"connect.clusterName", "CONNECT_CLUSTER_NAME", true, "",
+		func(e *ProfileEntry) string { return e.Connect.ClusterName },
+		func(p *EnvProfile, v string) { p.ClusterName = v }},
+	{"kafka.schemaHistoryBootstrapServers", "SCHEMA_HISTORY_BOOTSTRAP_SERVERS", true, "",
+		func(e *ProfileEntry) string { return e.Kafka.SchemaHistoryBootstrapServers },
+		func(p *EnvProfile, v string) { p.SchemaHistoryBootstrapServers = v }},
+	{"schemaRegistry.url", "SCHEMA_REGISTRY_URL", true, "",
+		func(e *ProfileEntry) string { return e.SchemaRegistry.URL },
+		func(p *EnvProfile, v string) { p.SchemaRegistryURL = v }},
+	{"snowflake.jdbcUrl", "SNOWFLAKE_JDBC_URL", true, "",
+		func(e *ProfileEntry) string { return e.Snowflake.JDBCURL },
+		func(p *EnvProfile, v string) { p.SnowflakeJDBCURL = v }},
+	{"snowflake.userSecret", "SNOWFLAKE_USER_SECRET", true, "",
+		func(e *ProfileEntry) string { return e.Snowflake.UserSecret },
+		func(p *EnvProfile, v string) { p.SnowflakeUserSecret = v }},
+	{"snowflake.passwordSecret", "SNOWFLAKE_PASSWORD_SECRET", true, "",
+		func(e *ProfileEntry) string { return e.Snowflake.PasswordSecret },
+		func(p *EnvProfile, v string) { p.SnowflakePasswordSecret = v }},
+	{"snowflake.logical", "SNOWFLAKE_DB_LOGICAL", true, "",
+		func(e *ProfileEntry) string { return e.Snowflake.Logical },
+		func(p *EnvProfile, v string) { p.SnowflakeLogical = v }},
+	{"snowflake.credentialsSecret", "SNOWFLAKE_CREDENTIALS_SECRET", true, "",
+		func(e *ProfileEntry) string { return e.Snowflake.CredentialsSecret },
+		func(p *EnvProfile, v string) { p.SnowflakeCredsSecret = v }},
+	{"snowflake.role", "SNOWFLAKE_ROLE", true, "",
+		func(e *ProfileEntry) string { return e.Snowflake.Role },
+		func(p *EnvProfile, v string) { p.SnowflakeRole = v }},
+	{"snowflake.database", "SNOWFLAKE_DATABASE", true, "",
+		func(e *ProfileEntry) string { return e.Snowflake.Database },
+		func(p *EnvProfile, v string) { p.SnowflakeDatabase = v }},
+	{"kubernetes.sourceNamespace", "K8S_SOURCE_NAMESPACE", false, "strimzi",
+		func(e *ProfileEntry) string { return e.Kubernetes.SourceNamespace },
+		func(p *EnvProfile, v string) { p.SourceNamespace = v }},
+	{"kubernetes.sinkNamespace", "K8S_SINK_NAMESPACE", false, "",
+		func(e *ProfileEntry) string { return e.Kubernetes.SinkNamespace },
+		func(p *EnvProfile, v string) { p.SinkNamespace = v }},
+	{"kubernetes.jobNamespace", "K8S_JOB_NAMESPACE", false, "",
+		func(e *ProfileEntry) string { return e.Kubernetes.JobNamespace },
+		func(p *EnvProfile, v string) { p.JobNamespace = v }},
+	{"kubernetes.jobImage", "K8S_JOB_IMAGE", false, "",
+		func(e *ProfileEntry) string { return e.Kubernetes.JobImage },
+		func(p *EnvProfile, v string) { p.JobImage = v }},
+}
+
+// ResolveProfile monta o perfil do ambiente env. Precedência (maior primeiro):
+//
+//  1. flag -set chave=valor (overrides)
+//  2. variável de ambiente com sufixo do ambiente (ex: CONNECT_CLUSTER_NAME_HOMOLOG)
+//  3. profiles.<env> no ingestion.yaml
+//  4. variável de ambiente sem sufixo (ex: CONNECT_CLUSTER_NAME), compatibilidade
+//
+// Não há defaults de produção: valor obrigatório não resolvido é erro.
+// cfg pode ser nil (modo single); nesse caso só flags e envs são usados.
+func ResolveProfile(cfg *IngestionConfig, env string, overrides map[string]string) (EnvProfile, error) {
+	p := EnvProfile{
+		Env:            env,
+		sqlServerHosts: map[string]string{},
+		sqlServerPorts: map[string]string{},
+
+		sqlServerTargets: map[string]SnowflakeTarget{},
+
+		clusters: map[string]ConnectCluster{},
+	}
+
+	var entry ProfileEntry
+	if cfg != nil && len(cfg.Profiles) > 0 {
+		e, ok := cfg.Profiles[env]
+		if !ok {
+			return p, fmt.Errorf("profile %q não definido em profiles (disponíveis: %s)", env, strings.Join(profileNames(cfg), ", "))
+		}
+		entry = e
+	}
+
+	known := map[string]bool{}
+	var problems []string
+
+	for _, f := range profileFields {
+		known[f.key] = true
+		v := resolveValue(f.key, f.env, env, f.yaml(&entry), overrides)
+		if v == "" {
+			v = f.def
+		}
+		if v == "" && f.required {
+			problems = append(problems, fmt.Sprintf("%s não resolvido (profiles.%s.%s, %s ou %s)", f.key, env, f.key, envKey(f.env, env), f.env))
+			continue
+		}
+		f.set(&p, v)
+	}
+
+	// hosts SQL Server: aliases do YAML + aliases citados no perfil
+	aliases := map[string]string{}
+	if cfg != nil {
+		for _, srv := range cfg.SqlServers {
+			if a := strings.TrimSpace(srv.Alias); a != "" {
+				aliases[strings.ToUpper(a)] = a
+			}
+		}
+	}
+	for a := range entry.SqlServers {
+		aliases[strings.ToUpper(a)] = a
+	}
+
+	for upper, alias := range aliases {
+		sp := lookupSqlServerProfile(entry.SqlServers, alias)
+		hostKey := "sqlservers." + alias + ".host"
+		portKey := "sqlservers." + alias + ".port"
+		known[hostKey], known[portKey] = true, true
+
+		host := resolveValue(hostKey, "SQLSERVER_"+upper+"_HOST", env, sp.Host, overrides)
+		if host == "" {
+			problems = append(problems, fmt.Sprintf("%s não resolvido (profiles.%s.%s ou SQLSERVER_%s_HOST[_%s])",
+				hostKey, env, hostKey, upper, strings.ToUpper(env)))
+		}
+		port := resolveValue(portKey, "SQLSERVER_"+upper+"_PORT", env, sp.Port, overrides)
+		if port == "" {
+			port = "1433"
+		}
+		p.sqlServerHosts[upper] = host
+		p.sqlServerPorts[upper] = port
+		p.sqlServerTargets[upper] = sp.Snowflake
+	}
+
+	p.clusters[""] = ConnectCluster{
+		Name:          p.ClusterName,
+		MaxConnectors: entry.Connect.MaxConnectors,
+		MaxTasks:      entry.Connect.MaxTasks,
+	}
+
+	// clusters adicionais: chaves citadas no YAML (alias/tabela) + as declaradas no perfil
+	keys := map[string]bool{}
+	if cfg != nil {
+		for _, k := range cfg.ConnectClusterKeys() {
+			keys[k] = true
+		}
+	}
+	for k := range entry.Connect.Clusters {
+		keys[k] = true
+	}
+	for key := range keys {
+		cp := entry.Connect.Clusters[key]
+		nameKey := "connect.clusters." + key + ".name"
+		known[nameKey] = true
+
+		envVar := "CONNECT_CLUSTER_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_")) + "_NAME"
+		name := resolveValue(nameKey, envVar, env, cp.Name, overrides)
+		if name == "" {
+			problems = append(problems, fmt.Sprintf("%s não resolvido (profiles.%s.%s ou %s[_%s])",
+				nameKey, env, nameKey, envVar, strings.ToUpper(env)))
+		}
+		p.clusters[key] = ConnectCluster{Key: key, Name: name, MaxConnectors: cp.MaxConnectors, MaxTasks: cp.MaxTasks}
+	}
+
+	for k := range overrides {
+		if !known[k] {
+			problems = append(problems, fmt.Sprintf("-set %s: chave desconhecida", k))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return p, fmt.Errorf("profile %s incompleto:\n- %s", env, strings.Join(problems, "\n- "))
+	}
+
+	return p, nil
+}
+
+// ConnectCluster retorna o cluster Kafka Connect da chave lógica ("" = cluster default).
+func (p EnvProfile) ConnectCluster(key string) (ConnectCluster, error) {
+	if c, ok := p.clusters[key]; ok && c.Name != "" {
+		return c, nil
+	}
+	return ConnectCluster{}, fmt.Errorf("cluster Kafka Connect %q não definido em profiles.%s.connect.clusters", key, p.Env)
+}
+
+// ConnectClusters lista os clusters do ambiente (default primeiro, depois por chave).
+func (p EnvProfile) ConnectClusters() []ConnectCluster {
+	keys := make([]string, 0, len(p.clusters))
+	for k := range p.clusters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]ConnectCluster, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, p.clusters[k])
+	}
+	return out
+}
+
+// SqlServerHost retorna o host do alias no ambiente.
+func (p EnvProfile) SqlServerHost(alias string) (string, error) {
+	if h := p.sqlServerHosts[strings.ToUpper(alias)]; h != "" {
+		return h, nil
+	}
+	return RequireEnvFor("SQLSERVER_"+strings.ToUpper(alias)+"_HOST", p.Env)
+}
+
+// SqlServerPort retorna a porta do alias no ambiente (default 1433).
+func (p EnvProfile) SqlServerPort(alias string) string {
+	if v := p.sqlServerPorts[strings.ToUpper(alias)]; v != "" {
+		return v
+	}
+	return envValue("SQLSERVER_"+strings.ToUpper(alias)+"_PORT", p.Env, "1433")
+}
+
+// RequireEnvFor é o RequireEnv com sufixo de ambiente (KEY_<ENV>, depois KEY).
+func RequireEnvFor(key, env string) (string, error) {
+	if v := envValue(key, env, ""); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("missing required env var %s (ou %s)", key, envKey(key, env))
+}
+
+// ParseOverrides converte entradas "chave=valor" do -set em mapa.
+func ParseOverrides(items []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, it := range items {
+		k, v, ok := strings.Cut(it, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("-set %q inválido (esperado chave=valor)", it)
+		}
+		out[k] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+func resolveValue(key, envVar, env, fromYAML string, overrides map[string]string) string {
+	if v := strings.TrimSpace(overrides[key]); v != "" {
+		return v
+	}
+	if env != "" {
+		if v := os.Getenv(envKey(envVar, env)); v != "" {
+			return v
+		}
+	}
+	if v := strings.TrimSpace(fromYAML); v != "" {
+		return v
+	}
+	return os.Getenv(envVar)
+}
+
+func lookupSqlServerProfile(m map[string]SqlServerProfile, alias string) SqlServerProfile {
+	if sp, ok := m[alias]; ok {
+		return sp
+	}
+	for k, sp := range m {
+		if strings.EqualFold(k, alias) {
+			return sp
+		}
+	}
+	return SqlServerProfile{}
+}
+
+// yamlProfiles monta, para cada profiles.<env>, o perfil só com o que vem do YAML
+// (database Snowflake e destinos por alias), suficiente para resolver SnowflakeDest
+// na validação sem variáveis de ambiente. Sem profiles, devolve um perfil vazio.
+func yamlProfiles(cfg *IngestionConfig) []EnvProfile {
+	if len(cfg.Profiles) == 0 {
+		return []EnvProfile{{}}
+	}
+	out := make([]EnvProfile, 0, len(cfg.Profiles))
+	for _, env := range profileNames(cfg) {
+		entry := cfg.Profiles[env]
+		p := EnvProfile{Env: env, SnowflakeDatabase: entry.Snowflake.Database, sqlServerTargets: map[string]SnowflakeTarget{}}
+		for alias, sp := range entry.SqlServers {
+			p.sqlServerTargets[strings.ToUpper(alias)] = sp.Snowflake
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func profileNames(cfg *IngestionConfig) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for n := range cfg.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func envKey(key, env string) string {
+	return key + "_" + strings.ToUpper(strings.ReplaceAll(env, "-", "_"))
+}
+
+func envValue(key, env, def string) string {
+	if env != "" {
+		if v := os.Getenv(envKey(key, env)); v != "" {
+			return v
+		}
+	}
+	return GetEnvOrDefault(key, def)
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Sizing configura a classe de tamanho (p/m/g) das tabelas (sizing: no ingestion.yaml).
+// Com auto, tabelas sem size explícito são classificadas pelas métricas do SQL Server;
+// a classe define os recursos dos connectors (tasks, threads de snapshot, buffer do sink
+// e partições dos tópicos).
+type Sizing struct {
+	Auto       bool                     `yaml:"auto,omitempty"`       // classifica tabelas sem size (default: -size)
+	Thresholds map[string]SizeThreshold `yaml:"thresholds,omitempty"` // m e g: mínimo para a tabela entrar na classe
+	Classes    map[string]SizeClass     `yaml:"classes,omitempty"`    // recursos por classe (campos vazios = default)
+
+	Configured bool `yaml:"-"` // sizing: informado no ingestion.yaml (preenchido por ResolveSizing)
+}
+
+// SizeThreshold: a tabela entra na classe quando atinge QUALQUER métrica informada (0 = ignorada).
+type SizeThreshold struct {
+	Rows          int64 `yaml:"rows,omitempty"`
+	ChangesPerDay int64 `yaml:"changesPerDay,omitempty"`
+	Bytes         int64 `yaml:"bytes,omitempty"` // linhas x largura estimada das colunas replicadas
+}
+
+// SizeClass são os recursos gerados para uma classe.
+type SizeClass struct {
+	SinkTasksMax       int   `yaml:"sinkTasksMax,omitempty"`       // tasksMax do sink (o source SQL Server usa 1 task por database)
+	SnapshotMaxThreads int   `yaml:"snapshotMaxThreads,omitempty"` // snapshot.max.threads do source
+	Partitions         int   `yaml:"partitions,omitempty"`         // topic.creation.default.partitions do source
+	BufferCountRecords int64 `yaml:"bufferCountRecords,omitempty"` // buffer.count.records do sink
+	BufferFlushTime    int64 `yaml:"bufferFlushTime,omitempty"`    // buffer.flush.time do sink (segundos)
+	BufferSizeBytes    int64 `yaml:"bufferSizeBytes,omitempty"`    // buffer.size.bytes do sink
+}
+
+// TableMetrics são as métricas usadas na classificação.
+type TableMetrics struct {
+	Rows          int64
+	ChangesPerDay int64
+	RowBytes      int64 // largura estimada de uma linha
+}
+
+// DefaultSizing: limites e recursos que completam um bloco sizing: parcial.
+// Sem o bloco, os connectors saem como antes do sizing (ver legacyClass).
+func DefaultSizing() Sizing {
+	return Sizing{
+		Thresholds: map[string]SizeThreshold{
+			"m": {Rows: 1_000_000, ChangesPerDay: 100_000, Bytes: 1 << 30},
+			"g": {Rows: 50_000_000, ChangesPerDay: 5_000_000, Bytes: 50 << 30},
+		},
+		Classes: map[string]SizeClass{
+			"p": {SinkTasksMax: 1, SnapshotMaxThreads: 2, Partitions: 1, BufferCountRecords: 10_000, BufferFlushTime: 300, BufferSizeBytes: 10_000_000},
+			"m": {SinkTasksMax: 1, SnapshotMaxThreads: 5, Partitions: 3, BufferCountRecords: 50_000, BufferFlushTime: 120, BufferSizeBytes: 50_000_000},
+			"g": {SinkTasksMax: 3, SnapshotMaxThreads: 8, Partitions: 6, BufferCountRecords: 200_000, BufferFlushTime: 60, BufferSizeBytes: 200_000_000},
+		},
+	}
+}
+
+// legacyClass são os recursos gerados para qualquer size quando sizing: não é informado:
+// os valores fixos de antes, sem buffer.* no sink e sem topic.creation.* no source.
+var legacyClass = SizeClass{SinkTasksMax: 1, SnapshotMaxThreads: 5}
+
+// ResolveSizing completa s com os defaults, campo a campo.
+func ResolveSizing(s Sizing) Sizing {
+	d := DefaultSizing()
+	out := Sizing{Auto: s.Auto, Thresholds: map[string]SizeThreshold{}, Classes: map[string]SizeClass{}}
+	out.Configured = s.Auto || len(s.Thresholds) > 0 || len(s.Classes) > 0
+
+	for _, size := range []string{"m", "g"} {
+		t, def := s.Thresholds[size], d.Thresholds[size]
+		if t.Rows == 0 {
+			t.Rows = def.Rows
+		}
+		if t.ChangesPerDay == 0 {
+			t.ChangesPerDay = def.ChangesPerDay
+		}
+		if t.Bytes == 0 {
+			t.Bytes = def.Bytes
+		}
+		out.Thresholds[size] = t
+	}
+
+	for _, size := range ValidSizes {
+		c, def := s.Classes[size], d.Classes[size]
+		if c.SinkTasksMax == 0 {
+			c.SinkTasksMax = def.SinkTasksMax
+		}
+		if c.SnapshotMaxThreads == 0 {
+			c.SnapshotMaxThreads = def.SnapshotMaxThreads
+		}
+		if c.Partitions == 0 {
+			c.Partitions = def.Partitions
+		}
+		if c.BufferCountRecords == 0 {
+			c.BufferCountRecords = def.BufferCountRecords
+		}
+		if c.BufferFlushTime == 0 {
+			c.BufferFlushTime = def.BufferFlushTime
+		}
+		if c.BufferSizeBytes == 0 {
+			c.BufferSizeBytes = def.BufferSizeBytes
+		}
+		out.Classes[size] = c
+	}
+	return out
+}
+
+// Classify devolve a classe da tabela (g antes de m) e a métrica que decidiu.
+// Espera um Sizing já resolvido.
+func (s Sizing) Classify(m TableMetrics) (string, string) {
+	for _, size := range []string{"g", "m"} {
+		if reason := s.Thresholds[size].reached(m); reason != "" {
+			return size, reason
+		}
+	}
+	return "p", "abaixo dos limites de m"
+}
+
+func (t SizeThreshold) reached(m TableMetrics) string {
+	switch {
+	case t.Rows > 0 && m.Rows >= t.Rows:
+		return fmt.Sprintf("rows %d >= %d", m.Rows, t.Rows)
+	case t.ChangesPerDay > 0 && m.ChangesPerDay >= t.ChangesPerDay:
+		return fmt.Sprintf("alterações/dia %d >= %d", m.ChangesPerDay, t.ChangesPerDay)
+	case t.Bytes > 0 && m.Rows*m.RowBytes >= t.Bytes:
+		return fmt.Sprintf("bytes %d >= %d", m.Rows*m.RowBytes, t.Bytes)
+	}
+	return ""
+}
+
+// Class devolve os recursos de size. Sem sizing: no ingestion.yaml, todo size recebe
+// legacyClass: só o nome dos arquivos e connectors muda com o size.
+// Espera um Sizing já resolvido.
+func (s Sizing) Class(size string) SizeClass {
+	if !s.Configured {
+		return legacyClass
+	}
+	return s.Classes[size]
+}
+
+// SinkBuffer são as propriedades de buffer/flush do sink da classe (ordenadas no template).
+// A classe sem buffer (legacyClass) não gera nenhuma.
+func (c SizeClass) SinkBuffer() map[string]string {
+	if c.BufferCountRecords == 0 && c.BufferFlushTime == 0 && c.BufferSizeBytes == 0 {
+		return nil
+	}
+	return map[string]string{
+		"buffer.count.records": strconv.FormatInt(c.BufferCountRecords, 10),
+		"buffer.flush.time":    strconv.FormatInt(c.BufferFlushTime, 10),
+		"buffer.size.bytes":    strconv.FormatInt(c.BufferSizeBytes, 10),
+	}
+}
+
+func validateSizing(ctx string, s Sizing) []string {
+	var problems []string
+
+	for _, size := range sortedKeys(s.Thresholds) {
+		if size != "m" && size != "g" {
+			problems = append(problems, fmt.Sprintf("%s.thresholds: classe %q inválida (limites só para m e g)", ctx, size))
+			continue
+		}
+		t := s.Thresholds[size]
+		if t.Rows < 0 || t.ChangesPerDay < 0 || t.Bytes < 0 {
+			problems = append(problems, fmt.Sprintf("%s.thresholds.%s: valores não podem ser negativos", ctx, size))
+		}
+	}
+	// com os defaults aplicados, g precisa ficar acima de m
+	r := ResolveSizing(s)
+	m, g := r.Thresholds["m"], r.Thresholds["g"]
+	if g.Rows < m.Rows || g.ChangesPerDay < m.ChangesPerDay || g.Bytes < m.Bytes {
+		problems = append(problems, fmt.Sprintf("%s.thresholds: limites de g (%+v) abaixo dos de m (%+v)", ctx, g, m))
+	}
+
+	for _, size := range sortedKeys(s.Classes) {
+		if !contains(ValidSizes, size) {
+			problems = append(problems, fmt.Sprintf("%s.classes: classe %q inválida (use p/m/g)", ctx, size))
+			continue
+		}
+		c := s.Classes[size]
+		if c.SinkTasksMax < 0 || c.SnapshotMaxThreads < 0 || c.Partitions < 0 ||
+			c.BufferCountRecords < 0 || c.BufferFlushTime < 0 || c.BufferSizeBytes < 0 {
+			problems = append(problems, fmt.Sprintf("%s.classes.%s: valores não podem ser negativos", ctx, size))
+		}
+	}
+	return problems
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SnowflakeTarget sobrescreve o destino Snowflake do profile. Pode ser declarado por alias
+// (sqlservers[].snowflake), por alias em um ambiente (profiles.<env>.sqlservers.<alias>.snowflake)
+// ou por tabela (tables[].snowflake). Campos vazios herdam do nível anterior.
+type SnowflakeTarget struct {
+	Database          string `yaml:"database,omitempty"`
+	Schema            string `yaml:"schema,omitempty"`
+	Logical           string `yaml:"logical,omitempty"` // landing zone: pasta/nome do sink e dos jobs
+	UserSecret        string `yaml:"userSecret,omitempty"`
+	PasswordSecret    string `yaml:"passwordSecret,omitempty"`
+	CredentialsSecret string `yaml:"credentialsSecret,omitempty"`
+}
+
+// SnowflakeDest é o destino resolvido de uma tabela. Schema vazio = database de origem.
+type SnowflakeDest struct {
+	Database          string
+	Schema            string
+	Logical           string
+	JDBCURL           string // db= ajustado quando o database difere do profile
+	UserSecret        string
+	PasswordSecret    string
+	CredentialsSecret string
+}
+
+var (
+	logicalPattern  = regexp.MustCompile(`^[a-z0-9]([a-z0-9_-]*[a-z0-9])?$`)
+	jdbcDBParam     = regexp.MustCompile(`([?&])db=[^&]*`)
+	identPatternCfg = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+)
+
+// SnowflakeDest resolve o destino de uma tabela do alias srv. Precedência (maior primeiro):
+// tabela (targetSchema, snowflake), profiles.<env>.sqlservers.<alias>.snowflake,
+// sqlservers[].snowflake e o snowflake do profile.
+func (p EnvProfile) SnowflakeDest(srv SqlServerEntry, t TableEntry) SnowflakeDest {
+	d := SnowflakeDest{
+		Database:          p.SnowflakeDatabase,
+		Logical:           p.SnowflakeLogical,
+		UserSecret:        p.SnowflakeUserSecret,
+		PasswordSecret:    p.SnowflakePasswordSecret,
+		CredentialsSecret: p.SnowflakeCredsSecret,
+	}
+
+	for _, lvl := range []SnowflakeTarget{srv.Snowflake, p.sqlServerTargets[strings.ToUpper(srv.Alias)], t.Snowflake} {
+		d.Database = firstNonEmpty(lvl.Database, d.Database)
+		d.Schema = firstNonEmpty(lvl.Schema, d.Schema)
+		d.Logical = firstNonEmpty(lvl.Logical, d.Logical)
+		d.UserSecret = firstNonEmpty(lvl.UserSecret, d.UserSecret)
+		d.PasswordSecret = firstNonEmpty(lvl.PasswordSecret, d.PasswordSecret)
+		d.CredentialsSecret = firstNonEmpty(lvl.CredentialsSecret, d.CredentialsSecret)
+	}
+	d.Schema = firstNonEmpty(t.TargetSchema, d.Schema)
+
+	d.Database = strings.ToUpper(d.Database)
+	d.Schema = strings.ToUpper(d.Schema)
+
+	d.JDBCURL = p.SnowflakeJDBCURL
+	if !strings.EqualFold(d.Database, p.SnowflakeDatabase) {
+		d.JDBCURL = WithJDBCDatabase(p.SnowflakeJDBCURL, d.Database)
+	}
+	return d
+}
+
+// TargetSet guarda a origem de cada destino Snowflake (DATABASE.SCHEMA.TABLE), para
+// acusar duas tabelas de origem gravando na mesma tabela de destino.
+type TargetSet map[string]string
+
+// Claim registra o destino de origin; é erro se outra origem já usa o mesmo destino.
+func (s TargetSet) Claim(database, schema, table, origin string) error {
+	key := strings.ToUpper(database) + "." + strings.ToUpper(schema) + "." + strings.ToUpper(table)
+	if prev, ok := s[key]; ok && prev != origin {
+		return fmt.Errorf("destino Snowflake %s já usado por %s", key, prev)
+	}
+	s[key] = origin
+	return nil
+}
+
+// WithJDBCDatabase troca (ou acrescenta) o parâmetro db= da URL JDBC do Snowflake.
+func WithJDBCDatabase(url, database string) string {
+	if jdbcDBParam.MatchString(url) {
+		return jdbcDBParam.ReplaceAllString(url, "${1}db="+database)
+	}
+	if strings.Contains(url, "?") {
+		return url + "&db=" + database
+	}
+	return url + "?db=" + database
+}
+
+func validateSnowflakeTarget(ctx string, t SnowflakeTarget) []string {
+	var problems []string
+	if t.Database != "" && !identPatternCfg.MatchString(t.Database) {
+		problems = append(problems, fmt.Sprintf("%s: snowflake.database %q inválido", ctx, t.Database))
+	}
+	if t.Schema != "" && !identPatternCfg.MatchString(t.Schema) {
+		problems = append(problems, fmt.Sprintf("%s: snowflake.schema %q inválido", ctx, t.Schema))
+	}
+	if t.Logical != "" && !logicalPattern.MatchString(t.Logical) {
+		problems = append(problems, fmt.Sprintf("%s: snowflake.logical %q inválido (minúsculas, dígitos, - e _)", ctx, t.Logical))
+	}
+	return problems
+}
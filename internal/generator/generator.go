@@ -1,19 +1,13 @@
 package generator
 
 import (
-	"bytes"
 	"log"
 	"os"
 	"path/filepath"
-	"text/template"
 )
 
-func RenderToFile(t *template.Template, data any, path string) error {
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		return err
-	}
-
+// WriteFile grava conteúdo já pronto (criando o diretório) e registra o caminho.
+func WriteFile(path string, data []byte) error {
 	dir := filepath.Dir(path)
 	if dir != "." && dir != "" {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -21,9 +15,10 @@ func RenderToFile(t *template.Template, data any, path string) error {
 		}
 	}
 
-	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+	if err := os.WriteFile(path, data, 0o644); err != nil {
 		return err
 	}
+	TrackWrite(path)
 
 	log.Printf("arquivo gerado: %s", path)
 	return nil
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"ih-ingestion/internal/yamlnode"
+)
+
+// MergeConnectorConfig aplica overrides ao spec.config dos KafkaConnector de doc
+// (multi-documento). A mescla é feita na árvore YAML: a ordem e os comentários das
+// chaves existentes são mantidos, chaves novas entram no fim em ordem alfabética e
+// valores nil removem a chave. overrides são planos (config.MergeConnectorConfig achata
+// os maps aninhados em chaves com ponto), como o spec.config do Kafka Connect.
+func MergeConnectorConfig(doc []byte, overrides map[string]any) ([]byte, error) {
+	if len(overrides) == 0 {
+		return doc, nil
+	}
+
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(doc))
+	for {
+		var n yaml.Node
+		if err := dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("YAML renderizado inválido: %w", err)
+		}
+		docs = append(docs, &n)
+	}
+
+	found := false
+	for _, d := range docs {
+		if len(d.Content) == 0 || d.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := d.Content[0]
+		if kind := yamlnode.Value(root, "kind"); kind == nil || kind.Value != "KafkaConnector" {
+			continue
+		}
+		found = true
+		spec := ensureMap(root, "spec")
+		cfg := ensureMap(spec, "config")
+		if err := mergeNode(cfg, overrides); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, errors.New("nenhum KafkaConnector no manifesto renderizado")
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	for _, d := range docs {
+		if err := enc.Encode(d); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func mergeNode(m *yaml.Node, overrides map[string]any) error {
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := overrides[k]
+		if v == nil {
+			deleteKey(m, k)
+			continue
+		}
+		if _, ok := v.(map[string]any); ok {
+			return fmt.Errorf("%s: spec.config só aceita valores escalares (use chaves com ponto)", k)
+		}
+
+		var val yaml.Node
+		if err := val.Encode(v); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		if existing := yamlnode.Value(m, k); existing != nil {
+			// mantém o comentário da linha do template
+			val.LineComment = existing.LineComment
+			*existing = val
+			continue
+		}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, &val)
+	}
+	return nil
+}
+
+// ensureMap devolve o mapping em key, criando-o (ou substituindo um escalar) se preciso.
+func ensureMap(m *yaml.Node, key string) *yaml.Node {
+	if v := yamlnode.Value(m, key); v != nil {
+		if v.Kind != yaml.MappingNode {
+			*v = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		return v
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+	return v
+}
+
+func deleteKey(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			// o comentário de cabeçalho da chave removida passa para a seguinte
+			if i+2 < len(m.Content) && m.Content[i].HeadComment != "" && m.Content[i+2].HeadComment == "" {
+				m.Content[i+2].HeadComment = m.Content[i].HeadComment
+			}
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Registro dos caminhos gravados pelo gerador na execução atual.
+// O GitOps usa essa lista para fazer stage apenas do que foi gerado.
+var (
+	trackMu sync.Mutex
+	written = map[string]struct{}{}
+)
+
+// TrackWrite registra um arquivo gravado pelo gerador.
+func TrackWrite(path string) {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+
+	written[absPath(path)] = struct{}{}
+}
+
+// TrackedPaths retorna (ordenados) todos os caminhos absolutos gravados.
+func TrackedPaths() []string {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+
+	out := make([]string, 0, len(written))
+	for p := range written {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ResetTracking limpa o registro (útil quando o gerador roda mais de uma vez no mesmo processo).
+func ResetTracking() {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+
+	written = map[string]struct{}{}
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,65 @@
+package gitops
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// authMethod escolhe a autenticação conforme a URL do repo:
+//   - SSH (git@host:... ou ssh://): chave em GIT_SSH_KEY_PATH (+ GIT_SSH_KEY_PASSPHRASE);
+//     known_hosts em GIT_SSH_KNOWN_HOSTS ou o padrão do usuário
+//   - HTTPS: token em GIT_TOKEN (usuário GIT_AUTH_USERNAME, default "git")
+//
+// Sem credenciais configuradas retorna nil (repo público ou credencial embutida na URL).
+func authMethod(cfg *Config) (transport.AuthMethod, error) {
+	if isSSHURL(cfg.RepoURL) {
+		if cfg.SSHKeyPath == "" {
+			return nil, nil
+		}
+
+		user := "git"
+		if ep, err := transport.NewEndpoint(cfg.RepoURL); err == nil && ep.User != "" {
+			user = ep.User
+		}
+
+		keys, err := ssh.NewPublicKeysFromFile(user, cfg.SSHKeyPath, cfg.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("carregando chave SSH %s: %w", cfg.SSHKeyPath, err)
+		}
+
+		var files []string
+		if cfg.SSHKnownHosts != "" {
+			files = append(files, cfg.SSHKnownHosts)
+		}
+		cb, err := ssh.NewKnownHostsCallback(files...)
+		if err != nil {
+			return nil, fmt.Errorf("carregando known_hosts: %w", err)
+		}
+		keys.HostKeyCallback = cb
+
+		return keys, nil
+	}
+
+	if strings.TrimSpace(cfg.AuthToken) == "" {
+		return nil, nil
+	}
+
+	user := cfg.AuthUsername
+	if user == "" {
+		user = "git"
+	}
+	return &http.BasicAuth{Username: user, Password: cfg.AuthToken}, nil
+}
+
+func isSSHURL(u string) bool {
+	u = strings.TrimSpace(u)
+	if strings.HasPrefix(u, "ssh://") {
+		return true
+	}
+	// formato scp-like: git@host:org/repo.git
+	return !strings.Contains(u, "://") && strings.Contains(u, "@") && strings.Contains(u, ":")
+}
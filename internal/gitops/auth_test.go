@@ -0,0 +1,130 @@
+package gitops
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestIsSSHURL(t *testing.T) {
+	tests := map[string]bool{
+		"git@github.com:acme/manifests.git":       true,
+		"ssh://git@gitlab.example.com/acme/m.git": true,
+		"https://github.com/acme/manifests.git":   false,
+		"https://user@github.com/acme/manifests":  false,
+		"/srv/git/manifests.git":                  false,
+		"file:///srv/git/manifests.git":           false,
+	}
+	for u, want := range tests {
+		if got := isSSHURL(u); got != want {
+			t.Errorf("isSSHURL(%q) = %v, esperado %v", u, got, want)
+		}
+	}
+}
+
+func TestAuthMethodHTTPS(t *testing.T) {
+	auth, err := authMethod(&Config{RepoURL: "https://github.com/acme/m.git"})
+	if err != nil || auth != nil {
+		t.Fatalf("sem token: esperado nil, veio %v, %v", auth, err)
+	}
+
+	auth, err = authMethod(&Config{RepoURL: "https://github.com/acme/m.git", AuthToken: "tok"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	basic, ok := auth.(*githttp.BasicAuth)
+	if !ok || basic.Username != "git" || basic.Password != "tok" {
+		t.Errorf("auth = %#v", auth)
+	}
+
+	auth, _ = authMethod(&Config{RepoURL: "https://dev.azure.com/o/p/_git/r", AuthToken: "tok", AuthUsername: "pat"})
+	if basic := auth.(*githttp.BasicAuth); basic.Username != "pat" {
+		t.Errorf("usuário = %q", basic.Username)
+	}
+}
+
+func TestAuthMethodSSH(t *testing.T) {
+	dir := t.TempDir()
+
+	auth, err := authMethod(&Config{RepoURL: "git@github.com:acme/m.git"})
+	if err != nil || auth != nil {
+		t.Fatalf("sem chave: esperado nil, veio %v, %v", auth, err)
+	}
+
+	_, err = authMethod(&Config{RepoURL: "git@github.com:acme/m.git", SSHKeyPath: filepath.Join(dir, "nao-existe")})
+	if err == nil || !strings.Contains(err.Error(), "carregando chave SSH") {
+		t.Fatalf("esperado erro de chave, veio %v", err)
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	knownHosts := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(knownHosts, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err = authMethod(&Config{RepoURL: "ssh://deploy@gitlab.example.com/acme/m.git", SSHKeyPath: keyPath, SSHKnownHosts: knownHosts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, ok := auth.(*gitssh.PublicKeys)
+	if !ok || keys.User != "deploy" || keys.HostKeyCallback == nil {
+		t.Errorf("auth = %#v", auth)
+	}
+
+	_, err = authMethod(&Config{RepoURL: "git@github.com:acme/m.git", SSHKeyPath: keyPath, SSHKnownHosts: filepath.Join(dir, "nao-existe")})
+	if err == nil || !strings.Contains(err.Error(), "known_hosts") {
+		t.Fatalf("esperado erro de known_hosts, veio %v", err)
+	}
+}
+
+// O token vai no clone via HTTPS e a recusa do servidor volta como *gitops.Error.
+func TestCloneHTTPSAuth(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got == "" {
+			got = r.Header.Get("Authorization")
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig(t, srv.URL+"/acme/manifests.git")
+	cfg.AuthToken = "s3cr3t"
+
+	_, err := PrepareRepo(cfg, "", "vendas")
+	var gerr *Error
+	if !errors.As(err, &gerr) || !strings.HasPrefix(gerr.Op, "clone ") {
+		t.Fatalf("esperado *gitops.Error de clone, veio %v", err)
+	}
+	if !errors.Is(err, transport.ErrAuthorizationFailed) {
+		t.Errorf("esperado ErrAuthorizationFailed na causa, veio %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.SetBasicAuth("git", "s3cr3t")
+	if got != req.Header.Get("Authorization") {
+		t.Errorf("Authorization = %q", got)
+	}
+}
@@ -0,0 +1,113 @@
+package gitops
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Azure DevOps limita a descrição do PR a 4000 caracteres.
+const azureMaxDescription = 4000
+
+// truncateDescription corta em max caracteres (runes, não bytes) terminando em "...",
+// para não partir um caractere multibyte no meio.
+func truncateDescription(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-3]) + "..."
+}
+
+// azureDevOpsProvider abre PRs via REST API do Azure DevOps (Azure Repos).
+type azureDevOpsProvider struct {
+	apiURL       string
+	organization string
+	project      string
+	repository   string
+	token        string
+	client       *http.Client
+}
+
+// repository: "organization/project/repository"
+func newAzureDevOpsProvider(apiURL, repository, token string, client *http.Client) (*azureDevOpsProvider, error) {
+	parts := strings.Split(strings.Trim(repository, "/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("GIT_PR_REPOSITORY inválido para azure: %q (esperado organization/project/repository)", repository)
+	}
+	if strings.TrimSpace(apiURL) == "" {
+		apiURL = "https://dev.azure.com"
+	}
+
+	return &azureDevOpsProvider{
+		apiURL:       strings.TrimRight(apiURL, "/"),
+		organization: parts[0],
+		project:      parts[1],
+		repository:   parts[2],
+		token:        token,
+		client:       client,
+	}, nil
+}
+
+func (p *azureDevOpsProvider) Name() string { return "azure" }
+
+func (p *azureDevOpsProvider) CreatePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	// PAT vai como Basic auth com usuário vazio
+	auth := base64.StdEncoding.EncodeToString([]byte(":" + p.token))
+	headers := map[string]string{"Authorization": "Basic " + auth}
+
+	description := truncateDescription(pr.Body, azureMaxDescription)
+
+	payload := map[string]any{
+		"sourceRefName": "refs/heads/" + pr.SourceBranch,
+		"targetRefName": "refs/heads/" + pr.TargetBranch,
+		"title":         pr.Title,
+		"description":   description,
+	}
+	if len(pr.Labels) > 0 {
+		labels := make([]map[string]string, 0, len(pr.Labels))
+		for _, l := range pr.Labels {
+			labels = append(labels, map[string]string{"name": l})
+		}
+		payload["labels"] = labels
+	}
+	// Reviewers no Azure DevOps são IDs (GUID) de usuários ou grupos
+	if len(pr.Reviewers) > 0 {
+		reviewers := make([]map[string]string, 0, len(pr.Reviewers))
+		for _, r := range pr.Reviewers {
+			reviewers = append(reviewers, map[string]string{"id": r})
+		}
+		payload["reviewers"] = reviewers
+	}
+
+	prURL := fmt.Sprintf("%s/%s/%s/_apis/git/repositories/%s/pullrequests?api-version=7.1",
+		p.apiURL,
+		url.PathEscape(p.organization),
+		url.PathEscape(p.project),
+		url.PathEscape(p.repository),
+	)
+
+	var created struct {
+		PullRequestID int `json:"pullRequestId"`
+		Repository    struct {
+			WebURL string `json:"webUrl"`
+		} `json:"repository"`
+	}
+	if err := doJSON(ctx, p.client, http.MethodPost, prURL, headers, payload, &created); err != nil {
+		if isAlreadyExists(err) {
+			return "", fmt.Errorf("%w: %s", ErrPullRequestExists, pr.SourceBranch)
+		}
+		return "", fmt.Errorf("criando PR no azure devops: %w", err)
+	}
+
+	webURL := created.Repository.WebURL
+	if webURL == "" {
+		webURL = fmt.Sprintf("%s/%s/%s/_git/%s", p.apiURL, p.organization, p.project, p.repository)
+	}
+
+	return fmt.Sprintf("%s/pullrequest/%d", webURL, created.PullRequestID), nil
+}
@@ -0,0 +1,118 @@
+package gitops
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func newTestAzure(t *testing.T, responses map[string]fakeResponse) (*fakeAPI, *azureDevOpsProvider) {
+	t.Helper()
+	api, srv := newFakeAPI(t, responses)
+	p, err := newAzureDevOpsProvider(srv.URL, "contoso/Dados/ingestion-manifests", "pat-token", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api, p
+}
+
+const azurePRPath = "POST /contoso/Dados/_apis/git/repositories/ingestion-manifests/pullrequests"
+
+func TestAzureCreatePullRequest(t *testing.T) {
+	api, p := newTestAzure(t, map[string]fakeResponse{
+		azurePRPath: {201, `{"pullRequestId": 88, "repository": {"webUrl": "https://dev.azure.com/contoso/Dados/_git/ingestion-manifests"}}`},
+	})
+
+	url, err := p.CreatePullRequest(context.Background(), PullRequest{
+		Title:        "Ingestion wave vendas",
+		Body:         "corpo",
+		SourceBranch: "ingestion/vendas",
+		TargetBranch: "main",
+		Labels:       []string{"ingestion"},
+		Reviewers:    []string{"6f1c0a9e-0000-4000-8000-000000000001"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://dev.azure.com/contoso/Dados/_git/ingestion-manifests/pullrequest/88" {
+		t.Errorf("url = %q", url)
+	}
+
+	calls := api.calls()
+	if len(calls) != 1 {
+		t.Fatalf("esperado 1 chamada, veio %d", len(calls))
+	}
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte(":pat-token"))
+	if got := calls[0].Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, esperado %q", got, want)
+	}
+	if calls[0].Query != "api-version=7.1" {
+		t.Errorf("query = %q", calls[0].Query)
+	}
+	jsonEqual(t, "payload do PR", calls[0].Body, map[string]any{
+		"sourceRefName": "refs/heads/ingestion/vendas",
+		"targetRefName": "refs/heads/main",
+		"title":         "Ingestion wave vendas",
+		"description":   "corpo",
+		"labels":        []map[string]string{{"name": "ingestion"}},
+		"reviewers":     []map[string]string{{"id": "6f1c0a9e-0000-4000-8000-000000000001"}},
+	})
+}
+
+func TestAzurePullRequestExists(t *testing.T) {
+	_, p := newTestAzure(t, map[string]fakeResponse{
+		azurePRPath: {409, `{"message":"TF401179: An active pull request for the source and target branch already exists."}`},
+	})
+
+	_, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "ingestion/vendas", TargetBranch: "main"})
+	if !errors.Is(err, ErrPullRequestExists) {
+		t.Fatalf("esperado ErrPullRequestExists, veio %v", err)
+	}
+}
+
+func TestAzureError(t *testing.T) {
+	_, p := newTestAzure(t, map[string]fakeResponse{
+		azurePRPath: {400, `{"message":"TF401398: The pull request cannot be activated because the source and/or the target branch no longer exists"}`},
+	})
+
+	_, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "ingestion/vendas", TargetBranch: "main"})
+	var he *httpStatusError
+	if !errors.As(err, &he) || he.Status != http.StatusBadRequest {
+		t.Fatalf("esperado status 400, veio %v", err)
+	}
+}
+
+func TestAzureTruncatesDescriptionOnRunes(t *testing.T) {
+	api, p := newTestAzure(t, map[string]fakeResponse{
+		azurePRPath: {201, `{"pullRequestId": 1}`},
+	})
+
+	body := strings.Repeat("ção ", 2000) // 8000 runes, 12000 bytes
+	if _, err := p.CreatePullRequest(context.Background(), PullRequest{Body: body, SourceBranch: "b", TargetBranch: "main"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := api.calls()[0].Body["description"].(string)
+	if !utf8.ValidString(got) {
+		t.Fatal("descrição truncada não é UTF-8 válido")
+	}
+	if n := utf8.RuneCountInString(got); n != azureMaxDescription {
+		t.Errorf("descrição com %d caracteres, esperado %d", n, azureMaxDescription)
+	}
+	if !strings.HasSuffix(got, "...") {
+		t.Errorf("descrição truncada sem reticências: ...%q", got[len(got)-10:])
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	if got := truncateDescription("curta", 10); got != "curta" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateDescription("ááááá", 4); got != "á..." {
+		t.Errorf("got %q", got)
+	}
+}
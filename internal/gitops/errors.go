@@ -0,0 +1,30 @@
+package gitops
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error é o erro estruturado das operações git (op + repositório + causa).
+type Error struct {
+	Op   string // ex: "clone", "fetch", "push ingestion-grupo1"
+	Path string // checkout local
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("git %s (%s): %v", e.Op, e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// DirtyError indica arquivos modificados no checkout que não pertencem à wave.
+type DirtyError struct {
+	Path  string
+	Files []string
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("checkout %s possui arquivos modificados que não foram gerados nesta execução:\n- %s",
+		e.Path, strings.Join(e.Files, "\n- "))
+}
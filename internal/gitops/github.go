@@ -0,0 +1,81 @@
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// githubProvider abre PRs via REST API do GitHub (github.com ou GHES).
+type githubProvider struct {
+	apiURL string
+	owner  string
+	repo   string
+	token  string
+	client *http.Client
+}
+
+// repository: "owner/repo"
+func newGitHubProvider(apiURL, repository, token string, client *http.Client) (*githubProvider, error) {
+	owner, repo, ok := strings.Cut(strings.Trim(repository, "/"), "/")
+	if !ok || owner == "" || repo == "" {
+		return nil, fmt.Errorf("GIT_PR_REPOSITORY inválido para github: %q (esperado owner/repo)", repository)
+	}
+	if strings.TrimSpace(apiURL) == "" {
+		apiURL = "https://api.github.com"
+	}
+
+	return &githubProvider{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		owner:  owner,
+		repo:   repo,
+		token:  token,
+		client: client,
+	}, nil
+}
+
+func (p *githubProvider) Name() string { return "github" }
+
+func (p *githubProvider) CreatePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	headers := map[string]string{
+		"Authorization":        "Bearer " + p.token,
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	repoURL := fmt.Sprintf("%s/repos/%s/%s", p.apiURL, p.owner, p.repo)
+
+	payload := map[string]any{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.SourceBranch,
+		"base":  pr.TargetBranch,
+	}
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := doJSON(ctx, p.client, http.MethodPost, repoURL+"/pulls", headers, payload, &created); err != nil {
+		if isAlreadyExists(err) {
+			return "", fmt.Errorf("%w: %s", ErrPullRequestExists, pr.SourceBranch)
+		}
+		return "", fmt.Errorf("criando PR no github: %w", err)
+	}
+
+	// Labels são aplicadas via API de issues (PR é uma issue no GitHub)
+	if len(pr.Labels) > 0 {
+		url := fmt.Sprintf("%s/issues/%d/labels", repoURL, created.Number)
+		if err := doJSON(ctx, p.client, http.MethodPost, url, headers, map[string]any{"labels": pr.Labels}, nil); err != nil {
+			return created.HTMLURL, fmt.Errorf("aplicando labels no PR #%d: %w", created.Number, err)
+		}
+	}
+
+	if len(pr.Reviewers) > 0 {
+		url := fmt.Sprintf("%s/pulls/%d/requested_reviewers", repoURL, created.Number)
+		if err := doJSON(ctx, p.client, http.MethodPost, url, headers, map[string]any{"reviewers": pr.Reviewers}, nil); err != nil {
+			return created.HTMLURL, fmt.Errorf("solicitando reviewers no PR #%d: %w", created.Number, err)
+		}
+	}
+
+	return created.HTMLURL, nil
+}
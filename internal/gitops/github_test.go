@@ -0,0 +1,104 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestGitHub(t *testing.T, responses map[string]fakeResponse) (*fakeAPI, *githubProvider) {
+	t.Helper()
+	api, srv := newFakeAPI(t, responses)
+	p, err := newGitHubProvider(srv.URL+"/", "acme/ingestion-manifests", "ghp_token", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api, p
+}
+
+func TestGitHubCreatePullRequest(t *testing.T) {
+	api, p := newTestGitHub(t, map[string]fakeResponse{
+		"POST /repos/acme/ingestion-manifests/pulls":                        {201, `{"number": 42, "html_url": "https://github.com/acme/ingestion-manifests/pull/42"}`},
+		"POST /repos/acme/ingestion-manifests/issues/42/labels":             {200, `[]`},
+		"POST /repos/acme/ingestion-manifests/pulls/42/requested_reviewers": {201, `{}`},
+	})
+
+	url, err := p.CreatePullRequest(context.Background(), PullRequest{
+		Title:        "Ingestion wave vendas (production)",
+		Body:         "corpo",
+		SourceBranch: "ingestion/vendas",
+		TargetBranch: "main",
+		Labels:       []string{"ingestion", "wave"},
+		Reviewers:    []string{"maria"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://github.com/acme/ingestion-manifests/pull/42" {
+		t.Errorf("url = %q", url)
+	}
+
+	calls := api.calls()
+	if len(calls) != 3 {
+		t.Fatalf("esperado 3 chamadas, veio %d: %+v", len(calls), calls)
+	}
+	for _, c := range calls {
+		if got := c.Header.Get("Authorization"); got != "Bearer ghp_token" {
+			t.Errorf("%s: Authorization = %q", c.Path, got)
+		}
+		if got := c.Header.Get("X-GitHub-Api-Version"); got != "2022-11-28" {
+			t.Errorf("%s: X-GitHub-Api-Version = %q", c.Path, got)
+		}
+		if got := c.Header.Get("Accept"); got != "application/vnd.github+json" {
+			t.Errorf("%s: Accept = %q", c.Path, got)
+		}
+	}
+	jsonEqual(t, "payload do PR", calls[0].Body, map[string]any{
+		"title": "Ingestion wave vendas (production)",
+		"body":  "corpo",
+		"head":  "ingestion/vendas",
+		"base":  "main",
+	})
+	jsonEqual(t, "labels", calls[1].Body, map[string]any{"labels": []string{"ingestion", "wave"}})
+	jsonEqual(t, "reviewers", calls[2].Body, map[string]any{"reviewers": []string{"maria"}})
+}
+
+func TestGitHubPullRequestExists(t *testing.T) {
+	_, p := newTestGitHub(t, map[string]fakeResponse{
+		"POST /repos/acme/ingestion-manifests/pulls": {422, `{"message":"Validation Failed","errors":[{"message":"A pull request already exists for acme:ingestion/vendas."}]}`},
+	})
+
+	_, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "ingestion/vendas", TargetBranch: "main"})
+	if !errors.Is(err, ErrPullRequestExists) {
+		t.Fatalf("esperado ErrPullRequestExists, veio %v", err)
+	}
+}
+
+func TestGitHubError(t *testing.T) {
+	_, p := newTestGitHub(t, map[string]fakeResponse{
+		"POST /repos/acme/ingestion-manifests/pulls": {401, `{"message":"Bad credentials"}`},
+	})
+
+	_, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "ingestion/vendas", TargetBranch: "main"})
+	var he *httpStatusError
+	if !errors.As(err, &he) || he.Status != http.StatusUnauthorized {
+		t.Fatalf("esperado status 401, veio %v", err)
+	}
+	if errors.Is(err, ErrPullRequestExists) || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("erro = %v", err)
+	}
+}
+
+func TestGitHubLabelErrorKeepsURL(t *testing.T) {
+	_, p := newTestGitHub(t, map[string]fakeResponse{
+		"POST /repos/acme/ingestion-manifests/pulls":           {201, `{"number": 7, "html_url": "https://github.com/acme/ingestion-manifests/pull/7"}`},
+		"POST /repos/acme/ingestion-manifests/issues/7/labels": {403, `{"message":"forbidden"}`},
+	})
+
+	url, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "b", TargetBranch: "main", Labels: []string{"x"}})
+	if err == nil || url != "https://github.com/acme/ingestion-manifests/pull/7" {
+		t.Fatalf("esperado erro com a URL do PR criado, veio %q, %v", url, err)
+	}
+}
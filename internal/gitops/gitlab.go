@@ -0,0 +1,99 @@
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// gitlabProvider abre merge requests via API v4 do GitLab.
+type gitlabProvider struct {
+	apiURL  string
+	project string
+	token   string
+	client  *http.Client
+}
+
+// repository: caminho do projeto ("grupo/projeto") ou ID numérico
+func newGitLabProvider(apiURL, repository, token string, client *http.Client) (*gitlabProvider, error) {
+	project := strings.Trim(repository, "/")
+	if project == "" {
+		return nil, fmt.Errorf("GIT_PR_REPOSITORY inválido para gitlab: %q", repository)
+	}
+	if strings.TrimSpace(apiURL) == "" {
+		apiURL = "https://gitlab.com/api/v4"
+	}
+
+	return &gitlabProvider{
+		apiURL:  strings.TrimRight(apiURL, "/"),
+		project: project,
+		token:   token,
+		client:  client,
+	}, nil
+}
+
+func (p *gitlabProvider) Name() string { return "gitlab" }
+
+func (p *gitlabProvider) CreatePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	headers := map[string]string{"PRIVATE-TOKEN": p.token}
+
+	reviewerIDs, err := p.resolveUserIDs(ctx, headers, pr.Reviewers)
+	if err != nil {
+		return "", err
+	}
+
+	payload := map[string]any{
+		"source_branch": pr.SourceBranch,
+		"target_branch": pr.TargetBranch,
+		"title":         pr.Title,
+		"description":   pr.Body,
+	}
+	if len(pr.Labels) > 0 {
+		payload["labels"] = strings.Join(pr.Labels, ",")
+	}
+	if len(reviewerIDs) > 0 {
+		payload["reviewer_ids"] = reviewerIDs
+	}
+
+	mrURL := fmt.Sprintf("%s/projects/%s/merge_requests", p.apiURL, url.PathEscape(p.project))
+	var created struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	if err := doJSON(ctx, p.client, http.MethodPost, mrURL, headers, payload, &created); err != nil {
+		if isAlreadyExists(err) {
+			return "", fmt.Errorf("%w: %s", ErrPullRequestExists, pr.SourceBranch)
+		}
+		return "", fmt.Errorf("criando merge request no gitlab: %w", err)
+	}
+
+	return created.WebURL, nil
+}
+
+// resolveUserIDs converte usernames em IDs (a API de MR só aceita IDs).
+// Valores numéricos são usados diretamente.
+func (p *gitlabProvider) resolveUserIDs(ctx context.Context, headers map[string]string, users []string) ([]int, error) {
+	ids := make([]int, 0, len(users))
+	for _, u := range users {
+		if id, err := strconv.Atoi(u); err == nil {
+			ids = append(ids, id)
+			continue
+		}
+
+		var found []struct {
+			ID int `json:"id"`
+		}
+		lookup := fmt.Sprintf("%s/users?username=%s", p.apiURL, url.QueryEscape(u))
+		if err := doJSON(ctx, p.client, http.MethodGet, lookup, headers, nil, &found); err != nil {
+			return nil, fmt.Errorf("buscando reviewer %q no gitlab: %w", u, err)
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("reviewer %q não encontrado no gitlab", u)
+		}
+		ids = append(ids, found[0].ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,101 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func newTestGitLab(t *testing.T, responses map[string]fakeResponse) (*fakeAPI, *gitlabProvider) {
+	t.Helper()
+	api, srv := newFakeAPI(t, responses)
+	p, err := newGitLabProvider(srv.URL+"/api/v4", "dados/ingestion-manifests", "glpat-token", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api, p
+}
+
+func TestGitLabCreateMergeRequest(t *testing.T) {
+	api, p := newTestGitLab(t, map[string]fakeResponse{
+		"GET /api/v4/users": {200, `[{"id": 311, "username": "maria"}]`},
+		"POST /api/v4/projects/dados%2Fingestion-manifests/merge_requests": {201, `{"iid": 5, "web_url": "https://gitlab.example.com/dados/ingestion-manifests/-/merge_requests/5"}`},
+	})
+
+	url, err := p.CreatePullRequest(context.Background(), PullRequest{
+		Title:        "Ingestion wave vendas",
+		Body:         "corpo",
+		SourceBranch: "ingestion/vendas",
+		TargetBranch: "main",
+		Labels:       []string{"ingestion", "wave"},
+		Reviewers:    []string{"maria", "17"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://gitlab.example.com/dados/ingestion-manifests/-/merge_requests/5" {
+		t.Errorf("url = %q", url)
+	}
+
+	calls := api.calls()
+	if len(calls) != 2 {
+		t.Fatalf("esperado 2 chamadas (lookup do reviewer + MR), veio %d: %+v", len(calls), calls)
+	}
+	for _, c := range calls {
+		if got := c.Header.Get("PRIVATE-TOKEN"); got != "glpat-token" {
+			t.Errorf("%s: PRIVATE-TOKEN = %q", c.Path, got)
+		}
+	}
+	if calls[0].Query != "username=maria" {
+		t.Errorf("lookup do reviewer: query = %q", calls[0].Query)
+	}
+	jsonEqual(t, "payload do MR", calls[1].Body, map[string]any{
+		"source_branch": "ingestion/vendas",
+		"target_branch": "main",
+		"title":         "Ingestion wave vendas",
+		"description":   "corpo",
+		"labels":        "ingestion,wave",
+		"reviewer_ids":  []int{311, 17},
+	})
+}
+
+func TestGitLabMergeRequestExists(t *testing.T) {
+	_, p := newTestGitLab(t, map[string]fakeResponse{
+		"POST /api/v4/projects/dados%2Fingestion-manifests/merge_requests": {409, `{"message":["Another open merge request already exists for this source branch: !4"]}`},
+	})
+
+	_, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "ingestion/vendas", TargetBranch: "main"})
+	if !errors.Is(err, ErrPullRequestExists) {
+		t.Fatalf("esperado ErrPullRequestExists, veio %v", err)
+	}
+}
+
+func TestGitLabError(t *testing.T) {
+	_, p := newTestGitLab(t, map[string]fakeResponse{
+		"POST /api/v4/projects/dados%2Fingestion-manifests/merge_requests": {500, `{"message":"500 Internal Server Error"}`},
+	})
+
+	_, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "ingestion/vendas", TargetBranch: "main"})
+	var he *httpStatusError
+	if !errors.As(err, &he) || he.Status != http.StatusInternalServerError {
+		t.Fatalf("esperado status 500, veio %v", err)
+	}
+	if errors.Is(err, ErrPullRequestExists) {
+		t.Errorf("500 não é PR existente: %v", err)
+	}
+}
+
+func TestGitLabUnknownReviewer(t *testing.T) {
+	api, p := newTestGitLab(t, map[string]fakeResponse{
+		"GET /api/v4/users": {200, `[]`},
+	})
+
+	_, err := p.CreatePullRequest(context.Background(), PullRequest{SourceBranch: "b", TargetBranch: "main", Reviewers: []string{"ninguem"}})
+	if err == nil {
+		t.Fatal("esperado erro para reviewer inexistente")
+	}
+	if n := len(api.calls()); n != 1 {
+		t.Errorf("o MR não deveria ser criado; %d chamadas", n)
+	}
+}
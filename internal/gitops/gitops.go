@@ -1,15 +1,24 @@
 package gitops
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
+	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
+const remoteName = "origin"
+
 // Config representa as configurações para operar o GitOps.
 type Config struct {
 	RepoURL      string
@@ -18,6 +27,25 @@ type Config struct {
 	LocalPath    string
 	UserName     string
 	UserEmail    string
+
+	// Autenticação (token via HTTPS ou chave SSH); ver auth.go
+	AuthUsername     string
+	AuthToken        string
+	SSHKeyPath       string
+	SSHKeyPassphrase string
+	SSHKnownHosts    string
+
+	// Retentativas de push rejeitado (branch remota/BaseBranch andaram durante a execução)
+	PushMaxAttempts int
+	PushBackoff     time.Duration
+
+	// Abertura de PR/MR após o push (opcional; desabilitado se PRProvider vazio)
+	PRProvider   string   // github | gitlab | azure
+	PRAPIURL     string   // URL base da API (default do provider se vazio)
+	PRToken      string   // token com permissão de abrir PR
+	PRRepository string   // github: owner/repo | gitlab: grupo/projeto | azure: org/projeto/repo
+	PRLabels     []string // labels aplicadas ao PR
+	PRReviewers  []string // reviewers (usernames no github/gitlab, IDs no azure)
 }
 
 // LoadConfigFromEnv lê as configurações de GitOps das variáveis de ambiente.
@@ -42,23 +70,84 @@ func LoadConfigFromEnv() (*Config, error) {
 	userName := os.Getenv("GIT_USER_NAME")
 	userEmail := os.Getenv("GIT_USER_EMAIL")
 
+	pushAttempts := 4
+	if v := strings.TrimSpace(os.Getenv("GIT_PUSH_MAX_ATTEMPTS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("GIT_PUSH_MAX_ATTEMPTS inválido: %q", v)
+		}
+		pushAttempts = n
+	}
+	pushBackoff := 2 * time.Second
+	if v := strings.TrimSpace(os.Getenv("GIT_PUSH_BACKOFF")); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("GIT_PUSH_BACKOFF inválido: %q (ex: 2s)", v)
+		}
+		pushBackoff = d
+	}
+
+	// Token do git: GIT_TOKEN; se vazio, reaproveita o token do PR
+	token := os.Getenv("GIT_TOKEN")
+	if strings.TrimSpace(token) == "" {
+		token = os.Getenv("GIT_PR_TOKEN")
+	}
+
 	return &Config{
-		RepoURL:      repo,
-		BaseBranch:   baseBranch,
-		BranchPrefix: prefix,
-		LocalPath:    localPath,
-		UserName:     userName,
-		UserEmail:    userEmail,
+		RepoURL:          repo,
+		BaseBranch:       baseBranch,
+		BranchPrefix:     prefix,
+		LocalPath:        localPath,
+		UserName:         userName,
+		UserEmail:        userEmail,
+		AuthUsername:     strings.TrimSpace(os.Getenv("GIT_AUTH_USERNAME")),
+		AuthToken:        token,
+		SSHKeyPath:       strings.TrimSpace(os.Getenv("GIT_SSH_KEY_PATH")),
+		SSHKeyPassphrase: os.Getenv("GIT_SSH_KEY_PASSPHRASE"),
+		SSHKnownHosts:    strings.TrimSpace(os.Getenv("GIT_SSH_KNOWN_HOSTS")),
+		PushMaxAttempts:  pushAttempts,
+		PushBackoff:      pushBackoff,
+		PRProvider:       strings.TrimSpace(os.Getenv("GIT_PR_PROVIDER")),
+		PRAPIURL:         strings.TrimSpace(os.Getenv("GIT_PR_API_URL")),
+		PRToken:          os.Getenv("GIT_PR_TOKEN"),
+		PRRepository:     strings.TrimSpace(os.Getenv("GIT_PR_REPOSITORY")),
+		PRLabels:         splitList(os.Getenv("GIT_PR_LABELS")),
+		PRReviewers:      splitList(os.Getenv("GIT_PR_REVIEWERS")),
 	}, nil
 }
 
+// Repo é o checkout local preparado por PrepareRepo.
+// Mantém um lock sobre o checkout até Close.
+type Repo struct {
+	Path   string
+	Branch string
+
+	cfg  *Config
+	repo *git.Repository
+	lock *repoLock
+
+	// leaseHash: hash da branch remota da wave quando o histórico local foi reescrito
+	// (rebase); o push vira force-with-lease contra esse hash.
+	leaseHash plumbing.Hash
+	// ownCommits: commits criados nesta execução (reaplicados em caso de rebase)
+	ownCommits []plumbing.Hash
+	// Conflicts: arquivos que conflitaram no rebase da branch remota e precisam ser
+	// regenerados; CommitAndPush falha se algum deles não estiver entre os caminhos gravados.
+	Conflicts []string
+}
+
 // PrepareRepo garante que o repositório local exista, esteja atualizado
 // e faz checkout de uma branch de trabalho baseada em BaseBranch.
 // branchSuffix é usado para compor o nome final da branch (prefix+suffix).
-// Retorna (localPathResolvido, branchName, erro).
-func PrepareRepo(cfg *Config, execDir, branchSuffix string) (string, string, error) {
+//
+// Se a branch da wave já existir no remote, ela é reaproveitada: se já contém a
+// BaseBranch, continua de onde está; senão, seus commits são reaplicados sobre a
+// BaseBranch mais recente (arquivos conflitantes ficam em Repo.Conflicts).
+//
+// O checkout fica travado (lock) até Close.
+func PrepareRepo(cfg *Config, execDir, branchSuffix string) (*Repo, error) {
 	if cfg == nil {
-		return "", "", fmt.Errorf("config GitOps é nil")
+		return nil, fmt.Errorf("config GitOps é nil")
 	}
 
 	// Resolve caminho local do repositório (absoluto)
@@ -69,34 +158,51 @@ func PrepareRepo(cfg *Config, execDir, branchSuffix string) (string, string, err
 		localPath = filepath.Join(execDir, localPath)
 	}
 
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return nil, fmt.Errorf("criando diretório pai de %s: %w", localPath, err)
+	}
+	lock, err := acquireLock(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rp, err := prepareLocked(cfg, localPath, branchSuffix)
+	if err != nil {
+		_ = lock.release()
+		return nil, err
+	}
+	rp.lock = lock
+
+	return rp, nil
+}
+
+func prepareLocked(cfg *Config, localPath, branchSuffix string) (*Repo, error) {
+	var r *git.Repository
+
 	// Se .git não existe, clona o repositório
 	gitDir := filepath.Join(localPath, ".git")
 	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-		if err := cloneRepo(cfg, localPath); err != nil {
-			return "", "", fmt.Errorf("falha ao clonar repositório: %w", err)
+		r, err = cloneRepo(cfg, localPath)
+		if err != nil {
+			return nil, err
 		}
 	} else if err == nil {
-		// Já é um repositório git -> atualiza
-		if err := runGit(localPath, "fetch", "--all"); err != nil {
-			return "", "", fmt.Errorf("git fetch: %w", err)
+		r, err = git.PlainOpen(localPath)
+		if err != nil {
+			return nil, &Error{Op: "open", Path: localPath, Err: err}
 		}
-		if err := runGit(localPath, "checkout", cfg.BaseBranch); err != nil {
-			return "", "", fmt.Errorf("git checkout %s: %w", cfg.BaseBranch, err)
+
+		// Checkout com sujeira de outra ferramenta/execução: não mistura com a wave
+		dirty, err := dirtyFiles(r, localPath)
+		if err != nil {
+			return nil, err
 		}
-		if err := runGit(localPath, "pull", "--ff-only"); err != nil {
-			return "", "", fmt.Errorf("git pull: %w", err)
+		if len(dirty) > 0 {
+			return nil, &DirtyError{Path: localPath, Files: dirty}
 		}
 	} else {
 		// Qualquer outro erro de Stat
-		return "", "", fmt.Errorf("erro verificando .git em %s: %w", localPath, err)
-	}
-
-	// Configura user.name / user.email se fornecidos
-	if strings.TrimSpace(cfg.UserName) != "" {
-		_ = runGit(localPath, "config", "user.name", cfg.UserName)
-	}
-	if strings.TrimSpace(cfg.UserEmail) != "" {
-		_ = runGit(localPath, "config", "user.email", cfg.UserEmail)
+		return nil, fmt.Errorf("erro verificando .git em %s: %w", localPath, err)
 	}
 
 	// Monta o nome da branch
@@ -106,86 +212,520 @@ func PrepareRepo(cfg *Config, execDir, branchSuffix string) (string, string, err
 	} else {
 		suffix = strings.ReplaceAll(suffix, " ", "-")
 	}
-	branchName := cfg.BranchPrefix + suffix
 
-	// Garante que estamos na baseBranch e cria/substitui a branch de trabalho
-	if err := runGit(localPath, "checkout", cfg.BaseBranch); err != nil {
-		return "", "", fmt.Errorf("git checkout %s: %w", cfg.BaseBranch, err)
-	}
-	if err := runGit(localPath, "checkout", "-B", branchName); err != nil {
-		return "", "", fmt.Errorf("git checkout -B %s: %w", branchName, err)
+	rp := &Repo{Path: localPath, Branch: cfg.BranchPrefix + suffix, cfg: cfg, repo: r}
+
+	conflicts, err := rp.rebuildBranch()
+	if err != nil {
+		return nil, err
 	}
+	rp.Conflicts = conflicts
+
+	return rp, nil
+}
 
-	return localPath, branchName, nil
+// Close libera o lock do checkout.
+func (rp *Repo) Close() error {
+	if rp == nil {
+		return nil
+	}
+	err := rp.lock.release()
+	rp.lock = nil
+	return err
 }
 
-// CommitAndPush adiciona todas as mudanças, faz commit (se houver) e dá push.
-func CommitAndPush(localPath, branchName, message string) error {
-	changed, err := hasPendingChanges(localPath)
+// rebuildBranch busca o remote e posiciona a branch da wave sobre o estado mais recente:
+//   - sem branch remota: parte da BaseBranch
+//   - branch remota já contém a BaseBranch: parte da branch remota
+//   - branch remota atrás da BaseBranch: reaplica seus commits sobre a BaseBranch (rebase)
+//
+// Em seguida reaplica os commits próprios desta execução (ownCommits).
+// Retorna os arquivos que conflitaram.
+func (rp *Repo) rebuildBranch() ([]string, error) {
+	if err := rp.fetch(); err != nil {
+		return nil, err
+	}
+	if err := checkoutBase(rp.repo, rp.Path, rp.cfg.BaseBranch); err != nil {
+		return nil, err
+	}
+
+	baseRef, err := rp.repo.Reference(plumbing.NewRemoteReferenceName(remoteName, rp.cfg.BaseBranch), true)
 	if err != nil {
-		return fmt.Errorf("checando mudanças no git: %w", err)
+		return nil, &Error{Op: "resolve " + remoteName + "/" + rp.cfg.BaseBranch, Path: rp.Path, Err: err}
+	}
+	base := baseRef.Hash()
+
+	// commits próprios precisam ser lidos antes de mover a branch
+	own := make([]*object.Commit, 0, len(rp.ownCommits))
+	for _, h := range rp.ownCommits {
+		c, err := rp.repo.CommitObject(h)
+		if err != nil {
+			return nil, &Error{Op: "rebase", Path: rp.Path, Err: err}
+		}
+		own = append(own, c)
 	}
-	if !changed {
-		fmt.Printf("GitOps: nenhum arquivo modificado em %s, nada para commitar.\n", localPath)
+	rp.ownCommits = nil
+
+	start := base
+	var replay []*object.Commit
+	rp.leaseHash = plumbing.ZeroHash
+
+	remoteRef, err := rp.repo.Reference(plumbing.NewRemoteReferenceName(remoteName, rp.Branch), true)
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		// branch nova
+	case err != nil:
+		return nil, &Error{Op: "resolve " + remoteName + "/" + rp.Branch, Path: rp.Path, Err: err}
+	default:
+		remote := remoteRef.Hash()
+		upToDate, err := isAncestor(rp.repo, base, remote)
+		if err != nil {
+			return nil, &Error{Op: "merge-base " + rp.Branch, Path: rp.Path, Err: err}
+		}
+		if upToDate {
+			log.Printf("GitOps: branch %s já existe no remote e contém %s; continuando a partir dela", rp.Branch, rp.cfg.BaseBranch)
+			start = remote
+		} else {
+			replay, err = commitsSince(rp.repo, remote, base)
+			if err != nil {
+				return nil, &Error{Op: "rebase " + rp.Branch, Path: rp.Path, Err: err}
+			}
+			log.Printf("GitOps: branch %s já existe no remote atrás de %s; reaplicando %d commit(s) sobre %s",
+				rp.Branch, rp.cfg.BaseBranch, len(replay), rp.cfg.BaseBranch)
+			rp.leaseHash = remote
+		}
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(rp.Branch)
+	if err := rp.repo.Storer.SetReference(plumbing.NewHashReference(branchRef, start)); err != nil {
+		return nil, &Error{Op: "branch " + rp.Branch, Path: rp.Path, Err: err}
+	}
+	w, err := rp.repo.Worktree()
+	if err != nil {
+		return nil, &Error{Op: "worktree", Path: rp.Path, Err: err}
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: branchRef, Force: true}); err != nil {
+		return nil, &Error{Op: "checkout " + rp.Branch, Path: rp.Path, Err: err}
+	}
+
+	conflicts, err := rp.replayCommits(replay)
+	if err != nil {
+		return nil, err
+	}
+	ownConflicts, err := rp.replayCommits(own)
+	if err != nil {
+		return nil, err
+	}
+
+	// commits próprios reaplicados continuam sendo "desta execução"
+	if len(own) > 0 {
+		if commits, err := rp.headCommitsSince(start); err == nil {
+			rp.ownCommits = commits
+		}
+	}
+
+	return append(conflicts, ownConflicts...), nil
+}
+
+// CommitAndPush faz stage apenas dos caminhos em paths (gravados pelo gerador),
+// commita (se houver mudança) e dá push.
+// Se o checkout tiver arquivos modificados fora de paths, aborta listando-os.
+//
+// Se o push for rejeitado (branch remota ou BaseBranch andaram), refaz o rebase
+// sobre o estado mais recente e tenta de novo com backoff exponencial. Quando o
+// rebase conflita em arquivos gerados, chama regenerate (que regrava os artefatos
+// e devolve os caminhos) antes de commitar novamente.
+//
+// Arquivos em Conflicts que não estejam em paths (ou nos caminhos devolvidos por
+// regenerate) ficariam com a versão da BaseBranch, perdendo o que a branch remota
+// tinha: nesse caso nada é commitado e o erro lista os arquivos.
+//
+// Retorna pushed=false quando não havia nada para commitar.
+func (rp *Repo) CommitAndPush(message string, paths []string, regenerate func() ([]string, error)) (bool, error) {
+	attempts := rp.cfg.PushMaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	backoff := rp.cfg.PushBackoff
+
+	for attempt := 1; ; attempt++ {
+		if err := rp.checkConflicts(paths); err != nil {
+			return false, err
+		}
+
+		committed, err := rp.commit(message, paths)
+		if err != nil {
+			return false, err
+		}
+		if !committed && len(rp.ownCommits) == 0 && rp.leaseHash.IsZero() {
+			log.Printf("GitOps: nenhum arquivo modificado em %s, nada para commitar.", rp.Path)
+			return false, nil
+		}
+
+		err = rp.push()
+		if err == nil {
+			rp.Conflicts = nil
+			return true, nil
+		}
+		if !isPushRejected(err) || attempt >= attempts {
+			return false, err
+		}
+
+		log.Printf("GitOps: push rejeitado (tentativa %d/%d): %v; refazendo rebase em %s", attempt, attempts, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+
+		conflicts, err := rp.rebuildBranch()
+		if err != nil {
+			return false, err
+		}
+		rp.Conflicts = conflicts
+		if len(conflicts) > 0 {
+			if regenerate == nil {
+				return false, &Error{Op: "rebase " + rp.Branch, Path: rp.Path,
+					Err: fmt.Errorf("conflito em arquivos gerados: %s", strings.Join(conflicts, ", "))}
+			}
+			if paths, err = regenerate(); err != nil {
+				return false, fmt.Errorf("regenerando artefatos após conflito de rebase: %w", err)
+			}
+		} else {
+			paths = nil // tudo já foi reaplicado
+		}
+	}
+}
+
+// checkConflicts confere se cada arquivo em Conflicts foi regravado (está em paths).
+func (rp *Repo) checkConflicts(paths []string) error {
+	if len(rp.Conflicts) == 0 {
 		return nil
 	}
+	relPaths, err := repoRelativePaths(rp.Path, paths)
+	if err != nil {
+		return err
+	}
+	written := map[string]struct{}{}
+	for _, p := range relPaths {
+		written[p] = struct{}{}
+	}
+
+	var missing []string
+	for _, f := range rp.Conflicts {
+		if _, ok := written[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return &Error{Op: "rebase " + rp.Branch, Path: rp.Path,
+			Err: fmt.Errorf("conflito em arquivo(s) que esta execução não regenerou (rode a wave que os gera ou resolva à mão): %s", strings.Join(missing, ", "))}
+	}
+	return nil
+}
+
+// commit faz stage dos caminhos gerados e commita. Retorna false se não havia mudança.
+func (rp *Repo) commit(message string, paths []string) (bool, error) {
+	relPaths, err := repoRelativePaths(rp.Path, paths)
+	if err != nil {
+		return false, err
+	}
+
+	dirty, err := dirtyFiles(rp.repo, rp.Path)
+	if err != nil {
+		return false, err
+	}
 
-	if err := runGit(localPath, "add", "."); err != nil {
-		return fmt.Errorf("git add: %w", err)
+	expected := map[string]struct{}{}
+	for _, p := range relPaths {
+		expected[p] = struct{}{}
 	}
 
-	if err := runGit(localPath, "commit", "-m", message); err != nil {
-		return fmt.Errorf("git commit: %w", err)
+	var unexpected, toStage []string
+	for _, f := range dirty {
+		if _, ok := expected[f]; ok {
+			toStage = append(toStage, f)
+			continue
+		}
+		unexpected = append(unexpected, f)
+	}
+	if len(unexpected) > 0 {
+		return false, &DirtyError{Path: rp.Path, Files: unexpected}
 	}
 
-	if err := runGit(localPath, "push", "-u", "origin", branchName); err != nil {
-		return fmt.Errorf("git push: %w", err)
+	if len(toStage) == 0 {
+		return false, nil
 	}
 
+	w, err := rp.repo.Worktree()
+	if err != nil {
+		return false, &Error{Op: "worktree", Path: rp.Path, Err: err}
+	}
+
+	// Arquivo existente -> add; arquivo removido -> rm
+	for _, f := range toStage {
+		if _, statErr := os.Stat(filepath.Join(rp.Path, filepath.FromSlash(f))); statErr == nil {
+			_, err = w.Add(f)
+		} else {
+			_, err = w.Remove(f)
+		}
+		if err != nil {
+			return false, &Error{Op: "add " + f, Path: rp.Path, Err: err}
+		}
+	}
+
+	h, err := w.Commit(message, &git.CommitOptions{Author: rp.signature()})
+	if err != nil {
+		return false, &Error{Op: "commit", Path: rp.Path, Err: err}
+	}
+	rp.ownCommits = append(rp.ownCommits, h)
+
+	return true, nil
+}
+
+// push envia a branch de trabalho para o remote e configura o upstream (push -u).
+// Depois de um rebase da branch remota, usa force-with-lease contra o hash visto no fetch.
+func (rp *Repo) push() error {
+	auth, err := authMethod(rp.cfg)
+	if err != nil {
+		return err
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(rp.Branch)
+	opts := &git.PushOptions{
+		RemoteName: remoteName,
+		Auth:       auth,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("%s:%s", branchRef, branchRef))},
+	}
+	if !rp.leaseHash.IsZero() {
+		opts.RefSpecs = []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+%s:%s", branchRef, branchRef))}
+		opts.ForceWithLease = &git.ForceWithLease{RefName: branchRef, Hash: rp.leaseHash}
+	}
+
+	err = rp.repo.Push(opts)
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return &Error{Op: "push " + rp.Branch, Path: rp.Path, Err: err}
+	}
+	rp.leaseHash = plumbing.ZeroHash
+	rp.ownCommits = nil
+
+	cfg, err := rp.repo.Config()
+	if err != nil {
+		return &Error{Op: "config", Path: rp.Path, Err: err}
+	}
+	cfg.Branches[rp.Branch] = &gitconfig.Branch{Name: rp.Branch, Remote: remoteName, Merge: branchRef}
+	if err := rp.repo.SetConfig(cfg); err != nil {
+		return &Error{Op: "config", Path: rp.Path, Err: err}
+	}
+
+	return nil
+}
+
+func (rp *Repo) fetch() error {
+	auth, err := authMethod(rp.cfg)
+	if err != nil {
+		return err
+	}
+
+	err = rp.repo.Fetch(&git.FetchOptions{
+		RemoteName: remoteName,
+		Auth:       auth,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", remoteName))},
+		Prune:      true,
+		Force:      true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return &Error{Op: "fetch", Path: rp.Path, Err: err}
+	}
 	return nil
 }
 
-// cloneRepo faz o git clone --branch BaseBranch RepoURL localPath.
-func cloneRepo(cfg *Config, localPath string) error {
+// headCommitsSince lista os hashes dos commits do HEAD que não estão em stop.
+func (rp *Repo) headCommitsSince(stop plumbing.Hash) ([]plumbing.Hash, error) {
+	head, err := rp.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := commitsSince(rp.repo, head.Hash(), stop)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]plumbing.Hash, 0, len(commits))
+	for _, c := range commits {
+		out = append(out, c.Hash)
+	}
+	return out, nil
+}
+
+// isPushRejected identifica rejeição por branch remota à frente (non-fast-forward / lease).
+// O go-git devolve "non-fast-forward update: <ref>" tanto para o fast-forward quanto para
+// o lease; "fetch first" é o status do receive-pack de servidores git. Outras recusas
+// (hook, permissão, branch protegida) não se resolvem com rebase e não são retentadas.
+func isPushRejected(err error) bool {
+	if errors.Is(err, git.ErrNonFastForwardUpdate) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"non-fast-forward", "fetch first"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// signature usa GIT_USER_NAME/GIT_USER_EMAIL; se vazios, cai no user.* do repo/global.
+func (rp *Repo) signature() *object.Signature {
+	name := strings.TrimSpace(rp.cfg.UserName)
+	email := strings.TrimSpace(rp.cfg.UserEmail)
+
+	if name == "" || email == "" {
+		if c, err := rp.repo.ConfigScoped(gitconfig.GlobalScope); err == nil {
+			if name == "" {
+				name = c.User.Name
+			}
+			if email == "" {
+				email = c.User.Email
+			}
+		}
+	}
+	if name == "" {
+		name = "ih-ingestion"
+	}
+	if email == "" {
+		email = "ih-ingestion@localhost"
+	}
+
+	return &object.Signature{Name: name, Email: email, When: time.Now()}
+}
+
+// cloneRepo clona RepoURL (branch BaseBranch) em localPath.
+func cloneRepo(cfg *Config, localPath string) (*git.Repository, error) {
 	parent := filepath.Dir(localPath)
 	if err := os.MkdirAll(parent, 0o755); err != nil {
-		return fmt.Errorf("criando diretório pai %s: %w", parent, err)
+		return nil, fmt.Errorf("criando diretório pai %s: %w", parent, err)
 	}
 
 	// Se diretório existir e não estiver vazio, melhor falhar do que sobrescrever algo inesperado
 	if fi, err := os.Stat(localPath); err == nil && fi.IsDir() {
 		if entries, err := os.ReadDir(localPath); err == nil && len(entries) > 0 {
-			return fmt.Errorf("diretório %s já existe e não está vazio (não é seguro clonar aqui)", localPath)
+			return nil, fmt.Errorf("diretório %s já existe e não está vazio (não é seguro clonar aqui)", localPath)
 		}
 	}
 
-	cmd := exec.Command("git", "clone", "--branch", cfg.BaseBranch, cfg.RepoURL, localPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
+	auth, err := authMethod(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := git.PlainClone(localPath, false, &git.CloneOptions{
+		URL:           cfg.RepoURL,
+		Auth:          auth,
+		RemoteName:    remoteName,
+		ReferenceName: plumbing.NewBranchReferenceName(cfg.BaseBranch),
+	})
+	if err != nil {
+		return nil, &Error{Op: "clone " + cfg.RepoURL, Path: localPath, Err: err}
+	}
 
-// runGit executa um comando git no diretório localPath.
-func runGit(localPath string, args ...string) error {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = localPath
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return r, nil
 }
 
-// hasPendingChanges verifica se há mudanças não commitadas no repositório.
-func hasPendingChanges(localPath string) (bool, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
-	cmd.Dir = localPath
+// checkoutBase posiciona o working tree na baseBranch, alinhada (fast-forward) ao remote.
+func checkoutBase(r *git.Repository, localPath, baseBranch string) error {
+	baseRef := plumbing.NewBranchReferenceName(baseBranch)
+
+	remoteRef, err := r.Reference(plumbing.NewRemoteReferenceName(remoteName, baseBranch), true)
+	if err != nil {
+		return &Error{Op: "resolve " + remoteName + "/" + baseBranch, Path: localPath, Err: err}
+	}
+
+	localRef, err := r.Reference(baseRef, true)
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		// base ainda não existe localmente: cria a partir do remote
+	case err != nil:
+		return &Error{Op: "resolve " + baseBranch, Path: localPath, Err: err}
+	default:
+		ok, err := isAncestor(r, localRef.Hash(), remoteRef.Hash())
+		if err != nil {
+			return &Error{Op: "merge-base " + baseBranch, Path: localPath, Err: err}
+		}
+		if !ok {
+			return &Error{Op: "pull --ff-only " + baseBranch, Path: localPath,
+				Err: fmt.Errorf("branch local divergiu de %s/%s", remoteName, baseBranch)}
+		}
+	}
+
+	if err := r.Storer.SetReference(plumbing.NewHashReference(baseRef, remoteRef.Hash())); err != nil {
+		return &Error{Op: "update-ref " + baseBranch, Path: localPath, Err: err}
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return &Error{Op: "worktree", Path: localPath, Err: err}
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: baseRef, Force: true}); err != nil {
+		return &Error{Op: "checkout " + baseBranch, Path: localPath, Err: err}
+	}
+
+	return nil
+}
 
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = os.Stderr
+// isAncestor indica se ancestor é alcançável a partir de commit (ou igual).
+func isAncestor(r *git.Repository, ancestor, commit plumbing.Hash) (bool, error) {
+	if ancestor == commit {
+		return true, nil
+	}
 
-	if err := cmd.Run(); err != nil {
+	a, err := r.CommitObject(ancestor)
+	if err != nil {
+		return false, err
+	}
+	c, err := r.CommitObject(commit)
+	if err != nil {
 		return false, err
 	}
+	return a.IsAncestor(c)
+}
+
+// dirtyFiles lista (relativos à raiz do repo, ordenados) os arquivos modificados,
+// removidos ou não rastreados no working tree.
+func dirtyFiles(r *git.Repository, localPath string) ([]string, error) {
+	w, err := r.Worktree()
+	if err != nil {
+		return nil, &Error{Op: "worktree", Path: localPath, Err: err}
+	}
+
+	st, err := w.Status()
+	if err != nil {
+		return nil, &Error{Op: "status", Path: localPath, Err: err}
+	}
+
+	var files []string
+	for path, s := range st {
+		if s.Staging == git.Unmodified && s.Worktree == git.Unmodified {
+			continue
+		}
+		files = append(files, path)
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// repoRelativePaths converte caminhos absolutos em caminhos relativos ao repo (com "/").
+// Caminhos fora do repo são erro: não há como commitá-los.
+func repoRelativePaths(localPath string, paths []string) ([]string, error) {
+	root, err := filepath.Abs(localPath)
+	if err != nil {
+		return nil, err
+	}
 
-	return strings.TrimSpace(out.String()) != "", nil
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("caminho gerado %s está fora do repositório %s", p, root)
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	return out, nil
 }
@@ -0,0 +1,284 @@
+package gitops
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newBareRepo cria um repositório bare (o "remote") com um commit inicial em main.
+func newBareRepo(t *testing.T) string {
+	t.Helper()
+	tmp := t.TempDir()
+	bare := filepath.Join(tmp, "remote.git")
+	main := plumbing.NewBranchReferenceName("main")
+
+	if _, err := git.PlainInitWithOptions(bare, &git.PlainInitOptions{Bare: true, InitOptions: git.InitOptions{DefaultBranch: main}}); err != nil {
+		t.Fatal(err)
+	}
+
+	seedPath := filepath.Join(tmp, "seed")
+	seed, err := git.PlainInitWithOptions(seedPath, &git.PlainInitOptions{InitOptions: git.InitOptions{DefaultBranch: main}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(seedPath, "README.md"), "manifests\n")
+	commitAll(t, seed, "initial")
+	if _, err := seed.CreateRemote(&gitconfig.RemoteConfig{Name: remoteName, URLs: []string{bare}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.Push(&git.PushOptions{RemoteName: remoteName, RefSpecs: []gitconfig.RefSpec{"refs/heads/main:refs/heads/main"}}); err != nil {
+		t.Fatal(err)
+	}
+	return bare
+}
+
+func testConfig(t *testing.T, repoURL string) *Config {
+	t.Helper()
+	return &Config{
+		RepoURL:         repoURL,
+		BaseBranch:      "main",
+		BranchPrefix:    "ingestion-",
+		LocalPath:       filepath.Join(t.TempDir(), "checkout"),
+		UserName:        "ih-ingestion",
+		UserEmail:       "ih-ingestion@example.com",
+		PushMaxAttempts: 1,
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commitAll(t *testing.T, r *git.Repository, msg string) plumbing.Hash {
+	t.Helper()
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+	h, err := w.Commit(msg, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// remoteFile lê path na ponta de branch do repositório bare.
+func remoteFile(t *testing.T, bare, branch, path string) (string, *object.Commit) {
+	t.Helper()
+	r, err := git.PlainOpen(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := r.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		t.Fatalf("branch %s no remote: %v", branch, err)
+	}
+	c, err := r.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.File(path)
+	if err != nil {
+		t.Fatalf("%s em %s: %v", path, branch, err)
+	}
+	content, err := f.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content, c
+}
+
+func TestCloneCommitPush(t *testing.T) {
+	bare := newBareRepo(t)
+	cfg := testConfig(t, bare)
+
+	rp, err := PrepareRepo(cfg, t.TempDir(), "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if rp.Branch != "ingestion-vendas" {
+		t.Errorf("branch = %q", rp.Branch)
+	}
+	if _, err := os.Stat(filepath.Join(rp.Path, "README.md")); err != nil {
+		t.Fatalf("clone sem o conteúdo de main: %v", err)
+	}
+
+	file := filepath.Join(rp.Path, "sources", "vendas", "source.yaml")
+	writeFile(t, file, "kind: KafkaConnector\n")
+	pushed, err := rp.CommitAndPush("ingestion: vendas", []string{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed {
+		t.Fatal("esperado push")
+	}
+
+	content, c := remoteFile(t, bare, "ingestion-vendas", "sources/vendas/source.yaml")
+	if content != "kind: KafkaConnector\n" {
+		t.Errorf("conteúdo no remote = %q", content)
+	}
+	if c.Message != "ingestion: vendas" || c.Author.Email != "ih-ingestion@example.com" {
+		t.Errorf("commit = %q por %s", c.Message, c.Author.Email)
+	}
+
+	// upstream configurado (push -u)
+	rcfg, err := rp.repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := rcfg.Branches["ingestion-vendas"]; b == nil || b.Remote != remoteName {
+		t.Errorf("upstream não configurado: %+v", b)
+	}
+}
+
+func TestPrepareRepoReusesRemoteBranch(t *testing.T) {
+	bare := newBareRepo(t)
+	cfg := testConfig(t, bare)
+
+	rp, err := PrepareRepo(cfg, "", "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(rp.Path, "a.yaml")
+	writeFile(t, file, "a: 1\n")
+	if _, err := rp.CommitAndPush("primeira", []string{file}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// segunda execução no mesmo checkout: continua da branch remota, nada a commitar
+	rp, err = PrepareRepo(cfg, "", "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("branch remota não foi reaproveitada: %v", err)
+	}
+	pushed, err := rp.CommitAndPush("segunda", []string{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pushed {
+		t.Error("nada mudou; não deveria haver push")
+	}
+}
+
+func TestPrepareRepoLock(t *testing.T) {
+	cfg := testConfig(t, newBareRepo(t))
+
+	rp, err := PrepareRepo(cfg, "", "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PrepareRepo(cfg, "", "vendas"); err == nil || !strings.Contains(err.Error(), "em uso") {
+		t.Fatalf("esperado erro de lock, veio %v", err)
+	}
+	if err := rp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cfg.LocalPath + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock não removido: %v", err)
+	}
+
+	rp, err = PrepareRepo(cfg, "", "vendas")
+	if err != nil {
+		t.Fatalf("lock liberado, PrepareRepo deveria funcionar: %v", err)
+	}
+	rp.Close()
+}
+
+func TestDirtyCheckout(t *testing.T) {
+	cfg := testConfig(t, newBareRepo(t))
+
+	rp, err := PrepareRepo(cfg, "", "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := filepath.Join(rp.Path, "gerado.yaml")
+	writeFile(t, generated, "a: 1\n")
+	writeFile(t, filepath.Join(rp.Path, "manual.txt"), "editado à mão\n")
+
+	_, err = rp.CommitAndPush("wave", []string{generated}, nil)
+	var dirty *DirtyError
+	if !errors.As(err, &dirty) {
+		t.Fatalf("esperado DirtyError, veio %v", err)
+	}
+	if len(dirty.Files) != 1 || dirty.Files[0] != "manual.txt" {
+		t.Errorf("arquivos = %v", dirty.Files)
+	}
+	rp.Close()
+
+	// checkout sujo também barra a próxima execução
+	_, err = PrepareRepo(cfg, "", "vendas")
+	if !errors.As(err, &dirty) {
+		t.Fatalf("esperado DirtyError no PrepareRepo, veio %v", err)
+	}
+}
+
+func TestCommitPathOutsideRepo(t *testing.T) {
+	cfg := testConfig(t, newBareRepo(t))
+
+	rp, err := PrepareRepo(cfg, "", "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	outside := filepath.Join(t.TempDir(), "fora.yaml")
+	if _, err := rp.CommitAndPush("wave", []string{outside}, nil); err == nil || !strings.Contains(err.Error(), "fora do repositório") {
+		t.Fatalf("esperado erro de caminho fora do repo, veio %v", err)
+	}
+}
+
+func TestCloneError(t *testing.T) {
+	cfg := testConfig(t, filepath.Join(t.TempDir(), "nao-existe.git"))
+
+	_, err := PrepareRepo(cfg, "", "vendas")
+	var gerr *Error
+	if !errors.As(err, &gerr) {
+		t.Fatalf("esperado *gitops.Error, veio %T: %v", err, err)
+	}
+	if !strings.HasPrefix(gerr.Op, "clone ") || gerr.Path != cfg.LocalPath {
+		t.Errorf("Op=%q Path=%q", gerr.Op, gerr.Path)
+	}
+	if !strings.HasPrefix(err.Error(), "git clone ") {
+		t.Errorf("mensagem = %q", err.Error())
+	}
+	if _, err := os.Stat(cfg.LocalPath + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock não liberado após erro: %v", err)
+	}
+}
+
+func TestMissingBaseBranch(t *testing.T) {
+	cfg := testConfig(t, newBareRepo(t))
+	cfg.BaseBranch = "develop"
+
+	_, err := PrepareRepo(cfg, "", "vendas")
+	var gerr *Error
+	if !errors.As(err, &gerr) {
+		t.Fatalf("esperado *gitops.Error, veio %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// repoLock impede que duas execuções usem o mesmo GIT_LOCAL_PATH ao mesmo tempo.
+// O lock é o arquivo <localPath>.lock (fora do checkout, para não sujar o working tree).
+type repoLock struct {
+	path string
+}
+
+func acquireLock(localPath string) (*repoLock, error) {
+	lockPath := strings.TrimRight(localPath, string(os.PathSeparator)) + ".lock"
+
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			owner, _ := os.ReadFile(lockPath)
+			return nil, fmt.Errorf("checkout %s em uso por outra execução (lock %s: %s); se nenhuma execução estiver ativa, remova o arquivo de lock",
+				localPath, lockPath, strings.TrimSpace(string(owner)))
+		}
+		return nil, fmt.Errorf("criando lock %s: %w", lockPath, err)
+	}
+	defer f.Close()
+
+	host, _ := os.Hostname()
+	fmt.Fprintf(f, "pid=%d host=%s since=%s\n", os.Getpid(), host, time.Now().Format(time.RFC3339))
+
+	return &repoLock{path: lockPath}, nil
+}
+
+func (l *repoLock) release() error {
+	if l == nil {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removendo lock %s: %w", l.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrPullRequestExists indica que já existe PR/MR aberto para a branch da wave
+// (ex.: reexecução da mesma wave sobre uma branch já publicada).
+var ErrPullRequestExists = errors.New("já existe PR aberto para a branch")
+
+// PullRequest descreve o PR/MR que será aberto a partir da branch da wave.
+type PullRequest struct {
+	Title        string
+	Body         string
+	SourceBranch string
+	TargetBranch string
+	Labels       []string
+	Reviewers    []string
+}
+
+// PullRequestProvider abre um PR/MR no provedor Git e retorna a URL criada.
+type PullRequestProvider interface {
+	Name() string
+	CreatePullRequest(ctx context.Context, pr PullRequest) (string, error)
+}
+
+// WaveSummary resume o que foi gerado em uma wave; usado no corpo do PR.
+type WaveSummary struct {
+	Group   string
+	Env     string
+	From    string // preenchido em promoções: ambiente de origem
+	Aliases []string
+	Tables  []string
+	Sources []string
+	Topics  []string
+}
+
+// NewPullRequestProvider cria o provider conforme GIT_PR_PROVIDER.
+// Se o provider não estiver configurado, retorna (nil, nil) e o PR fica desabilitado.
+func NewPullRequestProvider(cfg *Config) (PullRequestProvider, error) {
+	if cfg == nil || strings.TrimSpace(cfg.PRProvider) == "" {
+		return nil, nil
+	}
+
+	if strings.TrimSpace(cfg.PRToken) == "" {
+		return nil, fmt.Errorf("GIT_PR_TOKEN não configurado (provider=%s)", cfg.PRProvider)
+	}
+	if strings.TrimSpace(cfg.PRRepository) == "" {
+		return nil, fmt.Errorf("GIT_PR_REPOSITORY não configurado (provider=%s)", cfg.PRProvider)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.PRProvider)) {
+	case "github":
+		return newGitHubProvider(cfg.PRAPIURL, cfg.PRRepository, cfg.PRToken, client)
+	case "gitlab":
+		return newGitLabProvider(cfg.PRAPIURL, cfg.PRRepository, cfg.PRToken, client)
+	case "azure", "azuredevops", "azure-devops":
+		return newAzureDevOpsProvider(cfg.PRAPIURL, cfg.PRRepository, cfg.PRToken, client)
+	default:
+		return nil, fmt.Errorf("GIT_PR_PROVIDER inválido: %q (use github, gitlab ou azure)", cfg.PRProvider)
+	}
+}
+
+// BuildPullRequest monta título e corpo do PR a partir do resumo da wave.
+func BuildPullRequest(cfg *Config, branchName string, summary WaveSummary) PullRequest {
+	title := fmt.Sprintf("Ingestion wave %s", summary.Group)
+	if summary.Env != "" {
+		title = fmt.Sprintf("%s (%s)", title, summary.Env)
+	}
+	if summary.From != "" {
+		title = fmt.Sprintf("Promote wave %s: %s → %s", summary.Group, summary.From, summary.Env)
+	}
+
+	var b strings.Builder
+	if summary.From != "" {
+		fmt.Fprintf(&b, "Promoção automática pelo ih-ingestion da wave `%s` de `%s` para `%s`", summary.Group, summary.From, summary.Env)
+	} else {
+		fmt.Fprintf(&b, "Artefatos gerados automaticamente pelo ih-ingestion para a wave `%s`", summary.Group)
+		if summary.Env != "" {
+			fmt.Fprintf(&b, " no ambiente `%s`", summary.Env)
+		}
+	}
+	b.WriteString(".\n")
+
+	writeSection(&b, "Aliases", summary.Aliases)
+	writeSection(&b, "Tabelas", summary.Tables)
+	writeSection(&b, "Sources", summary.Sources)
+	writeSection(&b, "Tópicos", summary.Topics)
+
+	return PullRequest{
+		Title:        title,
+		Body:         b.String(),
+		SourceBranch: branchName,
+		TargetBranch: cfg.BaseBranch,
+		Labels:       cfg.PRLabels,
+		Reviewers:    cfg.PRReviewers,
+	}
+}
+
+func writeSection(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+
+	sorted := append([]string(nil), items...)
+	sort.Strings(sorted)
+
+	fmt.Fprintf(b, "\n### %s (%d)\n\n", title, len(sorted))
+	for _, it := range sorted {
+		fmt.Fprintf(b, "- `%s`\n", it)
+	}
+}
+
+// doJSON envia payload como JSON e decodifica a resposta em out (se != nil).
+// Qualquer status fora de 2xx vira erro com o corpo da resposta.
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, payload, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("serializando payload: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("lendo resposta de %s %s: %w", method, url, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &httpStatusError{Method: method, URL: url, Status: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("decodificando resposta de %s %s: %w", method, url, err)
+		}
+	}
+
+	return nil
+}
+
+// httpStatusError é a resposta não-2xx de uma API de provider.
+type httpStatusError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+// isAlreadyExists reconhece a resposta de "PR já existe" (GitHub 422, GitLab/Azure 409).
+func isAlreadyExists(err error) bool {
+	var he *httpStatusError
+	if !errors.As(err, &he) {
+		return false
+	}
+	if he.Status != http.StatusConflict && he.Status != http.StatusUnprocessableEntity {
+		return false
+	}
+	return strings.Contains(strings.ToLower(he.Body), "already exists")
+}
+
+// splitList quebra listas separadas por vírgula vindas de env (labels, reviewers).
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
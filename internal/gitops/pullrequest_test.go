@@ -0,0 +1,99 @@
+package gitops
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// request é uma chamada recebida pelo servidor fake de um provider.
+type request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   map[string]any
+}
+
+// fakeAPI responde cada "MÉTODO /caminho" com o status e o corpo configurados
+// e guarda as requisições recebidas, na ordem.
+type fakeAPI struct {
+	t         *testing.T
+	responses map[string]fakeResponse
+
+	mu       sync.Mutex
+	requests []request
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+func newFakeAPI(t *testing.T, responses map[string]fakeResponse) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+	f := &fakeAPI{t: t, responses: responses}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := request{Method: r.Method, Path: r.URL.EscapedPath(), Query: r.URL.RawQuery, Header: r.Header.Clone()}
+	data, _ := io.ReadAll(r.Body)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &rec.Body); err != nil {
+			f.t.Errorf("%s %s: corpo não é JSON: %v", r.Method, r.URL, err)
+		}
+	}
+	f.mu.Lock()
+	f.requests = append(f.requests, rec)
+	f.mu.Unlock()
+
+	resp, ok := f.responses[r.Method+" "+rec.Path]
+	if !ok {
+		f.t.Errorf("requisição inesperada: %s %s", r.Method, rec.Path)
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.status)
+	_, _ = io.WriteString(w, resp.body)
+}
+
+func (f *fakeAPI) calls() []request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]request(nil), f.requests...)
+}
+
+// jsonEqual compara um valor decodificado de JSON com o esperado (via round-trip).
+func jsonEqual(t *testing.T, field string, got, want any) {
+	t.Helper()
+	w, _ := json.Marshal(want)
+	g, _ := json.Marshal(got)
+	if string(w) != string(g) {
+		t.Errorf("%s = %s, esperado %s", field, g, w)
+	}
+}
+
+func TestIsAlreadyExists(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&httpStatusError{Status: 422, Body: `{"message":"A pull request already exists for o:b."}`}, true},
+		{&httpStatusError{Status: 409, Body: `["Another open merge request already exists for this source branch"]`}, true},
+		{&httpStatusError{Status: 422, Body: `{"message":"Validation Failed"}`}, false},
+		{&httpStatusError{Status: 500, Body: "already exists"}, false},
+		{io.EOF, false},
+	}
+	for _, tt := range tests {
+		if got := isAlreadyExists(tt.err); got != tt.want {
+			t.Errorf("isAlreadyExists(%v) = %v, esperado %v", tt.err, got, tt.want)
+		}
+	}
+}
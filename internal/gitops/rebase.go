@@ -0,0 +1,186 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// maxReplayCommits limita a caminhada de histórico ao buscar commits próprios da wave.
+const maxReplayCommits = 200
+
+// commitsSince retorna (do mais antigo para o mais novo) os commits de tip que
+// não são alcançáveis a partir de stop, seguindo o first-parent. Merges são ignorados.
+func commitsSince(r *git.Repository, tip, stop plumbing.Hash) ([]*object.Commit, error) {
+	var out []*object.Commit
+
+	h := tip
+	for i := 0; i < maxReplayCommits; i++ {
+		reachable, err := isAncestor(r, h, stop)
+		if err != nil {
+			return nil, err
+		}
+		if reachable {
+			break
+		}
+
+		c, err := r.CommitObject(h)
+		if err != nil {
+			return nil, err
+		}
+		if c.NumParents() <= 1 {
+			out = append(out, c)
+		}
+		if c.NumParents() == 0 {
+			break
+		}
+		h = c.ParentHashes[0]
+	}
+
+	// inverte: mais antigo primeiro
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// replayCommits reaplica commits sobre o HEAD atual do working tree (rebase por arquivo).
+// Um arquivo conflita quando o HEAD tem conteúdo diferente do pai do commit original;
+// nesse caso ele não é aplicado e volta na lista de conflitos (para ser regenerado).
+func (rp *Repo) replayCommits(commits []*object.Commit) ([]string, error) {
+	w, err := rp.repo.Worktree()
+	if err != nil {
+		return nil, &Error{Op: "worktree", Path: rp.Path, Err: err}
+	}
+
+	var conflicts []string
+	for _, c := range commits {
+		head, err := rp.repo.Head()
+		if err != nil {
+			return nil, &Error{Op: "rev-parse HEAD", Path: rp.Path, Err: err}
+		}
+		headTree, err := treeOf(rp.repo, head.Hash())
+		if err != nil {
+			return nil, &Error{Op: "rebase", Path: rp.Path, Err: err}
+		}
+		tree, err := c.Tree()
+		if err != nil {
+			return nil, &Error{Op: "rebase", Path: rp.Path, Err: err}
+		}
+		parentTree := &object.Tree{}
+		if c.NumParents() > 0 {
+			if parentTree, err = treeOf(rp.repo, c.ParentHashes[0]); err != nil {
+				return nil, &Error{Op: "rebase", Path: rp.Path, Err: err}
+			}
+		}
+
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, &Error{Op: "rebase", Path: rp.Path, Err: err}
+		}
+
+		applied := 0
+		for _, ch := range changes {
+			path := ch.To.Name
+			if path == "" {
+				path = ch.From.Name
+			}
+
+			before := fileHash(parentTree, path)
+			after := fileHash(tree, path)
+			current := fileHash(headTree, path)
+
+			if current == after {
+				continue // já aplicado
+			}
+			if current != before {
+				conflicts = append(conflicts, path)
+				continue
+			}
+
+			if err := rp.applyFile(w, tree, path, after.IsZero()); err != nil {
+				return nil, err
+			}
+			applied++
+		}
+
+		if applied == 0 {
+			continue
+		}
+
+		committer := rp.signature()
+		_, err = w.Commit(c.Message, &git.CommitOptions{Author: &c.Author, Committer: committer})
+		if err != nil {
+			return nil, &Error{Op: "commit (rebase " + c.Hash.String()[:7] + ")", Path: rp.Path, Err: err}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		log.Printf("GitOps: rebase com conflito em %d arquivo(s) gerado(s); serão regenerados: %v", len(conflicts), conflicts)
+	}
+	return conflicts, nil
+}
+
+// applyFile grava (ou remove) no working tree o conteúdo de path em tree e faz stage.
+func (rp *Repo) applyFile(w *git.Worktree, tree *object.Tree, path string, remove bool) error {
+	full := filepath.Join(rp.Path, filepath.FromSlash(path))
+
+	if remove {
+		if _, err := w.Remove(path); err != nil {
+			return &Error{Op: "rm " + path, Path: rp.Path, Err: err}
+		}
+		return nil
+	}
+
+	f, err := tree.File(path)
+	if err != nil {
+		return &Error{Op: "rebase " + path, Path: rp.Path, Err: err}
+	}
+	rd, err := f.Reader()
+	if err != nil {
+		return &Error{Op: "rebase " + path, Path: rp.Path, Err: err}
+	}
+	defer rd.Close()
+
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return &Error{Op: "rebase " + path, Path: rp.Path, Err: err}
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return fmt.Errorf("criando diretório de %s: %w", full, err)
+	}
+	if err := os.WriteFile(full, data, 0o644); err != nil {
+		return fmt.Errorf("gravando %s: %w", full, err)
+	}
+	if _, err := w.Add(path); err != nil {
+		return &Error{Op: "add " + path, Path: rp.Path, Err: err}
+	}
+	return nil
+}
+
+func treeOf(r *git.Repository, h plumbing.Hash) (*object.Tree, error) {
+	c, err := r.CommitObject(h)
+	if err != nil {
+		return nil, err
+	}
+	return c.Tree()
+}
+
+// fileHash retorna o hash do blob em path (ZeroHash se o arquivo não existe na árvore).
+func fileHash(t *object.Tree, path string) plumbing.Hash {
+	f, err := t.File(path)
+	if err != nil {
+		if !errors.Is(err, object.ErrFileNotFound) && !errors.Is(err, object.ErrDirectoryNotFound) {
+			log.Printf("GitOps: aviso lendo %s da árvore: %v", path, err)
+		}
+		return plumbing.ZeroHash
+	}
+	return f.Hash
+}
@@ -0,0 +1,216 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+)
+
+func TestIsPushRejected(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{git.ErrNonFastForwardUpdate, true},
+		{&Error{Op: "push x", Err: errors.New("non-fast-forward update: refs/heads/ingestion-vendas")}, true},
+		{errors.New("command error on refs/heads/ingestion-vendas: fetch first"), true},
+		{errors.New("command error on refs/heads/main: pre-receive hook declined"), false},
+		{errors.New("remote: GitLab: You are not allowed to push code to protected branches; [remote rejected]"), false},
+		{errors.New("authorization failed"), false},
+	}
+	for _, tt := range tests {
+		if got := isPushRejected(tt.err); got != tt.want {
+			t.Errorf("isPushRejected(%q) = %v, esperado %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// racingClones prepara dois checkouts da mesma branch de wave sobre um único remote.
+func racingClones(t *testing.T) (string, *Repo, *Repo) {
+	t.Helper()
+	bare := newBareRepo(t)
+	repos := make([]*Repo, 2)
+	for i := range repos {
+		cfg := testConfig(t, bare)
+		cfg.PushMaxAttempts = 3
+		rp, err := PrepareRepo(cfg, "", "vendas")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { rp.Close() })
+		repos[i] = rp
+	}
+	return bare, repos[0], repos[1]
+}
+
+// Dois clones gravam arquivos diferentes: o segundo push é rejeitado, refaz o rebase
+// sobre a branch remota e entra na segunda tentativa com os dois arquivos.
+func TestPushRaceDisjointFiles(t *testing.T) {
+	bare, a, b := racingClones(t)
+
+	fa := filepath.Join(a.Path, "sources", "a.yaml")
+	writeFile(t, fa, "a: 1\n")
+	fb := filepath.Join(b.Path, "sources", "b.yaml")
+	writeFile(t, fb, "b: 1\n")
+
+	if _, err := a.CommitAndPush("wave a", []string{fa}, nil); err != nil {
+		t.Fatal(err)
+	}
+	regenerated := false
+	pushed, err := b.CommitAndPush("wave b", []string{fb}, func() ([]string, error) {
+		regenerated = true
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed || regenerated {
+		t.Errorf("pushed=%v regenerated=%v", pushed, regenerated)
+	}
+
+	if got, _ := remoteFile(t, bare, "ingestion-vendas", "sources/a.yaml"); got != "a: 1\n" {
+		t.Errorf("a.yaml = %q", got)
+	}
+	if got, _ := remoteFile(t, bare, "ingestion-vendas", "sources/b.yaml"); got != "b: 1\n" {
+		t.Errorf("b.yaml = %q", got)
+	}
+}
+
+// Os dois clones gravam o mesmo arquivo: o rebase do segundo conflita e regenerate
+// regrava o arquivo sobre a versão remota.
+func TestPushRaceConflictRegenerates(t *testing.T) {
+	bare, a, b := racingClones(t)
+
+	fa := filepath.Join(a.Path, "source.yaml")
+	writeFile(t, fa, "tables: [a]\n")
+	fb := filepath.Join(b.Path, "source.yaml")
+	writeFile(t, fb, "tables: [b]\n")
+
+	if _, err := a.CommitAndPush("wave a", []string{fa}, nil); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err := b.CommitAndPush("wave b", []string{fb}, func() ([]string, error) {
+		if len(b.Conflicts) != 1 || b.Conflicts[0] != "source.yaml" {
+			return nil, fmt.Errorf("conflitos = %v", b.Conflicts)
+		}
+		writeFile(t, fb, "tables: [a, b]\n")
+		return []string{fb}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed || len(b.Conflicts) != 0 {
+		t.Errorf("pushed=%v conflicts=%v", pushed, b.Conflicts)
+	}
+	if got, _ := remoteFile(t, bare, "ingestion-vendas", "source.yaml"); got != "tables: [a, b]\n" {
+		t.Errorf("source.yaml = %q", got)
+	}
+}
+
+func TestPushRaceConflictWithoutRegenerate(t *testing.T) {
+	_, a, b := racingClones(t)
+
+	fa := filepath.Join(a.Path, "source.yaml")
+	writeFile(t, fa, "tables: [a]\n")
+	fb := filepath.Join(b.Path, "source.yaml")
+	writeFile(t, fb, "tables: [b]\n")
+
+	if _, err := a.CommitAndPush("wave a", []string{fa}, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := b.CommitAndPush("wave b", []string{fb}, nil)
+	if err == nil || !strings.Contains(err.Error(), "source.yaml") {
+		t.Fatalf("esperado erro de conflito, veio %v", err)
+	}
+}
+
+// regenerate que não regrava um arquivo conflitante não pode commitar a versão da base.
+func TestPushConflictNotRegenerated(t *testing.T) {
+	_, a, b := racingClones(t)
+
+	fa := filepath.Join(a.Path, "source.yaml")
+	writeFile(t, fa, "tables: [a]\n")
+	fb := filepath.Join(b.Path, "source.yaml")
+	writeFile(t, fb, "tables: [b]\n")
+	other := filepath.Join(b.Path, "sink.yaml")
+	writeFile(t, other, "sink: b\n")
+
+	if _, err := a.CommitAndPush("wave a", []string{fa}, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err := b.CommitAndPush("wave b", []string{fb, other}, func() ([]string, error) {
+		return []string{other}, nil
+	})
+	var gerr *Error
+	if !errors.As(err, &gerr) || !strings.Contains(err.Error(), "não regenerou") || !strings.Contains(err.Error(), "source.yaml") {
+		t.Fatalf("esperado erro listando source.yaml, veio %v", err)
+	}
+}
+
+// Branch remota reescrita depois do PrepareRepo: o conflito detectado no PrepareRepo
+// precisa ser coberto pelos caminhos do primeiro commit.
+func TestPrepareRepoConflictsMustBeRegenerated(t *testing.T) {
+	bare := newBareRepo(t)
+
+	// branch da wave com source.yaml
+	first := testConfig(t, bare)
+	rp, err := PrepareRepo(first, "", "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filepath.Join(rp.Path, "source.yaml")
+	writeFile(t, f, "tables: [a]\n")
+	if _, err := rp.CommitAndPush("wave", []string{f}, nil); err != nil {
+		t.Fatal(err)
+	}
+	rp.Close()
+
+	// main anda e muda o mesmo arquivo: a branch da wave fica atrás e conflita no rebase
+	seed := testConfig(t, bare)
+	seed.BranchPrefix = ""
+	base, err := PrepareRepo(seed, "", "hotfix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(base.Path, "source.yaml"), "tables: [hotfix]\n")
+	w, _ := base.repo.Worktree()
+	if _, err := w.Add("source.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Commit("hotfix", &git.CommitOptions{Author: base.signature()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := base.repo.Push(&git.PushOptions{RemoteName: remoteName, RefSpecs: []gitconfig.RefSpec{"refs/heads/hotfix:refs/heads/main"}}); err != nil {
+		t.Fatal(err)
+	}
+	base.Close()
+
+	second := testConfig(t, bare)
+	rp, err = PrepareRepo(second, "", "vendas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+	if len(rp.Conflicts) != 1 || rp.Conflicts[0] != "source.yaml" {
+		t.Fatalf("conflitos = %v", rp.Conflicts)
+	}
+
+	unrelated := filepath.Join(rp.Path, "sink.yaml")
+	writeFile(t, unrelated, "sink: 1\n")
+	if _, err := rp.CommitAndPush("wave", []string{unrelated}, nil); err == nil || !strings.Contains(err.Error(), "source.yaml") {
+		t.Fatalf("esperado erro por source.yaml não regenerado, veio %v", err)
+	}
+
+	writeFile(t, filepath.Join(rp.Path, "source.yaml"), "tables: [hotfix, a]\n")
+	if _, err := rp.CommitAndPush("wave", []string{unrelated, filepath.Join(rp.Path, "source.yaml")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := remoteFile(t, bare, "ingestion-vendas", "source.yaml"); got != "tables: [hotfix, a]\n" {
+		t.Errorf("source.yaml = %q", got)
+	}
+}
@@ -0,0 +1,114 @@
+package governance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/model"
+	"ih-ingestion/internal/snowflake"
+)
+
+// ResolveColumns casa as colunas de classifications com as colunas reais da tabela
+// (sem diferenciar maiúsculas), devolvendo nome real -> classificações.
+// Com strict, coluna inexistente é erro; senão é ignorada.
+func ResolveColumns(cols []model.ColumnInfo, classes map[string][]string, strict bool) (map[string][]string, error) {
+	byName := map[string]string{}
+	for _, c := range cols {
+		byName[strings.ToUpper(c.Name)] = c.Name
+	}
+
+	out := map[string][]string{}
+	for col, names := range classes {
+		real, ok := byName[strings.ToUpper(strings.TrimSpace(col))]
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("classifications: coluna %s não existe (ou foi excluída)", col)
+			}
+			continue
+		}
+		out[real] = append(out[real], names...)
+	}
+	return out, nil
+}
+
+// BuildSQL gera o bloco de governança do script do job: CREATE TAG, SET TAG e
+// SET MASKING POLICY em cada uma das tabelas (normalmente _INGEST e final).
+func BuildSQL(g config.Governance, tables []string, columns map[string][]string) (string, error) {
+	if len(columns) == 0 {
+		return "", nil
+	}
+
+	colNames := make([]string, 0, len(columns))
+	for c := range columns {
+		colNames = append(colNames, c)
+	}
+	sort.Strings(colNames)
+
+	tagSet := map[string]bool{}
+	type colGov struct {
+		column string
+		tags   []string // "<tag> = '<valor>'"
+		policy string
+	}
+	var plan []colGov
+
+	for _, col := range colNames {
+		cg := colGov{column: col}
+		values := map[string]string{}
+
+		for _, name := range columns[col] {
+			c, ok := g.ResolveClassification(name)
+			if !ok {
+				return "", fmt.Errorf("coluna %s: classificação %q não declarada em governance.classifications", col, name)
+			}
+			tag := qualifyTag(g.TagSchema, c.Tag)
+			if prev, ok := values[tag]; ok {
+				if prev != c.Value {
+					return "", fmt.Errorf("coluna %s: tag %s com valores diferentes (%s e %s)", col, tag, prev, c.Value)
+				}
+				continue
+			}
+			values[tag] = c.Value
+			tagSet[tag] = true
+			cg.tags = append(cg.tags, fmt.Sprintf("%s = '%s'", tag, strings.ReplaceAll(c.Value, "'", "''")))
+
+			if c.MaskingPolicy != "" {
+				if cg.policy != "" && cg.policy != c.MaskingPolicy {
+					return "", fmt.Errorf("coluna %s: mais de uma masking policy (%s e %s)", col, cg.policy, c.MaskingPolicy)
+				}
+				cg.policy = c.MaskingPolicy
+			}
+		}
+		plan = append(plan, cg)
+	}
+
+	tags := make([]string, 0, len(tagSet))
+	for t := range tagSet {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+
+	var b strings.Builder
+	for _, t := range tags {
+		fmt.Fprintf(&b, "CREATE TAG IF NOT EXISTS %s;\n", t)
+	}
+	for _, table := range tables {
+		for _, cg := range plan {
+			fmt.Fprintf(&b, "ALTER TABLE %s MODIFY COLUMN %s SET TAG %s;\n", table, snowflake.QuoteIdent(cg.column), strings.Join(cg.tags, ", "))
+			if cg.policy != "" {
+				fmt.Fprintf(&b, "ALTER TABLE %s MODIFY COLUMN %s SET MASKING POLICY %s;\n", table, snowflake.QuoteIdent(cg.column), cg.policy)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+func qualifyTag(tagSchema, tag string) string {
+	if strings.TrimSpace(tagSchema) == "" {
+		return tag
+	}
+	return strings.TrimSpace(tagSchema) + "." + tag
+}
@@ -0,0 +1,93 @@
+package governance
+
+import (
+	"fmt"
+	"strings"
+
+	"ih-ingestion/internal/config"
+)
+
+// Objects são os objetos criados pelo job de uma tabela (nomes sem qualificação,
+// o script já faz USE DATABASE/USE SCHEMA).
+type Objects struct {
+	Database    string
+	Schema      string
+	IngestTable string
+	FinalTable  string
+	Stage       string
+}
+
+const writePrivileges = "SELECT, INSERT, UPDATE, DELETE, TRUNCATE"
+
+// BuildGrantsSQL gera os GRANTs do job. schemaLevel (topo + alias) recebe também
+// future grants no schema; tableLevel vale só para as tabelas deste job.
+// GRANT é idempotente no Snowflake, então o job pode ser reexecutado.
+// O GRANT OWNERSHIP vai por último, depois de todos os grants concedidos pelo role do job;
+// a reexecução depende do role do job herdar o owner (ver BuildOwnerCheckSQL).
+func BuildGrantsSQL(o Objects, schemaLevel, tableLevel config.Grants) string {
+	all := config.MergeGrants(schemaLevel, tableLevel)
+	if all.IsEmpty() {
+		return ""
+	}
+
+	schema := o.Database + "." + o.Schema
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&b, format+";\n", args...)
+	}
+
+	for _, r := range config.MergeGrants(config.Grants{Readers: all.Readers}, config.Grants{Readers: all.Writers}).Readers {
+		line("GRANT USAGE ON DATABASE %s TO ROLE %s", o.Database, r)
+		line("GRANT USAGE ON SCHEMA %s TO ROLE %s", schema, r)
+	}
+
+	for _, r := range all.Readers {
+		line("GRANT SELECT ON TABLE %s TO ROLE %s", o.FinalTable, r)
+	}
+	for _, r := range schemaLevel.Readers {
+		line("GRANT SELECT ON FUTURE TABLES IN SCHEMA %s TO ROLE %s", schema, r)
+	}
+
+	for _, r := range all.Writers {
+		line("GRANT %s ON TABLE %s TO ROLE %s", writePrivileges, o.IngestTable, r)
+		line("GRANT %s ON TABLE %s TO ROLE %s", writePrivileges, o.FinalTable, r)
+		line("GRANT READ, WRITE ON STAGE %s TO ROLE %s", o.Stage, r)
+	}
+	for _, r := range schemaLevel.Writers {
+		line("GRANT %s ON FUTURE TABLES IN SCHEMA %s TO ROLE %s", writePrivileges, schema, r)
+	}
+
+	if all.Owner != "" {
+		line("GRANT OWNERSHIP ON TABLE %s TO ROLE %s COPY CURRENT GRANTS", o.IngestTable, all.Owner)
+		line("GRANT OWNERSHIP ON TABLE %s TO ROLE %s COPY CURRENT GRANTS", o.FinalTable, all.Owner)
+		line("GRANT OWNERSHIP ON STAGE %s TO ROLE %s COPY CURRENT GRANTS", o.Stage, all.Owner)
+	}
+
+	return b.String()
+}
+
+// BuildOwnerCheckSQL gera a pré-condição do owner, executada antes do DROP/CREATE do script.
+// Depois do GRANT OWNERSHIP, só quem tem o owner consegue apagar as tabelas e alterar o
+// stage numa nova execução: o role do job precisa herdá-lo (GRANT ROLE <owner> TO ROLE
+// <role do job>, feito uma vez por um admin). Sem isso o script para aqui, com a
+// instrução no erro, em vez de falhar no meio com "insufficient privileges".
+func BuildOwnerCheckSQL(jobRole string, schemaLevel, tableLevel config.Grants) string {
+	owner := strings.ToUpper(config.MergeGrants(schemaLevel, tableLevel).Owner)
+	if owner == "" {
+		return ""
+	}
+	jobRole = strings.ToUpper(jobRole)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Owner %s: o role do job precisa herdá-lo para recriar os objetos a cada execução\n", owner)
+	b.WriteString("EXECUTE IMMEDIATE $$\n")
+	b.WriteString("DECLARE\n")
+	fmt.Fprintf(&b, "  owner_nao_herdado EXCEPTION (-20001, 'role %s não herda o owner %s: rode uma vez GRANT ROLE %s TO ROLE %s');\n", jobRole, owner, owner, jobRole)
+	b.WriteString("BEGIN\n")
+	fmt.Fprintf(&b, "  IF (NOT IS_ROLE_IN_SESSION('%s')) THEN\n", owner)
+	b.WriteString("    RAISE owner_nao_herdado;\n")
+	b.WriteString("  END IF;\n")
+	b.WriteString("END;\n")
+	b.WriteString("$$;")
+	return b.String()
+}
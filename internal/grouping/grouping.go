@@ -0,0 +1,363 @@
+package grouping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Estratégias de agrupamento de tabelas em sources
+const (
+	FirstFit     = "first-fit"     // maiores primeiro, no primeiro grupo que couber (padrão)
+	Balanced     = "balanced"      // maiores primeiro, no grupo com menos linhas: minimiza o maior grupo
+	Affinity     = "affinity"      // tabelas com a mesma affinity ou ligadas por FK ficam juntas
+	ChangeVolume = "change-volume" // balanceia pelo volume de alterações em vez das linhas
+)
+
+// Names lista as estratégias aceitas em sqlservers[].grouping.
+var Names = []string{FirstFit, Balanced, Affinity, ChangeVolume}
+
+// Item é uma tabela a agrupar.
+type Item struct {
+	Key      string // schema.tabela (usado pelas relações de FK)
+	Rows     int64
+	Changes  int64  // alterações estimadas por dia
+	Affinity string // grupo declarado no ingestion.yaml
+}
+
+// Limits de um source (0 = sem limite).
+type Limits struct {
+	MaxTables  int
+	MaxRows    int64
+	MaxChanges int64
+}
+
+// Group é um source: índices dos itens e os totais.
+type Group struct {
+	Items   []int
+	Rows    int64
+	Changes int64
+}
+
+// Strategy distribui os itens em grupos respeitando os limites. Um item que sozinho
+// passa dos limites fica em um grupo próprio. related são pares de Key ligados por FK.
+type Strategy interface {
+	Name() string
+	Group(items []Item, limits Limits, related [][2]string) []Group
+}
+
+// Get devolve a estratégia pelo nome (vazio = first-fit).
+func Get(name string) (Strategy, error) {
+	switch strings.TrimSpace(name) {
+	case "", FirstFit:
+		return firstFit{}, nil
+	case Balanced:
+		return balanced{}, nil
+	case Affinity:
+		return affinity{}, nil
+	case ChangeVolume:
+		return changeVolume{}, nil
+	}
+	return nil, fmt.Errorf("estratégia de agrupamento %q desconhecida (use %s)", name, strings.Join(Names, ", "))
+}
+
+// IsValid indica se name é uma estratégia conhecida (vazio vale o padrão).
+func IsValid(name string) bool {
+	_, err := Get(name)
+	return err == nil
+}
+
+func (g *Group) fits(it Item, l Limits) bool {
+	if l.MaxTables > 0 && len(g.Items) >= l.MaxTables {
+		return false
+	}
+	if l.MaxRows > 0 && g.Rows+it.Rows > l.MaxRows {
+		return false
+	}
+	if l.MaxChanges > 0 && g.Changes+it.Changes > l.MaxChanges {
+		return false
+	}
+	return true
+}
+
+func (g *Group) add(idx int, it Item) {
+	g.Items = append(g.Items, idx)
+	g.Rows += it.Rows
+	g.Changes += it.Changes
+}
+
+func unlimited(l Limits) bool {
+	return l.MaxTables <= 0 && l.MaxRows <= 0 && l.MaxChanges <= 0
+}
+
+func all(items []Item) []Group {
+	var g Group
+	for i, it := range items {
+		g.add(i, it)
+	}
+	return []Group{g}
+}
+
+// order devolve os índices ordenados por peso decrescente (estável: empate mantém a ordem do YAML).
+func order(items []Item, weight func(Item) int64) []int {
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return weight(items[idx[a]]) > weight(items[idx[b]]) })
+	return idx
+}
+
+func rows(it Item) int64    { return it.Rows }
+func changes(it Item) int64 { return it.Changes }
+
+// ==== first-fit ====
+
+type firstFit struct{}
+
+func (firstFit) Name() string { return FirstFit }
+
+func (firstFit) Group(items []Item, l Limits, _ [][2]string) []Group {
+	if len(items) == 0 {
+		return nil
+	}
+	if unlimited(l) {
+		return all(items)
+	}
+
+	var groups []Group
+	for _, i := range order(items, rows) {
+		groups = placeFirstFit(groups, i, items[i], l)
+	}
+	return groups
+}
+
+func placeFirstFit(groups []Group, idx int, it Item, l Limits) []Group {
+	for gi := range groups {
+		if groups[gi].fits(it, l) {
+			groups[gi].add(idx, it)
+			return groups
+		}
+	}
+	var g Group
+	g.add(idx, it)
+	return append(groups, g)
+}
+
+// ==== balanced / change-volume ====
+
+type balanced struct{}
+
+func (balanced) Name() string { return Balanced }
+
+func (balanced) Group(items []Item, l Limits, _ [][2]string) []Group {
+	return leastLoaded(items, l, rows, func(g Group) int64 { return g.Rows })
+}
+
+type changeVolume struct{}
+
+func (changeVolume) Name() string { return ChangeVolume }
+
+func (changeVolume) Group(items []Item, l Limits, _ [][2]string) []Group {
+	return leastLoaded(items, l, changes, func(g Group) int64 { return g.Changes })
+}
+
+// leastLoaded abre o mínimo de grupos que os limites exigem e coloca cada item (maior peso
+// primeiro) no grupo com menor carga onde ele cabe; se não couber em nenhum, abre outro.
+func leastLoaded(items []Item, l Limits, weight func(Item) int64, load func(Group) int64) []Group {
+	if len(items) == 0 {
+		return nil
+	}
+	if unlimited(l) {
+		return all(items)
+	}
+
+	groups := make([]Group, minGroups(items, l))
+	for _, i := range order(items, weight) {
+		best := -1
+		for gi := range groups {
+			if !groups[gi].fits(items[i], l) {
+				continue
+			}
+			if best < 0 || load(groups[gi]) < load(groups[best]) ||
+				(load(groups[gi]) == load(groups[best]) && len(groups[gi].Items) < len(groups[best].Items)) {
+				best = gi
+			}
+		}
+		if best < 0 {
+			groups = append(groups, Group{})
+			best = len(groups) - 1
+		}
+		groups[best].add(i, items[i])
+	}
+
+	// grupos abertos de antemão que ficaram vazios
+	out := groups[:0]
+	for _, g := range groups {
+		if len(g.Items) > 0 {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// minGroups é o limite inferior de grupos pelos totais (tabelas, linhas e alterações).
+func minGroups(items []Item, l Limits) int {
+	var totalRows, totalChanges int64
+	for _, it := range items {
+		totalRows += it.Rows
+		totalChanges += it.Changes
+	}
+	n := 1
+	if l.MaxTables > 0 {
+		n = max(n, ceilDiv(int64(len(items)), int64(l.MaxTables)))
+	}
+	if l.MaxRows > 0 {
+		n = max(n, ceilDiv(totalRows, l.MaxRows))
+	}
+	if l.MaxChanges > 0 {
+		n = max(n, ceilDiv(totalChanges, l.MaxChanges))
+	}
+	return min(n, len(items))
+}
+
+func ceilDiv(a, b int64) int {
+	return int((a + b - 1) / b)
+}
+
+// ==== affinity ====
+
+type affinity struct{}
+
+func (affinity) Name() string { return Affinity }
+
+// Group junta em unidades as tabelas com a mesma affinity ou ligadas por FK (direta ou
+// transitiva) e distribui as unidades inteiras por first-fit. Uma unidade que não cabe
+// nos limites nem sozinha é quebrada por first-fit entre grupos novos.
+func (affinity) Group(items []Item, l Limits, related [][2]string) []Group {
+	if len(items) == 0 {
+		return nil
+	}
+	if unlimited(l) {
+		return all(items)
+	}
+
+	units := affinityUnits(items, related)
+
+	// unidades maiores primeiro
+	sort.SliceStable(units, func(a, b int) bool { return unitRows(items, units[a]) > unitRows(items, units[b]) })
+
+	var groups []Group
+	for _, u := range units {
+		var unit Group
+		for _, i := range u {
+			unit.add(i, items[i])
+		}
+		if !unitFits(Group{}, unit, l) {
+			var split []Group
+			for _, i := range order(subset(items, u), rows) {
+				split = placeFirstFit(split, u[i], items[u[i]], l)
+			}
+			groups = append(groups, split...)
+			continue
+		}
+
+		placed := false
+		for gi := range groups {
+			if unitFits(groups[gi], unit, l) {
+				for _, i := range u {
+					groups[gi].add(i, items[i])
+				}
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, unit)
+		}
+	}
+	return groups
+}
+
+func unitFits(g, unit Group, l Limits) bool {
+	if l.MaxTables > 0 && len(g.Items)+len(unit.Items) > l.MaxTables {
+		return false
+	}
+	if l.MaxRows > 0 && g.Rows+unit.Rows > l.MaxRows {
+		return false
+	}
+	if l.MaxChanges > 0 && g.Changes+unit.Changes > l.MaxChanges {
+		return false
+	}
+	return true
+}
+
+func unitRows(items []Item, u []int) int64 {
+	var total int64
+	for _, i := range u {
+		total += items[i].Rows
+	}
+	return total
+}
+
+func subset(items []Item, idx []int) []Item {
+	out := make([]Item, len(idx))
+	for i, j := range idx {
+		out[i] = items[j]
+	}
+	return out
+}
+
+// affinityUnits une (union-find) itens com a mesma affinity ou ligados por FK.
+// As unidades saem na ordem do primeiro item de cada uma.
+func affinityUnits(items []Item, related [][2]string) [][]int {
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	byKey := map[string]int{}
+	byAffinity := map[string]int{}
+	for i, it := range items {
+		byKey[strings.ToUpper(it.Key)] = i
+		if a := strings.ToUpper(strings.TrimSpace(it.Affinity)); a != "" {
+			if j, ok := byAffinity[a]; ok {
+				union(j, i)
+			} else {
+				byAffinity[a] = i
+			}
+		}
+	}
+	for _, r := range related {
+		a, okA := byKey[strings.ToUpper(r[0])]
+		b, okB := byKey[strings.ToUpper(r[1])]
+		if okA && okB {
+			union(a, b)
+		}
+	}
+
+	var units [][]int
+	pos := map[int]int{}
+	for i := range items {
+		root := find(i)
+		if p, ok := pos[root]; ok {
+			units[p] = append(units[p], i)
+			continue
+		}
+		pos[root] = len(units)
+		units = append(units, []int{i})
+	}
+	return units
+}
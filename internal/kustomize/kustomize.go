@@ -1,14 +1,23 @@
 package kustomize
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/konfig"
+
+	"ih-ingestion/internal/generator"
+	"ih-ingestion/internal/yamlnode"
 )
 
+// Kustomization é o conteúdo de um kustomization.yaml criado pelo CLI. Arquivos que já
+// existem são editados no nó YAML (ver UpdateKustomization), sem passar por esta struct.
 type Kustomization struct {
 	APIVersion string   `yaml:"apiVersion,omitempty"`
 	Kind       string   `yaml:"kind,omitempty"`
@@ -16,10 +25,28 @@ type Kustomization struct {
 	Resources  []string `yaml:"resources,omitempty"`
 }
 
-// UpdateKustomization garante que o kustomization.yaml da pasta `dir` exista
+// File devolve o kustomization da pasta dir (qualquer nome aceito pelo kustomize) e
+// se ele existe. Sem arquivo, devolve o caminho do kustomization.yaml a criar.
+func File(dir string) (string, bool, error) {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, true, nil
+		} else if !os.IsNotExist(err) {
+			return "", false, fmt.Errorf("erro lendo %s: %w", path, err)
+		}
+	}
+	return filepath.Join(dir, konfig.DefaultKustomizationFileName()), false, nil
+}
+
+// UpdateKustomization garante que o kustomization da pasta `dir` exista
 // e contenha todos os arquivos informados em `newFiles` (apenas nomes de arquivo, não caminhos absolutos).
 // namespace: se != "" e o arquivo ainda não tiver namespace, ele seta.
 // se namespace == "", não mexe no campo Namespace existente.
+//
+// Um arquivo existente só ganha itens no fim de resources (e o namespace, se faltar):
+// os demais campos, a ordem e os comentários ficam como estão. Sem mudança, o arquivo
+// não é regravado.
 func UpdateKustomization(dir string, newFiles []string, namespace string) error {
 	if len(newFiles) == 0 {
 		return nil
@@ -44,47 +71,152 @@ func UpdateKustomization(dir string, newFiles []string, namespace string) error
 		return nil
 	}
 
-	kpath := filepath.Join(dir, "kustomization.yaml")
-	var k Kustomization
+	kpath, exists, err := File(dir)
+	if err != nil {
+		return err
+	}
 
-	data, err := os.ReadFile(kpath)
-	if err == nil {
-		if err := yaml.Unmarshal(data, &k); err != nil {
-			return fmt.Errorf("falha ao parsear %s: %w", kpath, err)
+	var out []byte
+	if exists {
+		data, err := os.ReadFile(kpath)
+		if err != nil {
+			return fmt.Errorf("erro lendo %s: %w", kpath, err)
+		}
+		var changed bool
+		out, changed, err = appendResources(data, uniqNew, namespace)
+		if err != nil {
+			return fmt.Errorf("%s: %w", kpath, err)
+		}
+		if !changed {
+			return nil
+		}
+	} else {
+		k := Kustomization{
+			APIVersion: "kustomize.config.k8s.io/v1beta1",
+			Kind:       "Kustomization",
+			Namespace:  namespace,
+			Resources:  uniqNew,
+		}
+		if out, err = yaml.Marshal(&k); err != nil {
+			return fmt.Errorf("falha ao serializar kustomization: %w", err)
 		}
-	} else if !os.IsNotExist(err) {
-		return fmt.Errorf("erro lendo %s: %w", kpath, err)
 	}
 
-	if k.APIVersion == "" {
-		k.APIVersion = "kustomize.config.k8s.io/v1beta1"
+	if err := os.WriteFile(kpath, out, 0o644); err != nil {
+		return fmt.Errorf("falha ao escrever %s: %w", kpath, err)
+	}
+	generator.TrackWrite(kpath)
+
+	return nil
+}
+
+// appendResources acrescenta ao fim de resources os itens que faltam e seta o namespace
+// se ele não existir, editando o nó YAML do documento.
+func appendResources(data []byte, files []string, namespace string) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("falha ao parsear: %w", err)
 	}
-	if k.Kind == "" {
-		k.Kind = "Kustomization"
+	if len(doc.Content) == 0 {
+		// arquivo vazio ou só com comentários
+		doc = yaml.Node{Kind: yaml.DocumentNode, HeadComment: doc.HeadComment, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
 	}
-	if namespace != "" && k.Namespace == "" {
-		k.Namespace = namespace
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, false, errors.New("documento não é um mapa")
 	}
 
-	existing := map[string]struct{}{}
-	for _, r := range k.Resources {
-		existing[strings.TrimSpace(r)] = struct{}{}
+	changed := false
+	if namespace != "" {
+		if ns := yamlnode.Value(root, "namespace"); ns == nil {
+			root.Content = append(root.Content, scalarNode("namespace"), scalarNode(namespace))
+			changed = true
+		} else if ns.Kind != yaml.ScalarNode {
+			return nil, false, errors.New("namespace não é um texto")
+		}
 	}
 
-	for _, f := range uniqNew {
+	resources := yamlnode.Value(root, "resources")
+	switch {
+	case resources == nil:
+		resources = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, scalarNode("resources"), resources)
+	case resources.ShortTag() == "!!null":
+		// "resources:" sem itens
+		*resources = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", LineComment: resources.LineComment}
+	case resources.Kind != yaml.SequenceNode:
+		return nil, false, errors.New("resources não é uma lista")
+	}
+
+	existing := map[string]struct{}{}
+	for _, r := range resources.Content {
+		existing[strings.TrimSpace(r.Value)] = struct{}{}
+	}
+	for _, f := range files {
 		if _, ok := existing[f]; !ok {
-			k.Resources = append(k.Resources, f)
+			resources.Content = append(resources.Content, scalarNode(f))
+			changed = true
+		}
+	}
+	if changed && resources.Style&yaml.FlowStyle != 0 {
+		// [a, b] vira lista em bloco; o comentário da linha fica na chave
+		resources.Style &^= yaml.FlowStyle
+		if k := yamlnode.Key(root, "resources"); k.LineComment == "" {
+			k.LineComment, resources.LineComment = resources.LineComment, ""
 		}
 	}
+	if !changed {
+		return nil, false, nil
+	}
 
-	out, err := yaml.Marshal(&k)
-	if err != nil {
-		return fmt.Errorf("falha ao serializar kustomization: %w", err)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, false, fmt.Errorf("falha ao serializar kustomization: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, false, err
 	}
+	return buf.Bytes(), true, nil
+}
 
-	if err := os.WriteFile(kpath, out, 0o644); err != nil {
-		return fmt.Errorf("falha ao escrever %s: %w", kpath, err)
+func scalarNode(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+// LinkDir inclui a pasta dir (ex: <db>_<schema> sob o SourceRoot) nos resources do
+// kustomization da pasta pai. Se o pai ainda não tem kustomization, ele é
+// criado com todas as subpastas que já têm um, para as pastas de bancos gerados
+// antes não ficarem fora do bundle.
+func LinkDir(dir string) error {
+	parent := filepath.Dir(dir)
+	entries := []string{filepath.Base(dir)}
+
+	_, exists, err := File(parent)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		subdirs, err := os.ReadDir(parent)
+		if err != nil {
+			return fmt.Errorf("erro lendo %s: %w", parent, err)
+		}
+		entries = entries[:0]
+		for _, e := range subdirs {
+			if !e.IsDir() {
+				continue
+			}
+			if _, ok, err := File(filepath.Join(parent, e.Name())); err != nil {
+				return err
+			} else if ok {
+				entries = append(entries, e.Name())
+			}
+		}
+		if !slices.Contains(entries, filepath.Base(dir)) {
+			entries = append(entries, filepath.Base(dir))
+		}
 	}
 
-	return nil
+	return UpdateKustomization(parent, entries, "")
 }
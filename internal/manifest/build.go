@@ -0,0 +1,178 @@
+package manifest
+
+import (
+	"sort"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/model"
+)
+
+const avroConverter = "io.confluent.connect.avro.AvroConverter"
+
+// Source monta o KafkaConnector Debezium SQL Server de um grupo de tabelas.
+func Source(c model.SourceConfig) KafkaConnector {
+	var cfg Config
+
+	cfg.Section("Conexão com SQL Server")
+	cfg.Set("database.hostname", c.DatabaseHost)
+	cfg.Set("database.port", c.DatabasePort)
+	cfg.Set("database.user", "${secrets:"+c.DatabaseSecret+":user}")
+	cfg.Set("database.password", "${secrets:"+c.DatabaseSecret+":password}")
+	cfg.Set("database.names", c.DatabaseNameUpper)
+	cfg.Set("database.encrypt", false)
+
+	cfg.Section("Tópicos e tabelas")
+	cfg.Set("topic.prefix", c.TopicPrefix)
+	if c.TopicPartitions > 0 {
+		cfg.Set("topic.creation.default.partitions", c.TopicPartitions)
+		cfg.Set("topic.creation.default.replication.factor", -1)
+	}
+	cfg.Set("table.include.list", c.TableIncludeList)
+	if c.ColumnExcludeList != "" {
+		cfg.Set("column.exclude.list", c.ColumnExcludeList)
+	}
+	for _, k := range sortedKeys(c.ColumnMasks) {
+		cfg.Set(k, c.ColumnMasks[k])
+	}
+
+	cfg.Section("Regras de tipos / tombstones")
+	cfg.Set("decimal.handling.mode", "string")
+	cfg.Set("tombstones.on.delete", false)
+
+	cfg.Section("Schema history interno do Debezium")
+	cfg.Set("schema.history.internal.kafka.bootstrap.servers", c.SchemaHistoryBootstrapServers)
+	cfg.Set("schema.history.internal.kafka.topic", c.SchemaHistoryTopic)
+
+	cfg.Section("Converters (Avro) + Schema Registry")
+	cfg.Set("value.converter", avroConverter)
+	cfg.Set("key.converter", avroConverter)
+	cfg.Set("key.converter.schemas.enable", "false")
+	cfg.Set("value.converter.schemas.enable", "true")
+	cfg.Set("key.converter.schema.registry.url", c.SchemaRegistryURL)
+	cfg.Set("value.converter.schema.registry.url", c.SchemaRegistryURL)
+
+	cfg.Section("Modo de snapshot e leitura")
+	cfg.Set("data.query.mode", "direct")
+	cfg.Set("snapshot.mode", "when_needed")
+	cfg.Set("snapshot.locking.mode", "none")
+	cfg.Set("snapshot.isolation.mode", "read_committed")
+	cfg.Set("snapshot.max.threads", c.SnapshotMaxThreads)
+
+	return connector(c.Name, c.ClusterName, "io.debezium.connector.sqlserver.SqlServerConnector", c.TasksMax, cfg)
+}
+
+// Sink monta o KafkaConnector Snowflake de uma tabela.
+func Sink(c model.SinkConfig) KafkaConnector {
+	var cfg Config
+
+	cfg.Set("topics", c.TopicName)
+	cfg.Set("url", c.SnowflakeURL)
+	cfg.Set("user", "${secrets:"+c.SnowflakeUserSecret+":username}")
+	cfg.Set("password", "${secrets:"+c.SnowflakePasswordSecret+":password}")
+	cfg.Set("stage", c.Stage)
+	cfg.Set("table", c.Table)
+	cfg.Set("schema", c.Schema)
+
+	cfg.Section("Converters (Avro) + Schema Registry")
+	cfg.Set("key.converter", avroConverter)
+	cfg.Set("key.converter.schema.registry.url", c.SchemaRegistryURL)
+	cfg.Set("key.converter.schemas.enable", true)
+	cfg.Set("value.converter", avroConverter)
+	cfg.Set("value.converter.schema.registry.url", c.SchemaRegistryURL)
+	cfg.Set("value.converter.schemas.enable", true)
+
+	if c.ColumnRenames != "" {
+		cfg.Section("Colunas renomeadas pela política naming (origem:snowflake)")
+		cfg.Set("transforms", "renameColumns")
+		cfg.Set("transforms.renameColumns.type", "org.apache.kafka.connect.transforms.ReplaceField$Value")
+		cfg.Set("transforms.renameColumns.renames", c.ColumnRenames)
+	}
+	if len(c.Buffer) > 0 {
+		cfg.Section("Buffer/flush da classe de tamanho")
+		for _, k := range sortedKeys(c.Buffer) {
+			cfg.Set(k, c.Buffer[k])
+		}
+	}
+	if len(c.ExtraConfig) > 0 {
+		cfg.Section("Overrides da tabela (sinkConfig)")
+		for _, k := range sortedKeys(c.ExtraConfig) {
+			cfg.Set(k, c.ExtraConfig[k])
+		}
+	}
+
+	return connector(c.Name, c.ClusterName, "br.com.datastreambrasil.v3.SnowflakeSinkConnector", c.TasksMax, cfg)
+}
+
+func connector(name, cluster, class string, tasksMax int, cfg Config) KafkaConnector {
+	return KafkaConnector{
+		APIVersion: "kafka.strimzi.io/v1beta2",
+		Kind:       "KafkaConnector",
+		Metadata: ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"strimzi.io/cluster": cluster},
+		},
+		Spec: KafkaConnectorSpec{
+			AutoRestart: &AutoRestart{Enabled: true},
+			Class:       class,
+			TasksMax:    tasksMax,
+			Config:      cfg,
+		},
+	}
+}
+
+// SnowflakeJob monta o Job que aplica o script.sql (c.Script) e o ConfigMap com o script.
+// O container sai de j (config.ResolveJobSettings).
+func SnowflakeJob(c model.SnowflakeJobConfig, j config.JobSettings) (Job, ConfigMap) {
+	backoff := int32(0)
+	automount := false
+
+	job := Job{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Metadata:   ObjectMeta{Name: c.JobName},
+		Spec: JobSpec{
+			BackoffLimit: &backoff,
+			Template: PodTemplateSpec{Spec: PodSpec{
+				RestartPolicy:                "Never",
+				AutomountServiceAccountToken: &automount,
+				SecurityContext:              j.PodSecurityContext,
+				Containers: []Container{{
+					Name:            "snowflake-apply",
+					Image:           j.Image,
+					ImagePullPolicy: j.ImagePullPolicy,
+					Command:         j.Command,
+					Args:            j.Args,
+					// credenciais: SNOWFLAKE_ACCOUNT, SNOWFLAKE_USER, SNOWFLAKE_PASSWORD, SNOWFLAKE_WAREHOUSE
+					EnvFrom:         []EnvFromSource{{SecretRef: &LocalObjectReference{Name: c.CredentialsSecret}}},
+					Env:             []EnvVar{{Name: "HOME", Value: "/tmp"}},
+					SecurityContext: j.SecurityContext,
+					VolumeMounts: []VolumeMount{
+						{Name: "sql", MountPath: "/sql", ReadOnly: true},
+						{Name: "tmp", MountPath: "/tmp"},
+					},
+				}},
+				Volumes: []Volume{
+					{Name: "sql", ConfigMap: &LocalObjectReference{Name: c.SqlConfigMapName}},
+					{Name: "tmp", EmptyDir: &EmptyDir{}},
+				},
+			}},
+		},
+	}
+
+	cm := ConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   ObjectMeta{Name: c.SqlConfigMapName},
+		Data:       map[string]string{"script.sql": c.Script},
+	}
+	return job, cm
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
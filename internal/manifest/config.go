@@ -0,0 +1,95 @@
+package manifest
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config é o spec.config de um KafkaConnector: chaves na ordem em que foram definidas,
+// com um comentário opcional abrindo cada seção.
+type Config struct {
+	entries []configEntry
+	pending string // comentário da próxima chave
+}
+
+type configEntry struct {
+	key     string
+	value   any
+	comment string
+}
+
+// Section abre uma seção: o comentário vai na próxima chave definida.
+func (c *Config) Section(comment string) {
+	c.pending = comment
+}
+
+// Set define key. Uma chave já existente mantém a posição e o comentário.
+func (c *Config) Set(key string, value any) {
+	for i := range c.entries {
+		if c.entries[i].key == key {
+			c.entries[i].value = value
+			return
+		}
+	}
+	c.entries = append(c.entries, configEntry{key: key, value: value, comment: c.pending})
+	c.pending = ""
+}
+
+// Get devolve o valor de key.
+func (c Config) Get(key string) (any, bool) {
+	for _, e := range c.entries {
+		if e.key == key {
+			return e.value, true
+		}
+	}
+	return nil, false
+}
+
+// Keys lista as chaves na ordem do manifesto.
+func (c Config) Keys() []string {
+	out := make([]string, len(c.entries))
+	for i, e := range c.entries {
+		out[i] = e.key
+	}
+	return out
+}
+
+func (c Config) MarshalYAML() (any, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, e := range c.entries {
+		k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.key}
+		if e.comment != "" {
+			k.HeadComment = "# " + e.comment
+		}
+		v := &yaml.Node{}
+		if err := v.Encode(e.value); err != nil {
+			return nil, fmt.Errorf("config %s: %w", e.key, err)
+		}
+		n.Content = append(n.Content, k, v)
+	}
+	return n, nil
+}
+
+func (c *Config) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("linha %d: spec.config deve ser um mapa", n.Line)
+	}
+	*c = Config{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if _, dup := c.Get(n.Content[i].Value); dup {
+			return fmt.Errorf("linha %d: chave %s duplicada em spec.config", n.Content[i].Line, n.Content[i].Value)
+		}
+		var v any
+		if err := n.Content[i+1].Decode(&v); err != nil {
+			return fmt.Errorf("config %s: %w", n.Content[i].Value, err)
+		}
+		comment := n.Content[i].HeadComment
+		if len(comment) > 2 && comment[:2] == "# " {
+			comment = comment[2:]
+		}
+		c.Section(comment)
+		c.Set(n.Content[i].Value, v)
+	}
+	return nil
+}
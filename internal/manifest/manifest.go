@@ -0,0 +1,287 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"ih-ingestion/internal/config"
+)
+
+// Recursos gerados pelo CLI, serializados com yaml.v3: a saída é sempre YAML válido,
+// com valores escapados pelo encoder e chaves na ordem dos campos.
+
+type ObjectMeta struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+// KafkaConnector (kafka.strimzi.io/v1beta2)
+type KafkaConnector struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   ObjectMeta         `yaml:"metadata"`
+	Spec       KafkaConnectorSpec `yaml:"spec"`
+}
+
+type KafkaConnectorSpec struct {
+	AutoRestart *AutoRestart `yaml:"autoRestart,omitempty"`
+	Class       string       `yaml:"class"`
+	TasksMax    int          `yaml:"tasksMax"`
+	Config      Config       `yaml:"config"`
+}
+
+type AutoRestart struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// Job (batch/v1): subconjunto usado pelo job Snowflake.
+type Job struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Metadata   ObjectMeta `yaml:"metadata"`
+	Spec       JobSpec    `yaml:"spec"`
+}
+
+type JobSpec struct {
+	BackoffLimit *int32          `yaml:"backoffLimit,omitempty"`
+	Template     PodTemplateSpec `yaml:"template"`
+}
+
+type PodTemplateSpec struct {
+	Spec PodSpec `yaml:"spec"`
+}
+
+type PodSpec struct {
+	RestartPolicy                string                  `yaml:"restartPolicy,omitempty"`
+	AutomountServiceAccountToken *bool                   `yaml:"automountServiceAccountToken,omitempty"`
+	SecurityContext              *config.SecurityContext `yaml:"securityContext,omitempty"`
+	Containers                   []Container             `yaml:"containers"`
+	Volumes                      []Volume                `yaml:"volumes,omitempty"`
+}
+
+type Container struct {
+	Name            string                  `yaml:"name"`
+	Image           string                  `yaml:"image"`
+	ImagePullPolicy string                  `yaml:"imagePullPolicy,omitempty"`
+	Command         []string                `yaml:"command,omitempty"`
+	Args            []string                `yaml:"args,omitempty"`
+	EnvFrom         []EnvFromSource         `yaml:"envFrom,omitempty"`
+	Env             []EnvVar                `yaml:"env,omitempty"`
+	SecurityContext *config.SecurityContext `yaml:"securityContext,omitempty"`
+	VolumeMounts    []VolumeMount           `yaml:"volumeMounts,omitempty"`
+}
+
+type EnvFromSource struct {
+	SecretRef *LocalObjectReference `yaml:"secretRef,omitempty"`
+}
+
+type LocalObjectReference struct {
+	Name string `yaml:"name"`
+}
+
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type Volume struct {
+	Name      string                `yaml:"name"`
+	ConfigMap *LocalObjectReference `yaml:"configMap,omitempty"`
+	EmptyDir  *EmptyDir             `yaml:"emptyDir,omitempty"`
+}
+
+type EmptyDir struct{}
+
+// ConfigMap (v1)
+type ConfigMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   ObjectMeta        `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// Marshal serializa os recursos em um arquivo multi-documento.
+func Marshal(docs ...any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, d := range docs {
+		if err := enc.Encode(d); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Verify faz o round-trip de um arquivo gerado: cada documento é lido no tipo do seu
+// kind e serializado, e o resultado precisa ter o mesmo conteúdo do original (um campo
+// que o tipo não conhece ou um valor convertido, como image: 1.0 virando "1.0", é erro).
+// A serialização também precisa ser estável numa segunda volta. Exige apiVersion, kind e
+// metadata.name em todos os documentos.
+func Verify(data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for i := 1; ; i++ {
+		var n yaml.Node
+		if err := dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				if i == 1 {
+					return errors.New("manifesto vazio")
+				}
+				return nil
+			}
+			return fmt.Errorf("documento %d: YAML inválido: %w", i, err)
+		}
+
+		var head struct {
+			APIVersion string     `yaml:"apiVersion"`
+			Kind       string     `yaml:"kind"`
+			Metadata   ObjectMeta `yaml:"metadata"`
+		}
+		if err := n.Decode(&head); err != nil {
+			return fmt.Errorf("documento %d: %w", i, err)
+		}
+		if head.APIVersion == "" || head.Kind == "" || head.Metadata.Name == "" {
+			return fmt.Errorf("documento %d: apiVersion, kind e metadata.name são obrigatórios", i)
+		}
+
+		first, err := roundTrip(&n, head.Kind)
+		if err != nil {
+			return fmt.Errorf("documento %d (%s %s): %w", i, head.Kind, head.Metadata.Name, err)
+		}
+		var again yaml.Node
+		if err := yaml.Unmarshal(first, &again); err != nil {
+			return fmt.Errorf("documento %d (%s %s): releitura: %w", i, head.Kind, head.Metadata.Name, err)
+		}
+		var want, got any
+		if err := n.Decode(&want); err != nil {
+			return fmt.Errorf("documento %d: %w", i, err)
+		}
+		if err := again.Decode(&got); err != nil {
+			return fmt.Errorf("documento %d (%s %s): releitura: %w", i, head.Kind, head.Metadata.Name, err)
+		}
+		if err := sameContent("", want, got); err != nil {
+			return fmt.Errorf("documento %d (%s %s): %w", i, head.Kind, head.Metadata.Name, err)
+		}
+		second, err := roundTrip(&again, head.Kind)
+		if err != nil {
+			return fmt.Errorf("documento %d (%s %s): releitura: %w", i, head.Kind, head.Metadata.Name, err)
+		}
+		if !bytes.Equal(first, second) {
+			return fmt.Errorf("documento %d (%s %s): serialização não é estável", i, head.Kind, head.Metadata.Name)
+		}
+	}
+}
+
+func roundTrip(n *yaml.Node, kind string) ([]byte, error) {
+	var v any
+	switch kind {
+	case "KafkaConnector":
+		v = &KafkaConnector{}
+	case "Job":
+		v = &Job{}
+	case "ConfigMap":
+		v = &ConfigMap{}
+	default:
+		v = &map[string]any{}
+	}
+	if err := n.Decode(v); err != nil {
+		return nil, err
+	}
+	return Marshal(v)
+}
+
+// sameContent compara o documento original (want) com o lido de volta do tipo (got).
+// Campos ausentes equivalem ao valor zero, já que o tipo omite campos vazios (omitempty).
+func sameContent(path string, want, got any) error {
+	switch w := want.(type) {
+	case map[string]any:
+		g, _ := got.(map[string]any)
+		if g == nil && len(w) > 0 {
+			return fmt.Errorf("%s: esperado um mapa, o tipo gerou %v", pathLabel(path), got)
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case inWant && !inGot && !isZero(wv):
+				return fmt.Errorf("%s: campo não suportado pelo tipo (seria descartado)", joinPath(path, k))
+			case inGot && !inWant && !isZero(gv):
+				return fmt.Errorf("%s: ausente no manifesto, o tipo gerou %v", joinPath(path, k), gv)
+			}
+			if err := sameContent(joinPath(path, k), wv, gv); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		g, _ := got.([]any)
+		if len(w) != len(g) {
+			return fmt.Errorf("%s: %d itens no manifesto, %d depois do round-trip", pathLabel(path), len(w), len(g))
+		}
+		for i := range w {
+			if err := sameContent(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if isZero(want) && isZero(got) {
+		return nil
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("%s: valor %v (%T) virou %v (%T) no round-trip", pathLabel(path), want, want, got, got)
+	}
+	return nil
+}
+
+func isZero(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func pathLabel(path string) string {
+	if path == "" {
+		return "documento"
+	}
+	return path
+}
@@ -0,0 +1,261 @@
+package manifest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/model"
+	"ih-ingestion/internal/templates"
+)
+
+// Valores que quebrariam um manifesto montado por concatenação de texto.
+const (
+	withColon   = "valor: com dois pontos"
+	withHash    = "antes # depois"
+	withQuotes  = `aspas "duplas" e 'simples'`
+	withNewline = "linha 1\nlinha 2: x\n# linha 3"
+	withAll     = "'{x}: [a, b] # c\n- d \"e\"  "
+)
+
+// marshalVerified serializa docs, passa pelo Verify e lê cada documento de volta no tipo de out.
+func marshalVerified(t *testing.T, out []any, docs ...any) {
+	t.Helper()
+	data, err := Marshal(docs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(data); err != nil {
+		t.Fatalf("Verify: %v\n%s", err, data)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for i, o := range out {
+		if err := dec.Decode(o); err != nil {
+			t.Fatalf("documento %d: %v\n%s", i+1, err, data)
+		}
+	}
+}
+
+func checkConfig(t *testing.T, cfg Config, want map[string]any) {
+	t.Helper()
+	for k, v := range want {
+		got, ok := cfg.Get(k)
+		if !ok {
+			t.Errorf("config %s ausente", k)
+			continue
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Errorf("config %s = %#v, esperado %#v", k, got, v)
+		}
+	}
+}
+
+func TestSourceRoundTrip(t *testing.T) {
+	c := model.SourceConfig{
+		Name:                          "source-" + withColon,
+		ClusterName:                   withHash,
+		TasksMax:                      1,
+		DatabaseHost:                  withQuotes,
+		DatabasePort:                  "1433",
+		DatabaseSecret:                withNewline,
+		DatabaseNameUpper:             withAll,
+		TopicPrefix:                   "lz: #1",
+		TopicPartitions:               3,
+		TableIncludeList:              `dbo\.Pedidos,dbo\.Itens$`,
+		ColumnExcludeList:             `DB\.dbo\.Clientes\.Valor\$Bruto`,
+		ColumnMasks:                   map[string]string{"column.mask.with.8.chars": withColon + "," + withHash},
+		SchemaHistoryBootstrapServers: "kafka-0:9092,kafka-1:9092",
+		SchemaHistoryTopic:            withHash,
+		SchemaRegistryURL:             "http://registry:8081/#/" + withQuotes,
+		SnapshotMaxThreads:            5,
+	}
+
+	var got KafkaConnector
+	marshalVerified(t, []any{&got}, Source(c))
+
+	if got.Metadata.Name != c.Name || got.Metadata.Labels["strimzi.io/cluster"] != c.ClusterName {
+		t.Errorf("metadata = %+v", got.Metadata)
+	}
+	if got.Spec.TasksMax != c.TasksMax || got.Spec.AutoRestart == nil || !got.Spec.AutoRestart.Enabled {
+		t.Errorf("spec = %+v", got.Spec)
+	}
+	checkConfig(t, got.Spec.Config, map[string]any{
+		"database.hostname":                 c.DatabaseHost,
+		"database.port":                     c.DatabasePort,
+		"database.user":                     "${secrets:" + c.DatabaseSecret + ":user}",
+		"database.password":                 "${secrets:" + c.DatabaseSecret + ":password}",
+		"database.names":                    c.DatabaseNameUpper,
+		"database.encrypt":                  false,
+		"topic.prefix":                      c.TopicPrefix,
+		"topic.creation.default.partitions": c.TopicPartitions,
+		"table.include.list":                c.TableIncludeList,
+		"column.exclude.list":               c.ColumnExcludeList,
+		"column.mask.with.8.chars":          c.ColumnMasks["column.mask.with.8.chars"],
+		"schema.history.internal.kafka.bootstrap.servers": c.SchemaHistoryBootstrapServers,
+		"schema.history.internal.kafka.topic":             c.SchemaHistoryTopic,
+		"key.converter.schema.registry.url":               c.SchemaRegistryURL,
+		"value.converter.schema.registry.url":             c.SchemaRegistryURL,
+		"snapshot.max.threads":                            c.SnapshotMaxThreads,
+	})
+}
+
+func TestSinkRoundTrip(t *testing.T) {
+	c := model.SinkConfig{
+		Name:                    "sink-" + withHash,
+		ClusterName:             withColon,
+		TasksMax:                3,
+		TopicName:               withAll,
+		SnowflakeURL:            "jdbc:snowflake://conta.snowflakecomputing.com/?db=LZ&warehouse=WH # x",
+		SnowflakeUserSecret:     withQuotes,
+		SnowflakePasswordSecret: withNewline,
+		Stage:                   `"Stage: 1"`,
+		Table:                   withColon,
+		Schema:                  withHash,
+		SchemaRegistryURL:       "http://registry:8081",
+		Buffer:                  map[string]string{"buffer.flush.time": "60"},
+		ExtraConfig:             map[string]string{"behavior.on.null.values": withAll, "errors.tolerance": "all"},
+		ColumnRenames:           "Valor$Bruto:VALOR_BRUTO,Descrição:DESCRICAO",
+	}
+
+	var got KafkaConnector
+	marshalVerified(t, []any{&got}, Sink(c))
+
+	if got.Metadata.Name != c.Name || got.Metadata.Labels["strimzi.io/cluster"] != c.ClusterName || got.Spec.TasksMax != c.TasksMax {
+		t.Errorf("connector = %+v", got)
+	}
+	checkConfig(t, got.Spec.Config, map[string]any{
+		"topics":                              c.TopicName,
+		"url":                                 c.SnowflakeURL,
+		"user":                                "${secrets:" + c.SnowflakeUserSecret + ":username}",
+		"password":                            "${secrets:" + c.SnowflakePasswordSecret + ":password}",
+		"stage":                               c.Stage,
+		"table":                               c.Table,
+		"schema":                              c.Schema,
+		"key.converter.schema.registry.url":   c.SchemaRegistryURL,
+		"value.converter.schema.registry.url": c.SchemaRegistryURL,
+		"buffer.flush.time":                   "60",
+		"behavior.on.null.values":             withAll,
+		"errors.tolerance":                    "all",
+		"transforms.renameColumns.renames":    c.ColumnRenames,
+	})
+}
+
+func TestSnowflakeJobRoundTrip(t *testing.T) {
+	c := model.SnowflakeJobConfig{
+		JobName:           "job: " + withHash,
+		CredentialsSecret: withQuotes,
+		SqlConfigMapName:  withColon,
+		Script: "USE ROLE R;\n-- comentário: com # e 'aspas'\n" +
+			"EXECUTE IMMEDIATE $$\nBEGIN\n  RETURN 'a: b';\nEND;\n$$;\n\n  indentado\t com tab  \n",
+	}
+	j := config.DefaultJobSettings()
+	j.Image = "registry:5000/ih/cli:1.4.0"
+	j.Args = []string{"-file", "/sql/script.sql", "-label", withAll}
+
+	var job Job
+	var cm ConfigMap
+	gotJob, gotCM := SnowflakeJob(c, j)
+	marshalVerified(t, []any{&job, &cm}, gotJob, gotCM)
+
+	if job.Metadata.Name != c.JobName {
+		t.Errorf("job name = %q", job.Metadata.Name)
+	}
+	ctr := job.Spec.Template.Spec.Containers[0]
+	if ctr.Image != j.Image || !reflect.DeepEqual(ctr.Args, j.Args) || !reflect.DeepEqual(ctr.Command, j.Command) {
+		t.Errorf("container = %+v", ctr)
+	}
+	if ctr.EnvFrom[0].SecretRef.Name != c.CredentialsSecret {
+		t.Errorf("envFrom = %+v", ctr.EnvFrom)
+	}
+	if !reflect.DeepEqual(ctr.SecurityContext, j.SecurityContext) || !reflect.DeepEqual(job.Spec.Template.Spec.SecurityContext, j.PodSecurityContext) {
+		t.Errorf("securityContext não sobreviveu ao round-trip")
+	}
+	if job.Spec.Template.Spec.Volumes[0].ConfigMap.Name != c.SqlConfigMapName || cm.Metadata.Name != c.SqlConfigMapName {
+		t.Errorf("configMap = %q / %q", job.Spec.Template.Spec.Volumes[0].ConfigMap.Name, cm.Metadata.Name)
+	}
+	if cm.Data["script.sql"] != c.Script {
+		t.Errorf("script.sql = %q, esperado %q", cm.Data["script.sql"], c.Script)
+	}
+}
+
+// Os templates em texto (base dos overrides) precisam passar pelo mesmo Verify.
+func TestVerifyBuiltinTemplates(t *testing.T) {
+	for _, name := range []string{templates.Source, templates.Sink, templates.Job} {
+		t.Run(name, func(t *testing.T) {
+			data := templates.Sample(name)
+			if job, ok := data.(model.SnowflakeJobConfig); ok {
+				job.Script = "USE ROLE R;\nSELECT 'a: b'; -- #\n"
+				data = job
+			}
+			var buf bytes.Buffer
+			set := templates.Default()
+			var err error
+			switch name {
+			case templates.Source:
+				err = set.Source.Execute(&buf, data)
+			case templates.Sink:
+				err = set.Sink.Execute(&buf, data)
+			case templates.Job:
+				err = set.Job.Execute(&buf, data)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Verify(buf.Bytes()); err != nil {
+				t.Errorf("Verify: %v\n%s", err, buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	const connector = `apiVersion: kafka.strimzi.io/v1beta2
+kind: KafkaConnector
+metadata:
+  name: c
+spec:
+  class: X
+  tasksMax: 1
+  config:
+    topics: t
+`
+	const job = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: j
+spec:
+  template:
+    spec:
+      containers:
+        - name: c
+          image: img:1
+`
+	cases := []struct {
+		name, doc, want string
+	}{
+		{"vazio", "", "manifesto vazio"},
+		{"yaml inválido", "kind: [", "YAML inválido"},
+		{"sem name", "apiVersion: v1\nkind: ConfigMap\nmetadata: {}\n", "metadata.name"},
+		{"campo desconhecido", connector + "  pause: true\n", "spec.pause: campo não suportado"},
+		{"campo aninhado desconhecido", strings.Replace(job, "          image: img:1\n", "          image: img:1\n          resources: {limits: {cpu: 1}}\n", 1), "resources: campo não suportado"},
+		{"valor convertido", strings.Replace(job, "image: img:1", "image: 1.0", 1), "virou"},
+		{"chave duplicada", connector + "    topics: u\n", "duplicada"},
+		{"segundo documento", connector + "---\n" + strings.Replace(job, "kind: Job", "kind: Job\nstatus: {}", 1) + "  selector: {a: b}\n", "documento 2"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify([]byte(tc.doc))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Verify = %v, esperado erro com %q", err, tc.want)
+			}
+		})
+	}
+
+	if err := Verify([]byte(connector + "---\n" + job)); err != nil {
+		t.Errorf("manifesto válido rejeitado: %v", err)
+	}
+}
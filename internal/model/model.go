@@ -1,6 +1,10 @@
 package model
 
-import "database/sql"
+import (
+	"database/sql"
+
+	"ih-ingestion/internal/config"
+)
 
 type ColumnInfo struct {
 	Name             string
@@ -14,20 +18,26 @@ type ColumnInfo struct {
 type SourceConfig struct {
 	Name                          string
 	ClusterName                   string
+	TasksMax                      int
 	DatabaseHost                  string
 	DatabasePort                  string
 	DatabaseSecret                string
 	DatabaseNameUpper             string
 	TopicPrefix                   string
+	TopicPartitions               int // topic.creation.default.partitions (classe de tamanho; 0 = sem topic.creation.*)
 	TableIncludeList              string
+	ColumnExcludeList             string            // column.exclude.list (vazio = omitido)
+	ColumnMasks                   map[string]string // column.mask.* -> colunas
 	SchemaHistoryBootstrapServers string
 	SchemaHistoryTopic            string
 	SchemaRegistryURL             string
+	SnapshotMaxThreads            int
 }
 
 type SinkConfig struct {
 	Name                    string
 	ClusterName             string
+	TasksMax                int
 	TopicName               string
 	SnowflakeURL            string
 	SnowflakeUserSecret     string
@@ -35,17 +45,35 @@ type SinkConfig struct {
 	Stage                   string
 	Table                   string
 	Schema                  string
+	SchemaRegistryURL       string            // schemaRegistry.url do profile (o mesmo do source)
+	Buffer                  map[string]string // buffer/flush da classe de tamanho (sem as chaves de ExtraConfig)
+	ExtraConfig             map[string]string // sinkConfig da tabela (ordenado por chave no template)
+	ColumnRenames           string            // renames do ReplaceField$Value (origem:snowflake,...); vazio = sem transform
 }
 
 type SnowflakeJobConfig struct {
-	JobName             string
-	ConnectionConfigMap string
-	SqlConfigMapName    string
-	Role                string
-	Database            string
-	Schema              string
-	TableIngest         string
-	TableFinal          string
-	StageName           string
-	BusinessColumnsDDL  string
+	JobName           string
+	CredentialsSecret string // envFrom: SNOWFLAKE_ACCOUNT/USER/PASSWORD/WAREHOUSE
+	SqlConfigMapName  string
+
+	// Container (ver config.JobSettings; no template, use toYaml | nindent)
+	Image              string
+	ImagePullPolicy    string
+	Command            []string
+	Args               []string
+	SecurityContext    *config.SecurityContext
+	PodSecurityContext *config.SecurityContext
+
+	Role               string
+	Database           string
+	Schema             string
+	TableIngest        string
+	TableFinal         string
+	StageName          string
+	BusinessColumnsDDL string
+	GovernanceSQL      string // tags e masking policies (vazio = sem classificações)
+	GrantsSQL          string // grants e ownership (vazio = sem grants)
+	OwnerCheckSQL      string // pré-condição do owner antes do DROP (vazio = sem owner)
+
+	Script string // script.sql completo (templates.ScriptTemplate)
 }
@@ -0,0 +1,205 @@
+package promote
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/generator"
+	"ih-ingestion/internal/kustomize"
+	"ih-ingestion/internal/repo"
+	"ih-ingestion/internal/validate"
+)
+
+// Options descreve uma promoção de wave entre dois ambientes.
+type Options struct {
+	Group          string
+	BaseDir        string // apps/ (GitOps) ou out/ (local)
+	SourceProvider string // ex: "debeziumsqlserver"
+	ArgoStyle      bool
+	DryRun         bool
+
+	Config *config.IngestionConfig // opcional: destino Snowflake declarado por alias
+}
+
+// Promote copia os artefatos da wave do ambiente from.Env para to.Env, reescrevendo
+// os campos específicos de ambiente (cluster, JDBC, secrets, logical DB, hosts SQL Server)
+// pelo tipo de cada manifesto (ver rewriteManifest).
+// Os kustomization.yaml de destino são atualizados e um novo manifesto da wave é gravado.
+func Promote(opts Options, from, to config.EnvProfile) (*repo.WaveManifest, error) {
+	srcLayout := repo.NewLayout(opts.BaseDir, from.Env, opts.SourceProvider, from.SnowflakeLogical, opts.ArgoStyle)
+	dstLayout := repo.NewLayout(opts.BaseDir, to.Env, opts.SourceProvider, to.SnowflakeLogical, opts.ArgoStyle)
+
+	manifestPath := srcLayout.WaveManifestPath(opts.Group)
+	m, err := repo.LoadWaveManifest(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("lendo manifesto da wave %s em %s: %w", opts.Group, from.Env, err)
+	}
+
+	rules, err := buildRules(from, to, m.Aliases, m.Clusters, opts.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// landing zones além da do profile: a de destino sai das mesmas regras de troca.
+	// Clusters Kafka Connect adicionais mantêm a chave (pasta clusters/<chave>) nos dois ambientes.
+	type logicalPair struct{ from, to string }
+	zones := []logicalPair{{from.SnowflakeLogical, to.SnowflakeLogical}}
+	var logicals []string
+	for _, l := range m.Logicals {
+		dst := rules.only(fieldLogical).replace(l)
+		logicals = append(logicals, dst)
+		zones = append(zones, logicalPair{l, dst})
+	}
+
+	var layouts [][2]repo.Layout
+	for _, key := range append([]string{""}, m.Clusters...) {
+		for _, lp := range zones {
+			layouts = append(layouts, [2]repo.Layout{
+				repo.NewLayout(opts.BaseDir, from.Env, opts.SourceProvider, lp.from, opts.ArgoStyle).WithCluster(key),
+				repo.NewLayout(opts.BaseDir, to.Env, opts.SourceProvider, lp.to, opts.ArgoStyle).WithCluster(key),
+			})
+		}
+	}
+
+	out := &repo.WaveManifest{
+		Group:            m.Group,
+		Env:              to.Env,
+		SnowflakeLogical: to.SnowflakeLogical,
+		Logicals:         logicals,
+		Clusters:         m.Clusters,
+		Aliases:          m.Aliases,
+		Tables:           m.Tables,
+		Sources:          m.Sources,
+		Topics:           m.Topics,
+		Discovery:        m.Discovery,
+		Sizes:            m.Sizes,
+	}
+
+	// mapeia todos os caminhos antes de gravar qualquer coisa
+	type copyPair struct{ rel, src, dst string }
+	pairs := make([]copyPair, 0, len(m.Files))
+	for _, rel := range m.Files {
+		src := filepath.Join(opts.BaseDir, filepath.FromSlash(rel))
+		dst, err := mapPath(src, layouts)
+		if err != nil {
+			return nil, err
+		}
+		// layout local não separa source/sink por ambiente: copiar sobrescreveria a origem
+		if dst == src {
+			return nil, fmt.Errorf("artefato %s tem o mesmo caminho em %s e %s; o layout não separa ambientes para este artefato", rel, from.Env, to.Env)
+		}
+		pairs = append(pairs, copyPair{rel: rel, src: src, dst: dst})
+	}
+
+	// arquivos por diretório de destino, para atualizar os kustomization.yaml
+	kustomFiles := map[string][]string{}
+
+	for _, p := range pairs {
+		data, err := os.ReadFile(p.src)
+		if err != nil {
+			return nil, fmt.Errorf("lendo artefato %s: %w", p.src, err)
+		}
+		rewritten, err := rules.rewriteManifest(data)
+		if err != nil {
+			return nil, fmt.Errorf("reescrevendo %s: %w", p.src, err)
+		}
+
+		dstRel, err := dstLayout.RelPath(p.dst)
+		if err != nil {
+			return nil, err
+		}
+		out.Files = append(out.Files, dstRel)
+
+		log.Printf("[promote %s→%s] %s -> %s", from.Env, to.Env, p.rel, dstRel)
+
+		if !opts.DryRun {
+			if err := generator.WriteFile(p.dst, rewritten); err != nil {
+				return nil, fmt.Errorf("gravando %s: %w", p.dst, err)
+			}
+		}
+
+		dir := filepath.Dir(p.dst)
+		kustomFiles[dir] = append(kustomFiles[dir], filepath.Base(p.dst))
+	}
+
+	if opts.DryRun {
+		log.Printf("[promote %s→%s] DRY-RUN: %d arquivo(s) NÃO gravados", from.Env, to.Env, len(out.Files))
+		return out, nil
+	}
+
+	dirs := make([]string, 0, len(kustomFiles))
+	for d := range kustomFiles {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	bundleRoots := map[string]bool{} // raízes cujo kustomization foi atualizado
+	for _, dir := range dirs {
+		// namespaces do profile de destino
+		ns := to.JobNamespace
+		for _, pair := range layouts {
+			switch {
+			case isUnder(dir, pair[1].SourceRoot()):
+				ns = to.SourceNamespace
+			case isUnder(dir, pair[1].SinkRoot()):
+				ns = to.SinkNamespace
+			}
+		}
+		if err := kustomize.UpdateKustomization(dir, kustomFiles[dir], ns); err != nil {
+			return nil, fmt.Errorf("atualizando kustomization em %s: %w", dir, err)
+		}
+		if err := kustomize.LinkDir(dir); err != nil {
+			return nil, fmt.Errorf("incluindo %s no kustomization da pasta pai: %w", dir, err)
+		}
+		bundleRoots[filepath.Dir(dir)] = true
+	}
+	// o destino precisa renderizar antes do manifesto da wave (e do push)
+	if err := validate.CheckBundles(bundleRoots); err != nil {
+		return nil, err
+	}
+
+	data, err := out.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("serializando manifesto da wave: %w", err)
+	}
+	if err := generator.WriteFile(dstLayout.WaveManifestPath(opts.Group), data); err != nil {
+		return nil, fmt.Errorf("gravando manifesto da wave: %w", err)
+	}
+
+	return out, nil
+}
+
+// mapPath leva um arquivo de uma raiz do layout de origem para a raiz equivalente no destino.
+// layouts traz pares origem/destino (o do profile primeiro, depois as outras landing zones).
+func mapPath(src string, layouts [][2]repo.Layout) (string, error) {
+	for _, pair := range layouts {
+		srcRoots := pair[0].Roots()
+		dstRoots := pair[1].Roots()
+
+		for i, root := range srcRoots {
+			if !isUnder(src, root) {
+				continue
+			}
+			rel, err := filepath.Rel(root, src)
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(dstRoots[i], rel), nil
+		}
+	}
+
+	return "", fmt.Errorf("artefato %s não está sob nenhuma raiz do layout de %s", src, layouts[0][0].Env)
+}
+
+func isUnder(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
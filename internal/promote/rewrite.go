@@ -0,0 +1,385 @@
+package promote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/yamlnode"
+)
+
+// Campos de ambiente que a promoção troca. Cada campo dos manifestos usa só as regras
+// do seu tipo: um valor de origem que aparece em outro lugar (nome de tabela, comentário,
+// SQL de negócio) nunca é tocado.
+const (
+	fieldCluster        = "cluster"
+	fieldRegistry       = "schema registry"
+	fieldHistory        = "schema history bootstrap"
+	fieldJDBC           = "snowflake jdbc url"
+	fieldUserSecret     = "snowflake user secret"
+	fieldPasswordSecret = "snowflake password secret"
+	fieldLogical        = "snowflake logical"
+	fieldCredsSecret    = "snowflake credentials secret"
+	fieldImage          = "job image"
+	fieldRole           = "snowflake role"
+	fieldDatabase       = "snowflake database"
+	fieldHost           = "sqlserver host"
+	fieldPort           = "sqlserver port"
+)
+
+// rule troca um valor do ambiente de origem pelo equivalente no destino.
+type rule struct {
+	from, to string
+	field    string // um dos field* acima
+	origin   string // de onde veio a regra (mensagens de erro), ex: "cluster vendas"
+}
+
+type ruleSet []rule
+
+// buildRules monta as trocas a partir dos dois perfis. Valores iguais nos dois
+// ambientes são ignorados; o mesmo valor de origem de um campo apontando para destinos
+// diferentes é erro (a troca seria ambígua).
+// cfg (opcional) traz o destino Snowflake declarado por alias em sqlservers[].snowflake.
+// clusters são as chaves de connectCluster usadas na wave (nome do KafkaConnect por ambiente).
+func buildRules(from, to config.EnvProfile, aliases, clusters []string, cfg *config.IngestionConfig) (ruleSet, error) {
+	pairs := []rule{
+		{from.ClusterName, to.ClusterName, fieldCluster, "cluster"},
+		{from.SchemaRegistryURL, to.SchemaRegistryURL, fieldRegistry, "schema registry"},
+		{from.SchemaHistoryBootstrapServers, to.SchemaHistoryBootstrapServers, fieldHistory, "schema history bootstrap"},
+		{from.SnowflakeJDBCURL, to.SnowflakeJDBCURL, fieldJDBC, "snowflake jdbc url"},
+		{from.SnowflakeUserSecret, to.SnowflakeUserSecret, fieldUserSecret, "snowflake user secret"},
+		{from.SnowflakePasswordSecret, to.SnowflakePasswordSecret, fieldPasswordSecret, "snowflake password secret"},
+		{from.SnowflakeLogical, to.SnowflakeLogical, fieldLogical, "snowflake logical"},
+		{from.SnowflakeCredsSecret, to.SnowflakeCredsSecret, fieldCredsSecret, "snowflake credentials secret"},
+		{from.JobImage, to.JobImage, fieldImage, "job image"},
+		{from.SnowflakeRole, to.SnowflakeRole, fieldRole, "snowflake role"},
+		{from.SnowflakeDatabase, to.SnowflakeDatabase, fieldDatabase, "snowflake database"},
+	}
+
+	for _, key := range clusters {
+		src, err := from.ConnectCluster(key)
+		if err != nil {
+			return nil, err
+		}
+		dst, err := to.ConnectCluster(key)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, rule{src.Name, dst.Name, fieldCluster, "cluster " + key})
+	}
+
+	for _, alias := range aliases {
+		srcHost, err := from.SqlServerHost(alias)
+		if err != nil {
+			return nil, fmt.Errorf("host SQL Server do alias %s em %s: %w", alias, from.Env, err)
+		}
+		dstHost, err := to.SqlServerHost(alias)
+		if err != nil {
+			return nil, fmt.Errorf("host SQL Server do alias %s em %s: %w", alias, to.Env, err)
+		}
+		pairs = append(pairs,
+			rule{srcHost, dstHost, fieldHost, "sqlserver host " + alias},
+			rule{from.SqlServerPort(alias), to.SqlServerPort(alias), fieldPort, "sqlserver port " + alias},
+		)
+
+		// destino Snowflake do alias (profiles.<env>.sqlservers.<alias>.snowflake)
+		srv := lookupAlias(cfg, alias)
+		src, dst := from.SnowflakeDest(srv, config.TableEntry{}), to.SnowflakeDest(srv, config.TableEntry{})
+		pairs = append(pairs,
+			rule{src.JDBCURL, dst.JDBCURL, fieldJDBC, "snowflake jdbc url " + alias},
+			rule{src.Database, dst.Database, fieldDatabase, "snowflake database " + alias},
+			rule{src.Logical, dst.Logical, fieldLogical, "snowflake logical " + alias},
+			rule{src.UserSecret, dst.UserSecret, fieldUserSecret, "snowflake user secret " + alias},
+			rule{src.PasswordSecret, dst.PasswordSecret, fieldPasswordSecret, "snowflake password secret " + alias},
+			rule{src.CredentialsSecret, dst.CredentialsSecret, fieldCredsSecret, "snowflake credentials secret " + alias},
+		)
+	}
+
+	seen := map[string]rule{}
+	var rules ruleSet
+	for _, p := range pairs {
+		if p.from == "" || p.from == p.to {
+			continue
+		}
+		key := p.field + "|" + p.from
+		if prev, ok := seen[key]; ok {
+			if prev.to != p.to {
+				return nil, fmt.Errorf("promoção ambígua: %q (%s/%s) vira %q e %q", p.from, prev.origin, p.origin, prev.to, p.to)
+			}
+			continue
+		}
+		seen[key] = p
+		rules = append(rules, p)
+	}
+
+	// mais longos primeiro: numa troca dentro de texto, o valor inteiro ganha de um prefixo
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].from) > len(rules[j].from) })
+
+	return rules, nil
+}
+
+func lookupAlias(cfg *config.IngestionConfig, alias string) config.SqlServerEntry {
+	if cfg != nil {
+		for _, srv := range cfg.SqlServers {
+			if strings.EqualFold(srv.Alias, alias) {
+				return srv
+			}
+		}
+	}
+	return config.SqlServerEntry{Alias: alias}
+}
+
+// only devolve as regras dos campos informados.
+func (rs ruleSet) only(fields ...string) ruleSet {
+	var out ruleSet
+	for _, r := range rs {
+		for _, f := range fields {
+			if r.field == f {
+				out = append(out, r)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// replace troca s quando ele é exatamente um valor de origem.
+func (rs ruleSet) replace(s string) string {
+	for _, r := range rs {
+		if s == r.from {
+			return r.to
+		}
+	}
+	return s
+}
+
+// apply troca, em uma única passada, cada ocorrência "inteira" de um valor de origem
+// (não colada em letras, dígitos ou "_"). Uma troca nunca é reprocessada por outra.
+// Usado só no nome do sink, com as regras do logical.
+func (rs ruleSet) apply(s string) string {
+	if len(rs) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		matched := false
+		for _, r := range rs {
+			if !strings.HasPrefix(s[i:], r.from) {
+				continue
+			}
+			end := i + len(r.from)
+			if (i > 0 && isWordByte(s[i-1])) || (end < len(s) && isWordByte(s[end])) {
+				continue
+			}
+			b.WriteString(r.to)
+			i = end
+			matched = true
+			break
+		}
+		if !matched {
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// rewriteManifest troca os campos de ambiente de um artefato gerado, documento a documento,
+// pelo kind. A árvore YAML é editada no lugar: ordem, comentários e os demais valores
+// ficam como estavam. Documentos de outros kinds são copiados sem alteração.
+func (rs ruleSet) rewriteManifest(data []byte) ([]byte, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var n yaml.Node
+		if err := dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("YAML inválido: %w", err)
+		}
+		docs = append(docs, &n)
+	}
+
+	for _, d := range docs {
+		if len(d.Content) == 0 || d.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := d.Content[0]
+		switch kind := yamlnode.Scalar(root, "kind"); kind {
+		case "KafkaConnector":
+			rs.rewriteConnector(root)
+		case "Job":
+			rs.rewriteJob(root)
+		case "ConfigMap":
+			if script := yamlnode.Lookup(root, "data", "script.sql"); script != nil {
+				script.Value = rs.rewriteScript(script.Value)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	for _, d := range docs {
+		if err := enc.Encode(d); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// sqlIdent é um identificador Snowflake sem aspas ou entre aspas duplas.
+const sqlIdent = `("[^"]+"|[A-Za-z_][A-Za-z0-9_$]*)`
+
+// scriptTargets são os pontos do script.sql gerado onde o role e o database do ambiente
+// aparecem; o grupo 2 de cada expressão é o identificador trocado. Schema, tabelas,
+// colunas e tags nunca são tocados, mesmo com o nome igual ao do database ou do role.
+var scriptTargets = []struct {
+	re    *regexp.Regexp
+	field string
+}{
+	{regexp.MustCompile(`(?im)^(\s*USE\s+ROLE\s+)` + sqlIdent), fieldRole},
+	{regexp.MustCompile(`(?im)^(\s*USE\s+DATABASE\s+)` + sqlIdent), fieldDatabase},
+	{regexp.MustCompile(`(?i)(\bON\s+DATABASE\s+)` + sqlIdent), fieldDatabase},
+	// <db>.<schema> nos GRANTs de schema (governance.BuildGrantsSQL)
+	{regexp.MustCompile(`(?i)(\bSCHEMA\s+)` + sqlIdent + `\.`), fieldDatabase},
+	// mensagem da pré-condição do owner (governance.BuildOwnerCheckSQL)
+	{regexp.MustCompile(`('role )` + sqlIdent + ` não herda`), fieldRole},
+	{regexp.MustCompile(`(GRANT ROLE \S+ TO ROLE )` + sqlIdent + `'\)`), fieldRole},
+}
+
+// rewriteScript troca role e database do script.sql só nos scriptTargets.
+func (rs ruleSet) rewriteScript(script string) string {
+	for _, t := range scriptTargets {
+		rules := rs.only(t.field)
+		if len(rules) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range t.re.FindAllStringSubmatchIndex(script, -1) {
+			b.WriteString(script[last:m[4]])
+			b.WriteString(rules.replace(script[m[4]:m[5]]))
+			last = m[5]
+		}
+		b.WriteString(script[last:])
+		script = b.String()
+	}
+	return script
+}
+
+// connectorFields são as chaves do spec.config com valor de ambiente (valor inteiro).
+var connectorFields = map[string]string{
+	"database.hostname": fieldHost,
+	"database.port":     fieldPort,
+	"schema.history.internal.kafka.bootstrap.servers": fieldHistory,
+	"key.converter.schema.registry.url":               fieldRegistry,
+	"value.converter.schema.registry.url":             fieldRegistry,
+}
+
+func (rs ruleSet) rewriteConnector(root *yaml.Node) {
+	if n := yamlnode.Lookup(root, "metadata", "labels", "strimzi.io/cluster"); n != nil {
+		n.Value = rs.only(fieldCluster).replace(n.Value)
+	}
+	// sink-jdbcsnowflake-<logical>-...: a landing zone faz parte do nome do sink
+	if n := yamlnode.Lookup(root, "metadata", "name"); n != nil && strings.HasPrefix(n.Value, "sink-") {
+		n.Value = rs.only(fieldLogical).apply(n.Value)
+	}
+
+	cfg := yamlnode.Lookup(root, "spec", "config")
+	if cfg == nil || cfg.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(cfg.Content); i += 2 {
+		key, val := cfg.Content[i].Value, cfg.Content[i+1]
+		if val.Kind != yaml.ScalarNode {
+			continue
+		}
+		if field, ok := connectorFields[key]; ok {
+			val.Value = rs.only(field).replace(val.Value)
+			continue
+		}
+		switch key {
+		case "url":
+			val.Value = rs.replaceJDBC(val.Value)
+		case "user":
+			val.Value = rs.only(fieldUserSecret).replaceSecretRef(val.Value)
+		case "password":
+			val.Value = rs.only(fieldPasswordSecret).replaceSecretRef(val.Value)
+		}
+	}
+}
+
+func (rs ruleSet) rewriteJob(root *yaml.Node) {
+	containers := yamlnode.Lookup(root, "spec", "template", "spec", "containers")
+	if containers == nil || containers.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, c := range containers.Content {
+		if n := yamlnode.Lookup(c, "image"); n != nil {
+			n.Value = rs.only(fieldImage).replace(n.Value)
+		}
+		if envFrom := yamlnode.Lookup(c, "envFrom"); envFrom != nil && envFrom.Kind == yaml.SequenceNode {
+			for _, e := range envFrom.Content {
+				if n := yamlnode.Lookup(e, "secretRef", "name"); n != nil {
+					n.Value = rs.only(fieldCredsSecret).replace(n.Value)
+				}
+			}
+		}
+	}
+}
+
+// replaceJDBC troca a URL JDBC do sink. Uma tabela com snowflake.database próprio tem a
+// URL do profile com outro db=: a troca usa a URL de destino com o database equivalente.
+func (rs ruleSet) replaceJDBC(s string) string {
+	jdbc := rs.only(fieldJDBC)
+	if out := jdbc.replace(s); out != s {
+		return out
+	}
+	i := strings.Index(s, "?")
+	if i < 0 {
+		return s
+	}
+	q, err := url.ParseQuery(s[i+1:])
+	if err != nil || q.Get("db") == "" {
+		return s
+	}
+	db := q.Get("db")
+	for _, r := range jdbc {
+		if config.WithJDBCDatabase(r.from, db) == s {
+			return config.WithJDBCDatabase(r.to, rs.only(fieldDatabase).replace(db))
+		}
+	}
+	return s
+}
+
+// replaceSecretRef troca o nome do Secret em ${secrets:<nome>:<chave>}.
+func (rs ruleSet) replaceSecretRef(s string) string {
+	const prefix = "${secrets:"
+	if !strings.HasPrefix(s, prefix) || !strings.HasSuffix(s, "}") {
+		return s
+	}
+	ref := s[len(prefix) : len(s)-1]
+	i := strings.LastIndex(ref, ":")
+	if i < 0 {
+		return s
+	}
+	return prefix + rs.replace(ref[:i]) + ref[i:] + "}"
+}
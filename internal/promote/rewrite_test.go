@@ -0,0 +1,106 @@
+package promote
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/governance"
+	"ih-ingestion/internal/manifest"
+	"ih-ingestion/internal/model"
+	"ih-ingestion/internal/templates"
+)
+
+// O targetSchema padrão é o nome do banco SQL Server em maiúsculas: com o database
+// Snowflake de mesmo nome, só o database pode mudar na promoção, nunca o schema.
+func TestRewriteScriptSchemaEqualsDatabase(t *testing.T) {
+	rules := ruleSet{
+		{from: "VENDAS", to: "VENDAS_PRD", field: fieldDatabase},
+		{from: "INGESTION_DEV", to: "INGESTION_PRD", field: fieldRole},
+	}
+
+	grants := config.Grants{Readers: []string{"ANALISTA"}, Owner: "DONO"}
+	objs := governance.Objects{Database: "VENDAS", Schema: "VENDAS", IngestTable: "PEDIDOS_INGEST", FinalTable: "PEDIDOS", Stage: "PEDIDOS"}
+	c := model.SnowflakeJobConfig{
+		JobName:            "lz-sql-ih-vendas-pedidos-v1",
+		CredentialsSecret:  "snowflake-credentials",
+		SqlConfigMapName:   "lz-sql-ih-vendas-pedidos-sql",
+		Role:               "INGESTION_DEV",
+		Database:           "VENDAS",
+		Schema:             "VENDAS",
+		TableIngest:        "PEDIDOS_INGEST",
+		TableFinal:         "PEDIDOS",
+		StageName:          "PEDIDOS",
+		BusinessColumnsDDL: "  VENDAS NUMBER(10,0),\n  INGESTION_DEV VARCHAR(10),\n",
+		GovernanceSQL:      "ALTER TABLE PEDIDOS MODIFY COLUMN VENDAS SET TAG CLASSIFICACAO = 'VENDAS';\n",
+		GrantsSQL:          governance.BuildGrantsSQL(objs, grants, config.Grants{}),
+		OwnerCheckSQL:      governance.BuildOwnerCheckSQL("INGESTION_DEV", grants, config.Grants{}),
+	}
+	var script bytes.Buffer
+	if err := templates.ScriptTemplate.Execute(&script, c); err != nil {
+		t.Fatal(err)
+	}
+	c.Script = script.String()
+
+	j := config.DefaultJobSettings()
+	j.Image = "registry:5000/ih/cli:1.4.0"
+	job, cm := manifest.SnowflakeJob(c, j)
+	sink := manifest.Sink(model.SinkConfig{
+		Name:         "sink-jdbcsnowflake-lz-vendas-pedidos-online-m-v1",
+		ClusterName:  "connect",
+		TasksMax:     1,
+		TopicName:    "t",
+		SnowflakeURL: "jdbc:snowflake://conta/?db=VENDAS",
+		Table:        "PEDIDOS",
+		Schema:       "VENDAS",
+	})
+	data, err := manifest.Marshal(sink, job, cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := rules.rewriteManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotSink manifest.KafkaConnector
+	var gotJob manifest.Job
+	var gotCM manifest.ConfigMap
+	dec := yaml.NewDecoder(bytes.NewReader(out))
+	for _, d := range []any{&gotSink, &gotJob, &gotCM} {
+		if err := dec.Decode(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if v, _ := gotSink.Spec.Config.Get("schema"); v != "VENDAS" {
+		t.Errorf("schema do sink = %v, esperado VENDAS", v)
+	}
+	got := gotCM.Data["script.sql"]
+	for _, want := range []string{
+		"USE ROLE INGESTION_PRD;",
+		"USE DATABASE VENDAS_PRD;",
+		"CREATE SCHEMA IF NOT EXISTS VENDAS;",
+		"USE SCHEMA VENDAS;",
+		"  VENDAS NUMBER(10,0),\n  INGESTION_DEV VARCHAR(10),",
+		"MODIFY COLUMN VENDAS SET TAG CLASSIFICACAO = 'VENDAS';",
+		"GRANT USAGE ON DATABASE VENDAS_PRD TO ROLE ANALISTA;",
+		"GRANT USAGE ON SCHEMA VENDAS_PRD.VENDAS TO ROLE ANALISTA;",
+		"'role INGESTION_PRD não herda o owner DONO: rode uma vez GRANT ROLE DONO TO ROLE INGESTION_PRD'",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("script.sql sem %q:\n%s", want, got)
+		}
+	}
+	// fora dos pontos de role/database, o script é o mesmo
+	back := ruleSet{
+		{from: "VENDAS_PRD", to: "VENDAS", field: fieldDatabase},
+		{from: "INGESTION_PRD", to: "INGESTION_DEV", field: fieldRole},
+	}
+	if restored := back.rewriteScript(got); restored != c.Script {
+		t.Errorf("script promovido e revertido difere do original:\n%s", restored)
+	}
+}
@@ -14,6 +14,7 @@ type Layout struct {
 	SourceProvider   string // ex: "debeziumsqlserver"
 	SnowflakeLogical string // ex: "lz-sql-ih-prd"
 	ArgoStyle        bool   // true = estrutura Argo real; false = estrutura local/out
+	Cluster          string // chave do cluster Kafka Connect ("" = cluster default)
 }
 
 // baseDir: caminho absoluto da base (apps ou out)
@@ -31,8 +32,36 @@ func NewLayout(baseDir, env, sourceProvider, snowflakeLogical string, argoStyle
 	}
 }
 
+// WithCluster devolve o layout dos connectors de um cluster Kafka Connect adicional.
+// O cluster default ("") mantém os caminhos sem o segmento clusters/<chave>.
+func (l Layout) WithCluster(key string) Layout {
+	l.Cluster = key
+	return l
+}
+
+// strimziBase é a base dos connectors (source/sink) do cluster do layout.
+func (l Layout) strimziBase() string {
+	base := l.BaseDir
+	if l.ArgoStyle {
+		base = filepath.Join(l.BaseDir, "strimzi", "envs", l.Env)
+	}
+	if l.Cluster != "" {
+		base = filepath.Join(base, "clusters", l.Cluster)
+	}
+	return base
+}
+
 // ==== ROOTS (ponto de ancoragem) ====
 
+// ConnectorRoots são as pastas com todos os KafkaConnector do cluster do layout
+// (source e sink de todas as landing zones), usadas no controle de capacidade.
+func (l Layout) ConnectorRoots() []string {
+	return []string{
+		filepath.Join(l.strimziBase(), "source"),
+		filepath.Join(l.strimziBase(), "sink"),
+	}
+}
+
 // SourceRoot:
 //
 //   - Modo GitOps (ArgoStyle=true):
@@ -40,20 +69,12 @@ func NewLayout(baseDir, env, sourceProvider, snowflakeLogical string, argoStyle
 //
 //   - Modo local/out (ArgoStyle=false):
 //     out/source/debeziumsqlserver
+//
+// Em um cluster adicional, clusters/<chave> entra antes de source
+// (ex: apps/strimzi/envs/<env>/clusters/<chave>/source/debeziumsqlserver).
 func (l Layout) SourceRoot() string {
-	if l.ArgoStyle {
-		return filepath.Join(
-			l.BaseDir,
-			"strimzi",
-			"envs",
-			l.Env,
-			"source",
-			l.SourceProvider,
-		)
-	}
-
 	return filepath.Join(
-		l.BaseDir,
+		l.strimziBase(),
 		"source",
 		l.SourceProvider,
 	)
@@ -66,21 +87,11 @@ func (l Layout) SourceRoot() string {
 //
 //   - Local:
 //     out/sink/jdbcsnowflake/<lz-sql-ih-prd>
+//
+// Em um cluster adicional, clusters/<chave> entra antes de sink.
 func (l Layout) SinkRoot() string {
-	if l.ArgoStyle {
-		return filepath.Join(
-			l.BaseDir,
-			"strimzi",
-			"envs",
-			l.Env,
-			"sink",
-			"jdbcsnowflake",
-			l.SnowflakeLogical,
-		)
-	}
-
 	return filepath.Join(
-		l.BaseDir,
+		l.strimziBase(),
 		"sink",
 		"jdbcsnowflake",
 		l.SnowflakeLogical,
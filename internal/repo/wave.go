@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WaveManifest registra o que uma wave gerou em um ambiente.
+// É gravado junto dos artefatos e usado pelo comando promote.
+type WaveManifest struct {
+	Group            string   `yaml:"group"`
+	Env              string   `yaml:"env"`
+	SnowflakeLogical string   `yaml:"snowflakeLogical"`
+	Logicals         []string `yaml:"logicals,omitempty"` // outras landing zones usadas (snowflake.logical por alias/tabela)
+	Clusters         []string `yaml:"clusters,omitempty"` // clusters Kafka Connect adicionais usados (connectCluster)
+	Aliases          []string `yaml:"aliases,omitempty"`
+	Tables           []string `yaml:"tables,omitempty"`
+	Sources          []string `yaml:"sources,omitempty"`
+	Topics           []string `yaml:"topics,omitempty"`
+	Files            []string `yaml:"files"` // relativos a BaseDir, com "/"
+
+	// Regras include/exclude e as tabelas que resolveram nesta geração
+	Discovery []DiscoveryRecord `yaml:"discovery,omitempty"`
+
+	// Classe (p/m/g) de cada tabela (alias:schema.tabela). A classe entra no nome dos
+	// arquivos, connectors e tópico: a próxima geração reusa a registrada aqui em vez de
+	// reclassificar pelo sizing.auto.
+	Sizes map[string]string `yaml:"sizes,omitempty"`
+}
+
+// DiscoveryRecord registra uma regra de descoberta de tabelas de um alias.
+type DiscoveryRecord struct {
+	Alias   string   `yaml:"alias"`
+	Schema  string   `yaml:"schema"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude,omitempty"`
+	CDCOnly bool     `yaml:"cdcOnly,omitempty"`
+	Tables  []string `yaml:"tables"`
+}
+
+// WaveManifestPath:
+//
+//	<BaseDir>/ingestion-waves/<env>/<group>.yaml
+func (l Layout) WaveManifestPath(group string) string {
+	return filepath.Join(
+		l.BaseDir,
+		"ingestion-waves",
+		l.Env,
+		group+".yaml",
+	)
+}
+
+// LoadWaveManifest lê o manifesto de uma wave.
+func LoadWaveManifest(path string) (*WaveManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m WaveManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("falha ao parsear %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Bytes serializa o manifesto em YAML.
+func (m *WaveManifest) Bytes() ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// RelPath converte um caminho absoluto sob BaseDir no formato usado em Files.
+func (l Layout) RelPath(path string) (string, error) {
+	rel, err := filepath.Rel(l.BaseDir, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Roots retorna as raízes de source, sink e job do layout.
+func (l Layout) Roots() []string {
+	return []string{l.SourceRoot(), l.SinkRoot(), l.JobRoot()}
+}
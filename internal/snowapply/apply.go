@@ -0,0 +1,197 @@
+package snowapply
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Modos de execução do script
+const (
+	// ModeIdempotent executa comando a comando em autocommit. Erro de objeto já
+	// existente é registrado como "skipped"; qualquer outro erro interrompe.
+	ModeIdempotent = "idempotent"
+	// ModeTransactional executa tudo numa transação e faz rollback no primeiro erro.
+	// Só aceita DML: no Snowflake cada DDL faz commit implícito (encerra a transação
+	// aberta e não volta no rollback), então script com DDL é recusado antes de executar.
+	// O script.sql gerado para o job é DDL e roda sempre no modo idempotent.
+	ModeTransactional = "transactional"
+)
+
+// Status de cada comando no resultado
+const (
+	StatusOK      = "ok"
+	StatusSkipped = "skipped"
+	StatusFailed  = "failed"
+	StatusNotRun  = "not_run"
+)
+
+// Options controla a execução do script.
+type Options struct {
+	Mode   string
+	DryRun bool // só separa e lista os comandos, sem executar
+}
+
+// StatementResult é o resultado de um comando.
+type StatementResult struct {
+	Index        int    `json:"index"`
+	Line         int    `json:"line"`
+	SQL          string `json:"sql"`
+	Status       string `json:"status"`
+	RowsAffected int64  `json:"rowsAffected,omitempty"`
+	DurationMs   int64  `json:"durationMs"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Result é o resumo da execução (serializado em JSON pelo snowflake-apply).
+type Result struct {
+	Mode       string            `json:"mode"`
+	DryRun     bool              `json:"dryRun,omitempty"`
+	OK         bool              `json:"ok"`
+	Error      string            `json:"error,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	DurationMs int64             `json:"durationMs"`
+	Executed   int               `json:"executed"`
+	Skipped    int               `json:"skipped"`
+	Statements []StatementResult `json:"statements"`
+}
+
+// execer é o que as duas formas de execução (conexão única ou transação) têm em comum.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Apply executa o script no banco. Todos os comandos rodam na mesma sessão
+// (USE ROLE/USE DATABASE valem para os seguintes). O erro retornado é o do
+// primeiro comando que falhou; o Result sempre vem preenchido.
+func Apply(ctx context.Context, db *sql.DB, script string, opts Options) (*Result, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeIdempotent
+	}
+	if opts.Mode != ModeIdempotent && opts.Mode != ModeTransactional {
+		return nil, fmt.Errorf("modo inválido: %s (use %s ou %s)", opts.Mode, ModeIdempotent, ModeTransactional)
+	}
+
+	stmts := SplitStatements(script)
+	res := &Result{Mode: opts.Mode, DryRun: opts.DryRun, StartedAt: time.Now().UTC()}
+	for i, s := range stmts {
+		res.Statements = append(res.Statements, StatementResult{Index: i + 1, Line: s.Line, SQL: s.SQL, Status: StatusNotRun})
+	}
+	defer func() { res.DurationMs = time.Since(res.StartedAt).Milliseconds() }()
+
+	if len(stmts) == 0 {
+		return res, fail(res, fmt.Errorf("script sem comandos"))
+	}
+	if opts.Mode == ModeTransactional {
+		for _, s := range res.Statements {
+			if isDDL(s.SQL) {
+				return res, fail(res, fmt.Errorf("comando #%d (linha %d) é DDL: no Snowflake DDL faz commit implícito e o modo %s não consegue desfazê-lo (use %s)",
+					s.Index, s.Line, ModeTransactional, ModeIdempotent))
+			}
+		}
+	}
+	if opts.DryRun {
+		for _, s := range res.Statements {
+			log.Printf("[snowflake-apply] DRY-RUN #%d (linha %d): %s", s.Index, s.Line, firstLine(s.SQL))
+		}
+		res.OK = true
+		return res, nil
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return res, fail(res, fmt.Errorf("abrindo sessão: %w", err))
+	}
+	defer conn.Close()
+
+	var target execer = conn
+	var tx *sql.Tx
+	if opts.Mode == ModeTransactional {
+		if tx, err = conn.BeginTx(ctx, nil); err != nil {
+			return res, fail(res, fmt.Errorf("iniciando transação: %w", err))
+		}
+		target = tx
+	}
+
+	for i := range res.Statements {
+		sr := &res.Statements[i]
+		start := time.Now()
+		r, err := target.ExecContext(ctx, sr.SQL)
+		sr.DurationMs = time.Since(start).Milliseconds()
+
+		if err != nil {
+			if opts.Mode == ModeIdempotent && isAlreadyApplied(err) {
+				sr.Status = StatusSkipped
+				sr.Error = err.Error()
+				res.Skipped++
+				log.Printf("[snowflake-apply] #%d (linha %d) ignorado: %v", sr.Index, sr.Line, err)
+				continue
+			}
+			sr.Status = StatusFailed
+			sr.Error = err.Error()
+			log.Printf("[snowflake-apply] #%d (linha %d) FALHOU: %v", sr.Index, sr.Line, err)
+			if tx != nil {
+				if rbErr := tx.Rollback(); rbErr != nil {
+					log.Printf("[snowflake-apply] rollback falhou: %v", rbErr)
+				}
+			}
+			return res, fail(res, fmt.Errorf("comando #%d (linha %d): %w", sr.Index, sr.Line, err))
+		}
+
+		sr.Status = StatusOK
+		if n, err := r.RowsAffected(); err == nil {
+			sr.RowsAffected = n
+		}
+		res.Executed++
+		log.Printf("[snowflake-apply] #%d (linha %d) ok em %dms: %s", sr.Index, sr.Line, sr.DurationMs, firstLine(sr.SQL))
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return res, fail(res, fmt.Errorf("commit: %w", err))
+		}
+	}
+
+	res.OK = true
+	return res, nil
+}
+
+func fail(res *Result, err error) error {
+	res.OK = false
+	res.Error = err.Error()
+	return err
+}
+
+// isAlreadyApplied reconhece erro de objeto já existente (ex: CREATE sem IF NOT EXISTS).
+func isAlreadyApplied(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "already exists")
+}
+
+// ddlKeywords são os comandos que fazem commit implícito no Snowflake. EXECUTE IMMEDIATE
+// entra junto porque o bloco pode conter DDL.
+var ddlKeywords = map[string]bool{
+	"CREATE": true, "ALTER": true, "DROP": true, "UNDROP": true,
+	"COMMENT": true, "GRANT": true, "REVOKE": true, "EXECUTE": true,
+}
+
+func isDDL(sql string) bool {
+	word, _, _ := strings.Cut(firstLine(sql), " ")
+	return ddlKeywords[strings.ToUpper(word)]
+}
+
+// firstLine resume o comando para o log (primeira linha de código, sem comentários).
+func firstLine(sql string) string {
+	for _, l := range strings.Split(sql, "\n") {
+		l = strings.TrimSpace(l)
+		if l != "" && !strings.HasPrefix(l, "--") {
+			if len(l) > 120 {
+				return l[:117] + "..."
+			}
+			return l
+		}
+	}
+	return ""
+}
@@ -0,0 +1,305 @@
+package snowapply
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubDriver é um driver database/sql em memória: registra os comandos recebidos e
+// falha os que contêm alguma chave de failOn.
+type stubDriver struct {
+	mu     sync.Mutex
+	failOn map[string]error
+	log    []string // comandos, BEGIN, COMMIT e ROLLBACK na ordem
+}
+
+func (d *stubDriver) Open(string) (driver.Conn, error) { return &stubConn{d: d}, nil }
+
+func (d *stubDriver) record(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, s)
+}
+
+func (d *stubDriver) calls() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.log...)
+}
+
+type stubConn struct{ d *stubDriver }
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare não suportado")
+}
+func (c *stubConn) Close() error { return nil }
+func (c *stubConn) Begin() (driver.Tx, error) {
+	c.d.record("BEGIN")
+	return stubTx{c.d}, nil
+}
+
+func (c *stubConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+	for k, err := range c.d.failOn {
+		if strings.Contains(query, k) {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type stubTx struct{ d *stubDriver }
+
+func (t stubTx) Commit() error   { t.d.record("COMMIT"); return nil }
+func (t stubTx) Rollback() error { t.d.record("ROLLBACK"); return nil }
+
+var (
+	registerOnce sync.Once
+	current      *stubDriver
+	currentMu    sync.Mutex
+)
+
+// stubRouter encaminha para o stub do teste corrente (sql.Register só aceita um nome por driver).
+type stubRouter struct{}
+
+func (stubRouter) Open(name string) (driver.Conn, error) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	return current.Open(name)
+}
+
+func openStub(t *testing.T, failOn map[string]error) (*sql.DB, *stubDriver) {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register("snowapply-stub", stubRouter{}) })
+	d := &stubDriver{failOn: failOn}
+	currentMu.Lock()
+	current = d
+	currentMu.Unlock()
+
+	db, err := sql.Open("snowapply-stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+const ddlScript = `USE ROLE INGESTION_ROLE;
+USE DATABASE LZ;
+
+-- comentário com ; no meio
+CREATE TABLE IF NOT EXISTS PEDIDOS (ID NUMBER);
+CREATE TAG PII;
+GRANT SELECT ON TABLE PEDIDOS TO ROLE BI_READER;
+`
+
+func statuses(res *Result) []string {
+	out := make([]string, len(res.Statements))
+	for i, s := range res.Statements {
+		out[i] = s.Status
+	}
+	return out
+}
+
+func equal(t *testing.T, field string, got, want []string) {
+	t.Helper()
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("%s = %q, esperado %q", field, got, want)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `USE ROLE R; -- fim; de linha
+/* bloco;
+comentado */
+INSERT INTO T VALUES ('a;b', 'it''s', 'c\';d');
+SELECT "col;umn" FROM T;
+EXECUTE IMMEDIATE $$
+BEGIN
+  RETURN 1;
+END;
+$$;
+-- só comentário;
+;
+SELECT 2`
+	got := SplitStatements(script)
+	want := []Statement{
+		{Line: 1, SQL: "USE ROLE R"},
+		{Line: 4, SQL: `INSERT INTO T VALUES ('a;b', 'it''s', 'c\';d')`},
+		{Line: 5, SQL: `SELECT "col;umn" FROM T`},
+		{Line: 6, SQL: "EXECUTE IMMEDIATE $$\nBEGIN\n  RETURN 1;\nEND;\n$$"},
+		{Line: 13, SQL: "SELECT 2"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("esperado %d comandos, veio %d: %q", len(want), len(got), got)
+	}
+	for i := range want {
+		// comentários anteriores ao código ficam no texto do comando
+		if got[i].Line != want[i].Line || !strings.HasSuffix(got[i].SQL, want[i].SQL) {
+			t.Errorf("comando %d = linha %d %q, esperado linha %d %q", i, got[i].Line, got[i].SQL, want[i].Line, want[i].SQL)
+		}
+	}
+}
+
+func TestApplyIdempotent(t *testing.T) {
+	db, d := openStub(t, map[string]error{"CREATE TAG": errors.New("SQL compilation error: Object 'PII' already exists.")})
+
+	res, err := Apply(context.Background(), db, ddlScript, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK || res.Mode != ModeIdempotent || res.Executed != 4 || res.Skipped != 1 {
+		t.Errorf("resultado = %+v", res)
+	}
+	equal(t, "status", statuses(res), []string{StatusOK, StatusOK, StatusOK, StatusSkipped, StatusOK})
+	if !strings.Contains(res.Statements[3].Error, "already exists") {
+		t.Errorf("erro do skipped = %q", res.Statements[3].Error)
+	}
+	if res.Statements[2].Line != 5 {
+		t.Errorf("linha do CREATE TABLE = %d", res.Statements[2].Line)
+	}
+	for _, c := range d.calls() {
+		if c == "BEGIN" || c == "COMMIT" {
+			t.Errorf("modo idempotent não abre transação: %v", d.calls())
+		}
+	}
+}
+
+func TestApplyIdempotentStopsOnError(t *testing.T) {
+	db, d := openStub(t, map[string]error{"CREATE TABLE": errors.New("Insufficient privileges to operate on schema 'VENDAS'")})
+
+	res, err := Apply(context.Background(), db, ddlScript, Options{})
+	if err == nil || !strings.Contains(err.Error(), "comando #3 (linha 5)") {
+		t.Fatalf("erro = %v", err)
+	}
+	if res.OK || res.Error != err.Error() || res.Executed != 2 {
+		t.Errorf("resultado = %+v", res)
+	}
+	equal(t, "status", statuses(res), []string{StatusOK, StatusOK, StatusFailed, StatusNotRun, StatusNotRun})
+	if n := len(d.calls()); n != 3 {
+		t.Errorf("comandos depois da falha foram executados: %v", d.calls())
+	}
+}
+
+const dmlScript = `INSERT INTO PEDIDOS SELECT * FROM PEDIDOS_INGEST;
+DELETE FROM PEDIDOS_INGEST;
+UPDATE CONTROLE SET ULTIMA_CARGA = CURRENT_TIMESTAMP();`
+
+func TestApplyTransactionalCommit(t *testing.T) {
+	db, d := openStub(t, nil)
+
+	res, err := Apply(context.Background(), db, dmlScript, Options{Mode: ModeTransactional})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK || res.Executed != 3 || res.Statements[0].RowsAffected != 1 {
+		t.Errorf("resultado = %+v", res)
+	}
+	calls := d.calls()
+	if calls[0] != "BEGIN" || calls[len(calls)-1] != "COMMIT" || len(calls) != 5 {
+		t.Errorf("chamadas = %q", calls)
+	}
+}
+
+func TestApplyTransactionalRollback(t *testing.T) {
+	db, d := openStub(t, map[string]error{"DELETE": errors.New("statement timeout")})
+
+	res, err := Apply(context.Background(), db, dmlScript, Options{Mode: ModeTransactional})
+	if err == nil {
+		t.Fatal("esperado erro")
+	}
+	equal(t, "status", statuses(res), []string{StatusOK, StatusFailed, StatusNotRun})
+	calls := d.calls()
+	if calls[len(calls)-1] != "ROLLBACK" {
+		t.Errorf("esperado rollback, chamadas = %q", calls)
+	}
+	for _, c := range calls {
+		if c == "COMMIT" {
+			t.Errorf("não pode haver commit: %q", calls)
+		}
+	}
+}
+
+func TestApplyTransactionalRejectsDDL(t *testing.T) {
+	db, d := openStub(t, nil)
+
+	res, err := Apply(context.Background(), db, ddlScript, Options{Mode: ModeTransactional})
+	if err == nil || !strings.Contains(err.Error(), "comando #3 (linha 5) é DDL") {
+		t.Fatalf("erro = %v", err)
+	}
+	if len(d.calls()) != 0 {
+		t.Errorf("nada deveria ser executado: %q", d.calls())
+	}
+	equal(t, "status", statuses(res), []string{StatusNotRun, StatusNotRun, StatusNotRun, StatusNotRun, StatusNotRun})
+}
+
+func TestApplyDryRun(t *testing.T) {
+	res, err := Apply(context.Background(), nil, ddlScript, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK || !res.DryRun || len(res.Statements) != 5 || res.Executed != 0 {
+		t.Errorf("resultado = %+v", res)
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	if _, err := Apply(context.Background(), nil, ddlScript, Options{Mode: "batch"}); err == nil {
+		t.Error("modo inválido deveria falhar")
+	}
+	res, err := Apply(context.Background(), nil, "-- só comentário\n", Options{DryRun: true})
+	if err == nil || res.OK {
+		t.Errorf("script vazio deveria falhar: %v", err)
+	}
+}
+
+// O JSON é o contrato com quem lê o resultado do job.
+func TestResultJSON(t *testing.T) {
+	db, _ := openStub(t, map[string]error{
+		"CREATE TAG": errors.New("Object 'PII' already exists."),
+		"GRANT":      errors.New("Role 'BI_READER' does not exist"),
+	})
+
+	res, _ := Apply(context.Background(), db, ddlScript, Options{})
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Mode       string           `json:"mode"`
+		OK         bool             `json:"ok"`
+		Error      string           `json:"error"`
+		StartedAt  string           `json:"startedAt"`
+		DurationMs *int64           `json:"durationMs"`
+		Executed   int              `json:"executed"`
+		Skipped    int              `json:"skipped"`
+		Statements []map[string]any `json:"statements"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Mode != "idempotent" || got.OK || !strings.Contains(got.Error, "BI_READER") ||
+		got.StartedAt == "" || got.DurationMs == nil || got.Executed != 3 || got.Skipped != 1 || len(got.Statements) != 5 {
+		t.Errorf("resultado JSON = %s", data)
+	}
+
+	last := got.Statements[4]
+	for _, k := range []string{"index", "line", "sql", "status", "durationMs", "error"} {
+		if _, ok := last[k]; !ok {
+			t.Errorf("statement sem %q: %v", k, last)
+		}
+	}
+	if last["status"] != "failed" || last["index"] != float64(5) {
+		t.Errorf("último comando = %v", last)
+	}
+	if _, ok := got.Statements[0]["error"]; ok {
+		t.Errorf("comando ok não deveria trazer error: %v", got.Statements[0])
+	}
+}
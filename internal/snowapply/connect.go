@@ -0,0 +1,55 @@
+package snowapply
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/snowflakedb/gosnowflake"
+
+	"ih-ingestion/internal/config"
+)
+
+// Open conecta no Snowflake com o driver Go, usando as variáveis do Secret montado no job:
+// SNOWFLAKE_ACCOUNT, SNOWFLAKE_USER, SNOWFLAKE_PASSWORD, SNOWFLAKE_WAREHOUSE e,
+// opcionalmente, SNOWFLAKE_ROLE. As chaves SNOWSQL_* (Secret antigo do snowsql) são aceitas
+// como fallback.
+func Open() (*sql.DB, error) {
+	get := func(key string) string {
+		if v := config.GetEnvOrDefault("SNOWFLAKE_"+key, ""); v != "" {
+			return v
+		}
+		alt := key
+		if key == "PASSWORD" {
+			alt = "PWD"
+		}
+		return config.GetEnvOrDefault("SNOWSQL_"+alt, "")
+	}
+
+	cfg := &gosnowflake.Config{
+		Account:     get("ACCOUNT"),
+		User:        get("USER"),
+		Password:    get("PASSWORD"),
+		Warehouse:   get("WAREHOUSE"),
+		Role:        get("ROLE"),
+		Application: "ih-ingestion",
+	}
+	required := []struct{ name, value string }{
+		{"ACCOUNT", cfg.Account}, {"USER", cfg.User}, {"PASSWORD", cfg.Password},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			return nil, fmt.Errorf("missing required env var SNOWFLAKE_%s", r.name)
+		}
+	}
+
+	dsn, err := gosnowflake.DSN(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("montando DSN do Snowflake: %w", err)
+	}
+
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
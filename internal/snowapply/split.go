@@ -0,0 +1,119 @@
+package snowapply
+
+import "strings"
+
+// Statement é um comando do script, com a linha onde começa (1-based).
+type Statement struct {
+	Line int
+	SQL  string
+}
+
+// SplitStatements separa o script em comandos terminados por ';', respeitando
+// strings ('...', com a aspa simples dobrada e \' como escape), identificadores
+// ("..."), blocos $$...$$ e comentários (-- e /* */). Comandos vazios ou só com
+// comentários são descartados.
+func SplitStatements(script string) []Statement {
+	var (
+		out       []Statement
+		b         strings.Builder
+		line      = 1
+		startLine = 0
+		hasCode   bool
+	)
+
+	flush := func() {
+		sql := strings.TrimSpace(b.String())
+		if hasCode && sql != "" {
+			out = append(out, Statement{Line: startLine, SQL: sql})
+		}
+		b.Reset()
+		hasCode = false
+		startLine = 0
+	}
+	mark := func() {
+		if !hasCode {
+			hasCode = true
+			startLine = line
+		}
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+
+		switch {
+		case c == '\n':
+			line++
+			b.WriteByte(c)
+
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			b.WriteString(script[i : i+end])
+			i += end - 1
+
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			} else {
+				end += 2
+			}
+			block := script[i : i+2+end]
+			line += strings.Count(block, "\n")
+			b.WriteString(block)
+			i += 1 + end
+
+		case c == '\'' || c == '"':
+			mark()
+			j := i + 1
+			for j < len(script) {
+				if c == '\'' && script[j] == '\\' && j+1 < len(script) {
+					j += 2
+					continue
+				}
+				if script[j] == c {
+					if j+1 < len(script) && script[j+1] == c { // '' ou ""
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(script) {
+				j = len(script) - 1
+			}
+			lit := script[i : j+1]
+			line += strings.Count(lit, "\n")
+			b.WriteString(lit)
+			i = j
+
+		case c == '$' && i+1 < len(script) && script[i+1] == '$':
+			mark()
+			end := strings.Index(script[i+2:], "$$")
+			if end < 0 {
+				end = len(script) - i - 2
+			} else {
+				end += 2
+			}
+			block := script[i : i+2+end]
+			line += strings.Count(block, "\n")
+			b.WriteString(block)
+			i += 1 + end
+
+		case c == ';':
+			flush()
+
+		default:
+			if c != ' ' && c != '\t' && c != '\r' {
+				mark()
+			}
+			b.WriteByte(c)
+		}
+	}
+	flush()
+
+	return out
+}
@@ -0,0 +1,60 @@
+package snowflake
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Palavras reservadas do Snowflake (e algumas do ANSI que quebram DDL sem aspas).
+var reservedWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		ACCOUNT ALL ALTER AND ANY AS BETWEEN BY CASE CAST CHECK COLUMN CONNECT CONNECTION
+		CONSTRAINT CREATE CROSS CURRENT CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER
+		DATABASE DELETE DISTINCT DROP ELSE EXISTS FALSE FOLLOWING FOR FROM FULL GRANT GROUP
+		GSCLUSTER HAVING ILIKE IN INCREMENT INNER INSERT INTERSECT INTO IS ISSUE JOIN LATERAL
+		LEFT LIKE LOCALTIME LOCALTIMESTAMP MINUS NATURAL NOT NULL OF ON OR ORDER ORGANIZATION
+		QUALIFY REGEXP REVOKE RIGHT RLIKE ROW ROWS SAMPLE SCHEMA SELECT SET SOME START TABLE
+		TABLESAMPLE THEN TO TRIGGER TRUE TRY_CAST UNION UNIQUE UPDATE USING VALUES VIEW WHEN
+		WHENEVER WHERE WITH
+		USER LIMIT OFFSET PRIMARY KEY REFERENCES FOREIGN DEFAULT INTERVAL
+	`) {
+		reservedWords[w] = true
+	}
+}
+
+// MetadataColumns são as colunas IH_* que o job adiciona em _INGEST.
+var MetadataColumns = []string{"IH_TOPIC", "IH_PARTITION", "IH_OFFSET", "IH_OP", "IH_DATETIME", "IH_BLOCKID"}
+
+// Tamanho máximo de identificador no Snowflake.
+const MaxIdentifierLength = 255
+
+var plainIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// IsReserved indica se a palavra é reservada (sem diferenciar maiúsculas).
+func IsReserved(name string) bool {
+	return reservedWords[strings.ToUpper(name)]
+}
+
+// IsPlainIdentifier: pode ir sem aspas no DDL (letras ASCII, dígitos, _ e $, e não reservado).
+func IsPlainIdentifier(name string) bool {
+	return plainIdent.MatchString(name) && !IsReserved(name)
+}
+
+// Normalize é a forma com que o Snowflake resolve o nome: identificadores sem aspas
+// viram maiúsculas, e QuoteIdent também usa maiúsculas, então a chave é sempre upper.
+func Normalize(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// QuoteIdent devolve o identificador pronto para o DDL: nomes simples ficam como estão
+// (o Snowflake resolve em maiúsculas); reservados, com espaços, acentos ou outros
+// caracteres vão entre aspas, em maiúsculas, para resolver igual aos demais.
+func QuoteIdent(name string) string {
+	name = strings.TrimSpace(name)
+	if IsPlainIdentifier(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(Normalize(name), `"`, `""`) + `"`
+}
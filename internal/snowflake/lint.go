@@ -0,0 +1,80 @@
+package snowflake
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"ih-ingestion/internal/model"
+)
+
+// TableTarget são os nomes de destino de uma tabela no Snowflake.
+type TableTarget struct {
+	Origin string // ex: alias:dbo.Clientes (só para as mensagens)
+	Schema string
+	Table  string
+	Stage  string
+}
+
+// LintTable valida os identificadores antes de gerar o job: schema/tabela/stage
+// precisam ser identificadores simples (vão sem aspas no DDL, no sink e no stage);
+// colunas podem ser citadas, mas não podem ser vazias, ter caracteres de controle,
+// passar do limite, repetir após normalização ou colidir com as colunas IH_*.
+func LintTable(t TableTarget, cols []model.ColumnInfo) error {
+	var problems []string
+
+	objects := []struct{ kind, name, hint string }{
+		{"schema de destino", t.Schema, "targetSchema"},
+		{"tabela de destino", t.Table, "targetTable"},
+		{"stage", t.Stage, "stage"},
+	}
+	for _, o := range objects {
+		switch {
+		case o.name == "":
+			problems = append(problems, fmt.Sprintf("%s vazio", o.kind))
+		case !IsPlainIdentifier(o.name):
+			reason := "tem caracteres fora de [A-Za-z0-9_$]"
+			if IsReserved(o.name) {
+				reason = "é palavra reservada"
+			}
+			problems = append(problems, fmt.Sprintf("%s %q %s (defina %s no ingestion.yaml)", o.kind, o.name, reason, o.hint))
+		case len(o.name) > MaxIdentifierLength:
+			problems = append(problems, fmt.Sprintf("%s %q passa de %d caracteres", o.kind, o.name, MaxIdentifierLength))
+		}
+	}
+
+	metadata := map[string]bool{}
+	for _, m := range MetadataColumns {
+		metadata[m] = true
+	}
+
+	seen := map[string]string{}
+	for _, c := range cols {
+		name := c.Name
+		key := Normalize(name)
+
+		switch {
+		case strings.TrimSpace(name) == "":
+			problems = append(problems, "coluna com nome vazio")
+			continue
+		case strings.IndexFunc(name, unicode.IsControl) >= 0:
+			problems = append(problems, fmt.Sprintf("coluna %q tem caracteres de controle", name))
+			continue
+		case len(name) > MaxIdentifierLength:
+			problems = append(problems, fmt.Sprintf("coluna %q passa de %d caracteres", name, MaxIdentifierLength))
+		case metadata[key]:
+			problems = append(problems, fmt.Sprintf("coluna %q colide com a coluna de metadados %s", name, key))
+		}
+
+		if prev, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("colunas %q e %q viram o mesmo identificador %s no Snowflake", prev, name, QuoteIdent(name)))
+		} else {
+			seen[key] = name
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("DDL inseguro para %s -> %s.%s:\n- %s", t.Origin, t.Schema, t.Table, strings.Join(problems, "\n- "))
+	}
+	return nil
+}
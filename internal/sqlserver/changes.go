@@ -0,0 +1,133 @@
+package sqlserver
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Origem da estimativa de alterações
+const (
+	ChangeSourceConfig = "config" // changeVolume no ingestion.yaml
+	ChangeSourceCDC    = "cdc"    // linhas da change table cdc.<capture>_CT
+	ChangeSourceIndex  = "index"  // sys.dm_db_index_operational_stats desde o start do SQL Server
+	ChangeSourceNone   = "none"   // sem estatística disponível
+)
+
+// janela mínima para não inflar a taxa de tabelas com poucas horas de histórico
+const minChangeWindow = time.Hour
+
+// período contado na change table do CDC, a partir da última alteração capturada
+const cdcSampleWindow = 24 * time.Hour
+
+// ChangeEstimate é a estimativa de alterações (insert/update/delete) por dia de uma tabela.
+type ChangeEstimate struct {
+	PerDay int64
+	Source string
+	Window time.Duration // período observado
+}
+
+// EstimateChanges estima as alterações por dia da tabela: primeiro pela change table do CDC
+// (linhas das últimas 24h dentro da retenção), depois pelos contadores de sys.dm_db_index_operational_stats
+// (desde o último start da instância, exige VIEW SERVER STATE).
+func EstimateChanges(db *sql.DB, schema, table string) (ChangeEstimate, error) {
+	est, ok, err := estimateFromCDC(db, schema, table)
+	if err != nil {
+		return ChangeEstimate{Source: ChangeSourceNone}, fmt.Errorf("change table CDC de %s.%s: %w", schema, table, err)
+	}
+	if ok {
+		return est, nil
+	}
+
+	est, err = estimateFromIndexStats(db, schema, table)
+	if err != nil {
+		return ChangeEstimate{Source: ChangeSourceNone}, fmt.Errorf("dm_db_index_operational_stats de %s.%s: %w", schema, table, err)
+	}
+	return est, nil
+}
+
+func estimateFromCDC(db *sql.DB, schema, table string) (ChangeEstimate, bool, error) {
+	const qCapture = `
+SELECT TOP 1 ct.capture_instance
+FROM cdc.change_tables ct
+JOIN sys.tables t   ON ct.source_object_id = t.object_id
+JOIN sys.schemas s  ON t.schema_id = s.schema_id
+WHERE s.name = @p1
+  AND t.name = @p2
+ORDER BY ct.create_date DESC;
+`
+	var capture sql.NullString
+	err := db.QueryRow(qCapture, schema, table).Scan(&capture)
+	if err == sql.ErrNoRows || (err == nil && !capture.Valid) {
+		return ChangeEstimate{}, false, nil
+	}
+	if err != nil {
+		// database sem CDC habilitado não tem o schema cdc
+		if strings.Contains(strings.ToLower(err.Error()), "invalid object name") {
+			return ChangeEstimate{}, false, nil
+		}
+		return ChangeEstimate{}, false, err
+	}
+
+	// Conta só a última janela (cdcSampleWindow, limitada à retenção): a change table é
+	// indexada por __$start_lsn, então o intervalo de LSN é um seek e não varre a retenção
+	// inteira. __$operation 3 é a imagem "antes" do update: conta cada update uma vez.
+	q := fmt.Sprintf(`
+DECLARE @to binary(10) = sys.fn_cdc_get_max_lsn();
+DECLARE @end datetime = sys.fn_cdc_map_lsn_to_time(@to);
+DECLARE @start datetime = DATEADD(SECOND, -@p1, @end);
+DECLARE @retained datetime = sys.fn_cdc_map_lsn_to_time(sys.fn_cdc_get_min_lsn(@p2));
+IF @retained > @start SET @start = @retained;
+DECLARE @from binary(10) = sys.fn_cdc_map_time_to_lsn('smallest greater than or equal', @start);
+
+SELECT COUNT_BIG(*), @start, @end
+FROM cdc.%s
+WHERE __$start_lsn BETWEEN @from AND @to
+  AND __$operation <> 3;
+`, quoteName(capture.String+"_CT"))
+
+	var (
+		count      int64
+		first, end sql.NullTime
+	)
+	if err := db.QueryRow(q, int32(cdcSampleWindow/time.Second), capture.String).Scan(&count, &first, &end); err != nil {
+		return ChangeEstimate{}, false, err
+	}
+	if count == 0 || !first.Valid || !end.Valid {
+		// nada na janela (retenção limpa ou tabela parada): tenta os contadores do índice
+		return ChangeEstimate{}, false, nil
+	}
+
+	window := end.Time.Sub(first.Time)
+	return ChangeEstimate{PerDay: perDay(count, window), Source: ChangeSourceCDC, Window: window}, true, nil
+}
+
+func estimateFromIndexStats(db *sql.DB, schema, table string) (ChangeEstimate, error) {
+	const q = `
+SELECT
+  COALESCE(SUM(os.leaf_insert_count + os.leaf_update_count + os.leaf_delete_count), 0),
+  DATEDIFF_BIG(SECOND, (SELECT sqlserver_start_time FROM sys.dm_os_sys_info), SYSDATETIME())
+FROM sys.dm_db_index_operational_stats(DB_ID(), OBJECT_ID(QUOTENAME(@p1) + '.' + QUOTENAME(@p2)), NULL, NULL) os
+WHERE os.index_id IN (0, 1);
+`
+	var count, seconds int64
+	if err := db.QueryRow(q, schema, table).Scan(&count, &seconds); err != nil {
+		return ChangeEstimate{}, err
+	}
+
+	window := time.Duration(seconds) * time.Second
+	return ChangeEstimate{PerDay: perDay(count, window), Source: ChangeSourceIndex, Window: window}, nil
+}
+
+func perDay(count int64, window time.Duration) int64 {
+	if window < minChangeWindow {
+		window = minChangeWindow
+	}
+	return int64(float64(count) * float64(24*time.Hour) / float64(window))
+}
+
+// quoteName é o QUOTENAME do SQL Server para nomes vindos do catálogo.
+func quoteName(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
@@ -10,6 +10,7 @@ import (
 
 	"ih-ingestion/internal/config"
 	"ih-ingestion/internal/model"
+	"ih-ingestion/internal/snowflake"
 )
 
 // MODO UNICO – ainda funciona (único banco via SQLSERVER_HOST/...)
@@ -54,23 +55,23 @@ func NewFromEnv() (*sql.DB, string, error) {
 	return db, dbName, nil
 }
 
-// conexão por alias (SQLSERVER_{ALIAS}_HOST/USER/PASSWORD)
-func NewFromAlias(alias, database string) (*sql.DB, error) {
+// conexão por alias (SQLSERVER_{ALIAS}_HOST/USER/PASSWORD, com sufixo _{ENV} opcional)
+func NewFromAlias(alias, database string, profile config.EnvProfile) (*sql.DB, error) {
 	upper := strings.ToUpper(alias)
 
-	host, err := config.RequireEnv("SQLSERVER_" + upper + "_HOST")
+	host, err := profile.SqlServerHost(alias)
 	if err != nil {
 		return nil, err
 	}
-	user, err := config.RequireEnv("SQLSERVER_" + upper + "_USER")
+	user, err := config.RequireEnvFor("SQLSERVER_"+upper+"_USER", profile.Env)
 	if err != nil {
 		return nil, err
 	}
-	password, err := config.RequireEnv("SQLSERVER_" + upper + "_PASSWORD")
+	password, err := config.RequireEnvFor("SQLSERVER_"+upper+"_PASSWORD", profile.Env)
 	if err != nil {
 		return nil, err
 	}
-	port := config.GetEnvOrDefault("SQLSERVER_"+upper+"_PORT", "1433")
+	port := profile.SqlServerPort(alias)
 
 	query := url.Values{}
 	query.Add("database", database)
@@ -138,6 +139,67 @@ ORDER BY ORDINAL_POSITION;
 	return cols, nil
 }
 
+// ListTables lista as tabelas de usuário do schema (sys.tables), ordenadas por nome.
+// Com cdcOnly, só as tabelas com CDC habilitado (is_tracked_by_cdc = 1).
+func ListTables(db *sql.DB, schema string, cdcOnly bool) ([]string, error) {
+	const q = `
+SELECT t.name
+FROM sys.tables t
+JOIN sys.schemas s ON t.schema_id = s.schema_id
+WHERE s.name = @p1
+  AND t.is_ms_shipped = 0
+  AND (@p2 = 0 OR t.is_tracked_by_cdc = 1)
+ORDER BY t.name;
+`
+	rows, err := db.Query(q, schema, cdcOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// ListForeignKeys retorna os pares schema.tabela -> schema.tabela referenciada de todas as
+// FKs do database (auto-referências ficam de fora).
+func ListForeignKeys(db *sql.DB) ([][2]string, error) {
+	const q = `
+SELECT DISTINCT
+  ps.name + '.' + pt.name AS parent_table,
+  rs.name + '.' + rt.name AS referenced_table
+FROM sys.foreign_keys fk
+JOIN sys.tables pt  ON fk.parent_object_id = pt.object_id
+JOIN sys.schemas ps ON pt.schema_id = ps.schema_id
+JOIN sys.tables rt  ON fk.referenced_object_id = rt.object_id
+JOIN sys.schemas rs ON rt.schema_id = rs.schema_id
+WHERE fk.parent_object_id <> fk.referenced_object_id
+ORDER BY parent_table, referenced_table;
+`
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs [][2]string
+	for rows.Next() {
+		var p [2]string
+		if err := rows.Scan(&p[0], &p[1]); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, rows.Err()
+}
+
 func GetTableRowCount(db *sql.DB, schema, table string) (int64, error) {
 	const q = `
 SELECT
@@ -159,6 +221,55 @@ WHERE p.index_id IN (0,1)
 	return rowCount.Int64, nil
 }
 
+// EstimateRowBytes estima a largura de uma linha pelos tipos das colunas (classificação
+// de tamanho). Tipos variáveis contam metade do tamanho declarado; (max), text e xml contam 4000.
+func EstimateRowBytes(cols []model.ColumnInfo) int64 {
+	const maxBytes = 4000
+	var total int64
+	for _, c := range cols {
+		switch t := strings.ToLower(c.DataType); t {
+		case "tinyint", "bit":
+			total++
+		case "smallint":
+			total += 2
+		case "int", "real", "smalldatetime":
+			total += 4
+		case "bigint", "float", "money", "datetime", "datetime2":
+			total += 8
+		case "smallmoney":
+			total += 4
+		case "date":
+			total += 3
+		case "time":
+			total += 5
+		case "datetimeoffset":
+			total += 10
+		case "uniqueidentifier":
+			total += 16
+		case "decimal", "numeric":
+			total += 9 // até precisão 19
+			if c.NumericPrecision.Valid && c.NumericPrecision.Int64 > 19 {
+				total += 8
+			}
+		case "char", "nchar", "binary", "varchar", "nvarchar", "varbinary":
+			n := int64(maxBytes)
+			if c.CharMaxLength.Valid && c.CharMaxLength.Int64 > 0 {
+				n = c.CharMaxLength.Int64
+				if t == "nchar" || t == "nvarchar" {
+					n *= 2
+				}
+				if strings.HasPrefix(t, "var") || strings.HasPrefix(t, "nvar") {
+					n /= 2
+				}
+			}
+			total += max(n, 1)
+		default: // text, ntext, image, xml, sql_variant, geography...
+			total += maxBytes
+		}
+	}
+	return total
+}
+
 func mapToSnowflakeType(c model.ColumnInfo) string {
 	t := strings.ToLower(c.DataType)
 
@@ -186,6 +297,72 @@ func mapToSnowflakeType(c model.ColumnInfo) string {
 	}
 }
 
+// ApplyColumnRules aplica excludeColumns/maskColumns às colunas lidas do banco:
+// excluídas saem do DDL; mascaradas viram VARCHAR do tamanho que o Debezium produz
+// (hash hexadecimal ou n caracteres '*'). Retorna também as regras com o nome real
+// de cada coluna. Com strict, coluna inexistente é erro; senão é ignorada.
+func ApplyColumnRules(cols []model.ColumnInfo, exclude []string, masks []config.ColumnMask, strict bool) ([]model.ColumnInfo, []string, []config.ColumnMask, error) {
+	byName := map[string]int{}
+	for i, c := range cols {
+		byName[strings.ToUpper(c.Name)] = i
+	}
+
+	drop := map[int]bool{}
+	var excluded []string
+	for _, name := range exclude {
+		i, ok := byName[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			if strict {
+				return nil, nil, nil, fmt.Errorf("excludeColumns: coluna %s não existe", name)
+			}
+			continue
+		}
+		drop[i] = true
+		excluded = append(excluded, cols[i].Name)
+	}
+
+	out := make([]model.ColumnInfo, len(cols))
+	copy(out, cols)
+
+	var applied []config.ColumnMask
+	for _, m := range masks {
+		i, ok := byName[strings.ToUpper(strings.TrimSpace(m.Name))]
+		if !ok {
+			if strict {
+				return nil, nil, nil, fmt.Errorf("maskColumns: coluna %s não existe", m.Name)
+			}
+			continue
+		}
+		switch strings.ToLower(out[i].DataType) {
+		case "char", "nchar", "varchar", "nvarchar", "text", "ntext":
+		default:
+			return nil, nil, nil, fmt.Errorf("maskColumns: coluna %s é %s; o Debezium só mascara colunas texto", out[i].Name, out[i].DataType)
+		}
+
+		length := m.Length
+		if m.Strategy == config.MaskHash {
+			length = config.HashHexLength[m.Algorithm]
+		}
+		out[i].DataType = "varchar"
+		out[i].CharMaxLength = sql.NullInt64{Int64: int64(length), Valid: true}
+
+		m.Name = cols[i].Name
+		applied = append(applied, m)
+	}
+
+	kept := out[:0]
+	for i, c := range out {
+		if !drop[i] {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil, nil, fmt.Errorf("excludeColumns remove todas as colunas")
+	}
+
+	return kept, excluded, applied, nil
+}
+
 func BuildBusinessColumnsDDL(cols []model.ColumnInfo) string {
 	var b strings.Builder
 
@@ -195,7 +372,7 @@ func BuildBusinessColumnsDDL(cols []model.ColumnInfo) string {
 		if strings.EqualFold(c.IsNullable, "YES") {
 			nullStr = "NULL"
 		}
-		fmt.Fprintf(&b, "      %s %s %s,\n", c.Name, sfType, nullStr)
+		fmt.Fprintf(&b, "  %s %s %s,\n", snowflake.QuoteIdent(c.Name), sfType, nullStr)
 	}
 
 	return b.String()
@@ -0,0 +1,159 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Funcs são as funções auxiliares disponíveis em todos os templates (embutidos e do
+// -templates-dir). Nomes e ordem dos argumentos seguem o sprig/Helm, para que o
+// valor do pipeline seja sempre o último argumento ({{ .Schema | default "DBO" }}).
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		// strings
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       join,
+		"quote":      func(v any) string { return fmt.Sprintf("%q", toString(v)) },
+		"squote":     func(v any) string { return "'" + strings.ReplaceAll(toString(v), "'", "''") + "'" },
+		"indent":     indent,
+		"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+
+		// valores
+		"default":  func(def, v any) any { return ternary(v, def, !empty(v)) },
+		"empty":    empty,
+		"coalesce": coalesce,
+		"required": required,
+		"ternary":  func(a, b any, cond bool) any { return ternary(a, b, cond) },
+
+		// coleções
+		"list": func(items ...any) []any { return items },
+		"dict": dict,
+		"keys": keys,
+
+		// YAML
+		"toYaml": toYaml,
+	}
+}
+
+func toString(v any) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func join(sep string, list any) (string, error) {
+	if s, ok := list.([]string); ok {
+		return strings.Join(s, sep), nil
+	}
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: esperava lista, recebeu %T", list)
+	}
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = toString(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// empty segue o sprig: nil, zero, "", false e coleções vazias.
+func empty(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !empty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func required(msg string, v any) (any, error) {
+	if empty(v) {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+func ternary(a, b any, cond bool) any {
+	if cond {
+		return a
+	}
+	return b
+}
+
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict: número ímpar de argumentos (use chave valor ...)")
+	}
+	out := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		out[toString(pairs[i])] = pairs[i+1]
+	}
+	return out, nil
+}
+
+// keys devolve as chaves de um map ordenadas.
+func keys(m any) ([]string, error) {
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Map {
+		return nil, fmt.Errorf("keys: esperava map, recebeu %T", m)
+	}
+	out := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		out = append(out, toString(k.Interface()))
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// toYaml serializa v em YAML bloco (sem newline final), para usar com nindent.
+func toYaml(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
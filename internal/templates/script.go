@@ -0,0 +1,51 @@
+package templates
+
+import "text/template"
+
+// ScriptTemplate gera o script.sql do ConfigMap do job (dados: model.SnowflakeJobConfig).
+// É SQL puro: o texto entra no manifesto como valor, serializado pelo YAML.
+var ScriptTemplate = template.Must(template.New("script").Funcs(Funcs()).Parse(scriptText))
+
+var scriptText = `
+USE ROLE {{ .Role }};
+USE DATABASE {{ .Database }};
+
+CREATE SCHEMA IF NOT EXISTS {{ .Schema }};
+USE SCHEMA {{ .Schema }};
+{{- if .OwnerCheckSQL }}
+
+{{ .OwnerCheckSQL }}{{ end }}
+
+DROP TABLE IF EXISTS {{ .TableIngest }};
+DROP TABLE IF EXISTS {{ .TableFinal }};
+
+CREATE TABLE IF NOT EXISTS {{ .TableIngest }} (
+{{ .BusinessColumnsDDL }}  IH_TOPIC VARCHAR(255) NOT NULL,
+  IH_PARTITION INT NOT NULL,
+  IH_OFFSET INT NOT NULL,
+  IH_OP VARCHAR(1) NOT NULL,
+  IH_DATETIME TIMESTAMP_NTZ NOT NULL,
+  IH_BLOCKID VARCHAR(40) NOT NULL,
+  constraint pkey PRIMARY KEY (IH_TOPIC, IH_PARTITION, IH_OFFSET)
+);
+
+CREATE TABLE IF NOT EXISTS {{ .TableFinal }} (
+{{ .BusinessColumnsDDL }});
+{{- if .GovernanceSQL }}
+
+-- Governança: tags e masking policies por classificação
+{{ .GovernanceSQL }}{{ end }}
+
+-- IF NOT EXISTS + ALTER: CREATE OR REPLACE apagaria os grants do stage a cada execução
+CREATE STAGE IF NOT EXISTS {{ .StageName }};
+ALTER STAGE {{ .StageName }} SET
+  FILE_FORMAT = (
+    TYPE = 'CSV',
+    FIELD_OPTIONALLY_ENCLOSED_BY = '"',
+    SKIP_HEADER = 0,
+    FIELD_DELIMITER = ';',
+    NULL_IF = ('\\N', 'NULL')
+  );
+{{ if .GrantsSQL }}
+-- Grants e ownership
+{{ .GrantsSQL }}{{ end }}`[1:]
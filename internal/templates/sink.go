@@ -2,7 +2,9 @@ package templates
 
 import "text/template"
 
-var SinkTemplate = template.Must(template.New("sink").Parse(`
+var SinkTemplate = template.Must(template.New("sink").Funcs(Funcs()).Parse(sinkText))
+
+var sinkText = `
 apiVersion: kafka.strimzi.io/v1beta2
 kind: KafkaConnector
 metadata:
@@ -14,7 +16,7 @@ spec:
   autoRestart:
     enabled: true
   class: br.com.datastreambrasil.v3.SnowflakeSinkConnector
-  tasksMax: 1
+  tasksMax: {{ .TasksMax }}
   config:
     topics: "{{ .TopicName }}"
     url: "{{ .SnowflakeURL }}"
@@ -25,9 +27,30 @@ spec:
     schema: "{{ .Schema }}"
 
     key.converter: "io.confluent.connect.avro.AvroConverter"
-    key.converter.schema.registry.url: "http://schema-registry-ih.kafka-admin:8081"
+    key.converter.schema.registry.url: "{{ .SchemaRegistryURL }}"
     key.converter.schemas.enable: true
     value.converter: "io.confluent.connect.avro.AvroConverter"
-    value.converter.schema.registry.url: "http://schema-registry-ih.kafka-admin:8081"
+    value.converter.schema.registry.url: "{{ .SchemaRegistryURL }}"
     value.converter.schemas.enable: true
-`[1:]))
+{{- if .ColumnRenames }}
+
+    # Colunas renomeadas pela política naming (origem:snowflake)
+    transforms: "renameColumns"
+    transforms.renameColumns.type: "org.apache.kafka.connect.transforms.ReplaceField$Value"
+    transforms.renameColumns.renames: "{{ .ColumnRenames }}"
+{{- end }}
+{{- if .Buffer }}
+
+    # Buffer/flush da classe de tamanho
+{{- range $k, $v := .Buffer }}
+    {{ $k }}: {{ printf "%q" $v }}
+{{- end }}
+{{- end }}
+{{- if .ExtraConfig }}
+
+    # Overrides da tabela (sinkConfig)
+{{- range $k, $v := .ExtraConfig }}
+    {{ $k }}: {{ printf "%q" $v }}
+{{- end }}
+{{- end }}
+`[1:]
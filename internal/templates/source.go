@@ -2,7 +2,9 @@ package templates
 
 import "text/template"
 
-var SourceTemplate = template.Must(template.New("source").Parse(`
+var SourceTemplate = template.Must(template.New("source").Funcs(Funcs()).Parse(sourceText))
+
+var sourceText = `
 apiVersion: kafka.strimzi.io/v1beta2
 kind: KafkaConnector
 metadata:
@@ -14,7 +16,7 @@ spec:
   autoRestart:
     enabled: true
   class: io.debezium.connector.sqlserver.SqlServerConnector
-  tasksMax: 1
+  tasksMax: {{ .TasksMax }}
   config:
     # Conexão com SQL Server
     database.hostname: "{{ .DatabaseHost }}"
@@ -26,7 +28,17 @@ spec:
 
     # Tópicos e tabelas
     topic.prefix: "{{ .TopicPrefix }}"
+{{- if .TopicPartitions }}
+    topic.creation.default.partitions: {{ .TopicPartitions }}
+    topic.creation.default.replication.factor: -1
+{{- end }}
     table.include.list: "{{ .TableIncludeList }}"
+{{- if .ColumnExcludeList }}
+    column.exclude.list: "{{ .ColumnExcludeList }}"
+{{- end }}
+{{- range $k, $v := .ColumnMasks }}
+    {{ $k }}: "{{ $v }}"
+{{- end }}
 
     # Regras de tipos / tombstones
     decimal.handling.mode: "string"
@@ -49,5 +61,5 @@ spec:
     snapshot.mode: "when_needed"
     snapshot.locking.mode: none
     snapshot.isolation.mode: read_committed
-    snapshot.max.threads: 5
-`[1:]))
+    snapshot.max.threads: {{ .SnapshotMaxThreads }}
+`[1:]
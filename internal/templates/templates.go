@@ -0,0 +1,252 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"ih-ingestion/internal/config"
+	"ih-ingestion/internal/model"
+)
+
+// Nomes dos templates (arquivo <nome>.yaml.tmpl no -templates-dir; script.sql.tmpl para o script)
+const (
+	Source = "source" // KafkaConnector Debezium, dados: model.SourceConfig
+	Sink   = "sink"   // KafkaConnector Snowflake, dados: model.SinkConfig
+	Job    = "job"    // Job + ConfigMap do Snowflake, dados: model.SnowflakeJobConfig
+	Script = "script" // script.sql do ConfigMap (SQL), dados: model.SnowflakeJobConfig
+)
+
+// Names lista os templates na ordem de geração.
+var Names = []string{Source, Sink, Job, Script}
+
+// FileName é o arquivo do template no diretório de overrides. Arquivos que começam
+// com "_" (ex: _helpers.tmpl) só trazem {{ define }} e são carregados em todos os templates.
+func FileName(name string) string {
+	if name == Script {
+		return name + ".sql.tmpl"
+	}
+	return name + ".yaml.tmpl"
+}
+
+// Set são os templates usados numa geração: os embutidos, com os overrides do diretório.
+// Os manifestos embutidos saem de structs tipadas (internal/manifest); os templates
+// source, sink e job só são executados quando sobrescritos. O script é sempre um template.
+type Set struct {
+	Source *template.Template
+	Sink   *template.Template
+	Job    *template.Template
+	Script *template.Template
+
+	Overridden []string // nomes carregados do disco
+}
+
+// Builtin devolve o texto do template embutido.
+func Builtin(name string) (string, bool) {
+	switch name {
+	case Source:
+		return sourceText, true
+	case Sink:
+		return sinkText, true
+	case Job:
+		return jobText, true
+	case Script:
+		return scriptText, true
+	}
+	return "", false
+}
+
+// Default são os templates embutidos no binário.
+func Default() *Set {
+	return &Set{Source: SourceTemplate, Sink: SinkTemplate, Job: SnowflakeJobTemplate, Script: ScriptTemplate}
+}
+
+// Load devolve os templates embutidos com os overrides de dir (vazio = só os embutidos).
+// Cada override é validado executando-o com dados de exemplo, para que um campo com nome
+// errado falhe aqui e não no meio da geração.
+func Load(dir string) (*Set, error) {
+	set := Default()
+	if strings.TrimSpace(dir) == "" {
+		return set, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("lendo diretório de templates %s: %w", dir, err)
+	}
+
+	var helpers []string
+	overrides := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		switch {
+		case strings.HasPrefix(e.Name(), "_") && strings.HasSuffix(e.Name(), ".tmpl"):
+			helpers = append(helpers, path)
+		case strings.HasSuffix(e.Name(), ".tmpl"):
+			i := slices.IndexFunc(Names, func(n string) bool { return FileName(n) == e.Name() })
+			if i < 0 {
+				files := make([]string, len(Names))
+				for j, n := range Names {
+					files[j] = FileName(n)
+				}
+				return nil, fmt.Errorf("template %s desconhecido (use %s)", path, strings.Join(files, ", "))
+			}
+			overrides[Names[i]] = path
+		}
+	}
+	sort.Strings(helpers)
+
+	for _, name := range Names {
+		path, ok := overrides[name]
+		if !ok {
+			continue
+		}
+		t, err := parseFile(name, path, helpers)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(io.Discard, Sample(name)); err != nil {
+			return nil, fmt.Errorf("template %s não executa com os dados de exemplo: %w", path, err)
+		}
+
+		switch name {
+		case Source:
+			set.Source = t
+		case Sink:
+			set.Sink = t
+		case Job:
+			set.Job = t
+		case Script:
+			set.Script = t
+		}
+		set.Overridden = append(set.Overridden, name)
+	}
+	return set, nil
+}
+
+func parseFile(name, path string, helpers []string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("lendo template %s: %w", path, err)
+	}
+	t, err := template.New(name).Funcs(Funcs()).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", path, err)
+	}
+	// helpers entram como templates associados ({{ template "nome" . }})
+	for _, h := range helpers {
+		hdata, err := os.ReadFile(h)
+		if err != nil {
+			return nil, fmt.Errorf("lendo helpers %s: %w", h, err)
+		}
+		if _, err := t.New(filepath.Base(h)).Parse(string(hdata)); err != nil {
+			return nil, fmt.Errorf("helpers %s: %w", h, err)
+		}
+	}
+	return t, nil
+}
+
+// IsOverridden indica se o template name veio do diretório de overrides.
+func (s *Set) IsOverridden(name string) bool {
+	return slices.Contains(s.Overridden, name)
+}
+
+// Dump grava os templates embutidos em dir (ver FileName) como ponto de partida
+// para overrides. Sem force, não sobrescreve arquivos existentes.
+func Dump(dir string, force bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, name := range Names {
+		path := filepath.Join(dir, FileName(name))
+		if !force {
+			if _, err := os.Stat(path); err == nil {
+				return written, fmt.Errorf("%s já existe (use -force para sobrescrever)", path)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return written, err
+			}
+		}
+		text, _ := Builtin(name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// Sample são dados de exemplo do template (validação dos overrides).
+func Sample(name string) any {
+	switch name {
+	case Source:
+		return model.SourceConfig{
+			Name:                          "source-debeziumsqlserver-vendas-dbo-grupo1-online-m-001",
+			ClusterName:                   "connect-cluster",
+			TasksMax:                      1,
+			DatabaseHost:                  "sqlserver.local",
+			DatabasePort:                  "1433",
+			DatabaseSecret:                "sqlserver-origem",
+			DatabaseNameUpper:             "VENDAS",
+			TopicPrefix:                   "source_debeziumsqlserver_vendas_dbo_grupo1_online_m",
+			TopicPartitions:               3,
+			TableIncludeList:              "dbo.Pedidos",
+			ColumnExcludeList:             "VENDAS.dbo.Pedidos.Observacao",
+			ColumnMasks:                   map[string]string{"column.mask.with.8.chars": "VENDAS.dbo.Pedidos.Cpf"},
+			SchemaHistoryBootstrapServers: "kafka:9092",
+			SchemaHistoryTopic:            "sh_source_debeziumsqlserver_vendas_dbo_grupo1_online_m_001",
+			SchemaRegistryURL:             "http://schema-registry:8081",
+			SnapshotMaxThreads:            5,
+		}
+	case Sink:
+		return model.SinkConfig{
+			Name:                    "sink-jdbcsnowflake-lz-vendas-pedidos-online-m-v1",
+			ClusterName:             "connect-cluster",
+			TasksMax:                1,
+			TopicName:               "source_debeziumsqlserver_vendas_dbo_grupo1_online_m.VENDAS.DBO.PEDIDOS",
+			SnowflakeURL:            "jdbc:snowflake://conta.snowflakecomputing.com/?db=LZ",
+			SnowflakeUserSecret:     "snowflake-user",
+			SnowflakePasswordSecret: "snowflake-password",
+			Stage:                   "PEDIDOS",
+			Table:                   "PEDIDOS",
+			Schema:                  "VENDAS",
+			Buffer:                  map[string]string{"buffer.flush.time": "120"},
+			ExtraConfig:             map[string]string{"behavior.on.null.values": "ignore"},
+			ColumnRenames:           "Cpf:DOC_CPF",
+			SchemaRegistryURL:       "http://schema-registry:8081",
+		}
+	case Job, Script:
+		j := config.DefaultJobSettings()
+		return model.SnowflakeJobConfig{
+			JobName:            "lz-sql-ih-vendas-pedidos-v1",
+			CredentialsSecret:  "snowflake-credentials",
+			SqlConfigMapName:   "lz-sql-ih-vendas-pedidos-sql",
+			Image:              "ih-ingestion/ingestion-cli:1.4.0",
+			ImagePullPolicy:    "IfNotPresent",
+			Command:            j.Command,
+			Args:               j.Args,
+			SecurityContext:    j.SecurityContext,
+			PodSecurityContext: j.PodSecurityContext,
+			Role:               "INGESTION_ROLE",
+			Database:           "LZ",
+			Schema:             "VENDAS",
+			TableIngest:        "PEDIDOS_INGEST",
+			TableFinal:         "PEDIDOS",
+			StageName:          "PEDIDOS",
+			BusinessColumnsDDL: "  ID NUMBER(10,0),\n",
+			Script:             "USE ROLE INGESTION_ROLE;\n",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// Build roda o `kustomize build` de dir em processo (krusty, a mesma biblioteca do
+// binário kustomize) e valida cada recurso do bundle contra os schemas embutidos.
+// Erros do kustomize (recurso inexistente, id duplicado, patch sem alvo...) e falhas
+// de schema dos recursos renderizados entram em Problems.
+func Build(dir string) ([]byte, Result) {
+	dir = filepath.Clean(dir)
+	res := Result{Kustomizations: 1}
+
+	opts := krusty.MakeDefaultOptions()
+	opts.Reorder = krusty.ReorderOptionLegacy // a ordem padrão do `kustomize build`
+	m, err := krusty.MakeKustomizer(opts).Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		res.Problems = append(res.Problems, Problem{File: dir, Msg: "kustomize build: " + err.Error()})
+		return nil, res
+	}
+	bundle, err := m.AsYaml()
+	if err != nil {
+		res.Problems = append(res.Problems, Problem{File: dir, Msg: "serializando o bundle: " + err.Error()})
+		return nil, res
+	}
+	res.Bundle = m.Size()
+
+	// os recursos já saem com nome, namespace, labels e patches finais; o problema
+	// aponta o recurso no bundle, não o arquivo de origem
+	docs, problems := parseDocuments(dir, bundle)
+	res.Problems = append(res.Problems, problems...)
+	for _, d := range docs {
+		ps, validated := checkDocument(dir, d)
+		for _, p := range ps {
+			p.File, p.Line = fmt.Sprintf("%s [%s %s]", dir, d.kind, qualified(d.namespace, d.name)), 0
+			res.Problems = append(res.Problems, p)
+		}
+		if validated {
+			res.Resources++
+		}
+	}
+	return bundle, res
+}
+
+// CheckBundles roda o Build de cada raiz atualizada (gerador e promote): erro do
+// kustomize (recurso listado que não existe, recurso duplicado...), nome longo demais
+// ou recurso renderizado fora do schema falham a execução antes do manifesto da wave
+// e do push.
+func CheckBundles(roots map[string]bool) error {
+	dirs := make([]string, 0, len(roots))
+	for d := range roots {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	var failed []string
+	for _, dir := range dirs {
+		_, res := Build(dir)
+		for _, p := range res.Problems {
+			log.Printf("ERRO kustomize build %s: %s", dir, p)
+		}
+		if len(res.Problems) > 0 {
+			failed = append(failed, dir)
+			continue
+		}
+		log.Printf("kustomize build %s: %d recurso(s) OK", dir, res.Bundle)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("bundle inválido em %s (ver erros acima)", strings.Join(failed, ", "))
+	}
+	return nil
+}
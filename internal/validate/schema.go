@@ -0,0 +1,363 @@
+package validate
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/types"
+
+	"ih-ingestion/internal/yamlnode"
+)
+
+// Schemas embutidos, no formato publicado pelos projetos:
+//   - schemas/kubernetes: OpenAPI v3 do Kubernetes v1.29.0 (api/openapi-spec/v3), para Job e ConfigMap;
+//   - schemas/strimzi: CRDs do Strimzi (install/cluster-operator), para KafkaConnector e KafkaTopic.
+//
+// Os arquivos não são editados: os ajustes (strict e o metadata dos CRDs) são feitos na
+// carga. Para atualizar, troque os arquivos pelos da nova versão. Kustomization não tem schema:
+// é lida com o tipo do próprio kustomize (types.Kustomization), que rejeita campo desconhecido.
+//
+//go:embed schemas
+var schemaFS embed.FS
+
+const (
+	coreAPI  = "schemas/kubernetes/api__v1_openapi.json"
+	batchAPI = "schemas/kubernetes/apis__batch__v1_openapi.json"
+
+	// metadata dos CRDs: o apiserver valida com o ObjectMeta, não com o schema do CRD
+	objectMeta = coreAPI + "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+)
+
+// openAPIKinds são os recursos validados pelo OpenAPI do Kubernetes.
+var openAPIKinds = []struct{ apiVersion, kind, file, component string }{
+	{"batch/v1", "Job", batchAPI, "io.k8s.api.batch.v1.Job"},
+	{"v1", "ConfigMap", coreAPI, "io.k8s.api.core.v1.ConfigMap"},
+}
+
+var (
+	// schemas por apiVersion/kind
+	schemas = map[string]*jsonschema.Schema{}
+	// versão suportada de cada kind, para a mensagem de apiVersion errada
+	kindVersions = map[string]string{"Kustomization": types.KustomizationVersion}
+)
+
+func init() {
+	if err := loadSchemas(); err != nil {
+		panic(err)
+	}
+}
+
+// schemaURL é o endereço do arquivo embutido no compilador (nada é baixado).
+func schemaURL(file string) string {
+	return "embed:///" + file
+}
+
+type crd struct {
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Versions []struct {
+			Name   string `json:"name"`
+			Served bool   `json:"served"`
+			Schema struct {
+				OpenAPIV3Schema map[string]any `json:"openAPIV3Schema"`
+			} `json:"schema"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+func loadSchemas() error {
+	c := jsonschema.NewCompiler()
+
+	for _, file := range []string{coreAPI, batchAPI} {
+		data, err := schemaFS.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if m, ok := doc.(map[string]any); ok {
+			if components, ok := m["components"].(map[string]any); ok {
+				if defs, ok := components["schemas"].(map[string]any); ok {
+					for _, s := range defs {
+						strict(s)
+					}
+				}
+			}
+		}
+		if err := c.AddResource(schemaURL(file), doc); err != nil {
+			return err
+		}
+	}
+
+	crds, err := fs.Glob(schemaFS, "schemas/strimzi/*.yaml")
+	if err != nil {
+		return err
+	}
+	var crdSchemas []struct{ apiVersion, kind, loc string }
+	for _, file := range crds {
+		data, err := schemaFS.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var raw any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		j, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		var def crd
+		if err := json.Unmarshal(j, &def); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(j))
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		versions := doc.(map[string]any)["spec"].(map[string]any)["versions"].([]any)
+		for i, v := range def.Spec.Versions {
+			if !v.Served || v.Schema.OpenAPIV3Schema == nil {
+				continue
+			}
+			s := versions[i].(map[string]any)["schema"].(map[string]any)["openAPIV3Schema"].(map[string]any)
+			if props, ok := s["properties"].(map[string]any); ok {
+				props["metadata"] = map[string]any{"$ref": schemaURL(objectMeta)}
+			}
+			strict(s)
+			crdSchemas = append(crdSchemas, struct{ apiVersion, kind, loc string }{
+				def.Spec.Group + "/" + v.Name, def.Spec.Names.Kind,
+				fmt.Sprintf("%s#/spec/versions/%d/schema/openAPIV3Schema", schemaURL(file), i),
+			})
+		}
+		if err := c.AddResource(schemaURL(file), doc); err != nil {
+			return err
+		}
+	}
+
+	register := func(apiVersion, kind, loc string) error {
+		s, err := c.Compile(loc)
+		if err != nil {
+			return fmt.Errorf("schema de %s %s: %w", apiVersion, kind, err)
+		}
+		schemas[apiVersion+"/"+kind] = s
+		kindVersions[kind] = apiVersion
+		return nil
+	}
+	for _, k := range openAPIKinds {
+		if err := register(k.apiVersion, k.kind, schemaURL(k.file)+"#/components/schemas/"+k.component); err != nil {
+			return err
+		}
+	}
+	for _, k := range crdSchemas {
+		if err := register(k.apiVersion, k.kind, k.loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// strict aplica aos schemas o que o apiserver faz e o JSON Schema não: objeto com
+// properties não aceita campo desconhecido (salvo x-kubernetes-preserve-unknown-fields)
+// e x-kubernetes-int-or-string aceita só inteiro ou string.
+func strict(v any) {
+	switch n := v.(type) {
+	case map[string]any:
+		_, hasProps := n["properties"]
+		_, hasAdditional := n["additionalProperties"]
+		if hasProps && !hasAdditional && n["x-kubernetes-preserve-unknown-fields"] != true {
+			n["additionalProperties"] = false
+		}
+		if _, typed := n["type"]; !typed && n["x-kubernetes-int-or-string"] == true {
+			n["type"] = []any{"integer", "string"}
+		}
+		for key, child := range n {
+			if key == "properties" {
+				if props, ok := child.(map[string]any); ok {
+					for _, p := range props {
+						strict(p)
+					}
+				}
+				continue
+			}
+			if key != "enum" && key != "default" {
+				strict(child)
+			}
+		}
+	case []any:
+		for _, child := range n {
+			strict(child)
+		}
+	}
+}
+
+// violation é uma falha de schema em um nó do documento.
+type violation struct {
+	line int
+	path string
+	msg  string
+}
+
+var printer = message.NewPrinter(language.English)
+
+// validateDocument confere o documento com o schema do seu apiVersion/kind.
+func validateDocument(s *jsonschema.Schema, d document) []violation {
+	var raw any
+	if err := d.root.Decode(&raw); err != nil {
+		return []violation{{line: d.line, msg: err.Error()}}
+	}
+	j, err := json.Marshal(raw)
+	if err != nil {
+		return []violation{{line: d.line, msg: fmt.Sprintf("documento não convertível para JSON: %v", err)}}
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(j))
+	if err != nil {
+		return []violation{{line: d.line, msg: err.Error()}}
+	}
+
+	err = s.Validate(inst)
+	if err == nil {
+		return nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []violation{{line: d.line, msg: err.Error()}}
+	}
+	var out []violation
+	collect(d.root, verr, &out)
+	return out
+}
+
+// collect transforma as folhas do erro do jsonschema em violations com linha e caminho.
+func collect(root *yaml.Node, e *jsonschema.ValidationError, out *[]violation) {
+	if len(e.Causes) > 0 {
+		for _, c := range e.Causes {
+			collect(root, c, out)
+		}
+		return
+	}
+	n, p := locate(root, e.InstanceLocation)
+	if ap, ok := e.ErrorKind.(*kind.AdditionalProperties); ok {
+		for _, key := range ap.Properties {
+			line := n.Line
+			if k := yamlnode.Key(n, key); k != nil {
+				line = k.Line
+			}
+			*out = append(*out, violation{line: line, path: joinPath(p, key), msg: "campo desconhecido"})
+		}
+		return
+	}
+	*out = append(*out, violation{line: n.Line, path: p, msg: e.ErrorKind.LocalizedString(printer)})
+}
+
+// locate segue o JSON pointer do erro no documento YAML e devolve o nó e o caminho legível.
+func locate(n *yaml.Node, ptr []string) (*yaml.Node, string) {
+	p := ""
+	for _, seg := range ptr {
+		if n.Kind == yaml.AliasNode {
+			n = n.Alias
+		}
+		switch n.Kind {
+		case yaml.MappingNode:
+			next := yamlnode.Value(n, seg)
+			if next == nil {
+				return n, p
+			}
+			n, p = next, joinPath(p, seg)
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i >= len(n.Content) {
+				return n, p
+			}
+			n, p = n.Content[i], fmt.Sprintf("%s[%d]", p, i)
+		default:
+			return n, p
+		}
+	}
+	return n, p
+}
+
+// validateKustomization lê o documento com o tipo do kustomize, que recusa campo desconhecido.
+func validateKustomization(d document) []violation {
+	data, err := yaml.Marshal(d.root)
+	if err != nil {
+		return []violation{{line: d.line, msg: err.Error()}}
+	}
+	var k types.Kustomization
+	if err := k.Unmarshal(data); err != nil {
+		return []violation{{line: d.line, msg: err.Error()}}
+	}
+	return nil
+}
+
+var dnsSubdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// objectRules são as regras que o apiserver e o operador do Strimzi aplicam fora do
+// schema: nome DNS-1123, label strimzi.io/cluster nos CRs e restartPolicy do Job.
+func objectRules(d document) []violation {
+	var out []violation
+	add := func(n *yaml.Node, p, msg string) {
+		line := d.line
+		if n != nil {
+			line = n.Line
+		}
+		out = append(out, violation{line: line, path: p, msg: msg})
+	}
+
+	meta := yamlnode.Value(d.root, "metadata")
+	if d.name == "" {
+		add(meta, "metadata.name", "obrigatório")
+	} else if !dnsSubdomain.MatchString(d.name) {
+		add(yamlnode.Value(meta, "name"), "metadata.name", fmt.Sprintf("%q não é um nome DNS-1123 válido (minúsculas, dígitos, '-' e '.')", d.name))
+	}
+
+	switch d.kind {
+	case "KafkaConnector", "KafkaTopic":
+		var labels *yaml.Node
+		if meta != nil {
+			labels = yamlnode.Value(meta, "labels")
+		}
+		if labels == nil || yamlnode.Scalar(labels, "strimzi.io/cluster") == "" {
+			add(meta, "metadata.labels", `sem o label strimzi.io/cluster (o operador ignora o recurso)`)
+		}
+	case "Job":
+		spec := yamlnode.Lookup(d.root, "spec", "template", "spec")
+		if spec != nil {
+			switch policy := yamlnode.Scalar(spec, "restartPolicy"); policy {
+			case "Never", "OnFailure":
+			case "":
+				add(spec, "spec.template.spec.restartPolicy", "obrigatório no Job (Never ou OnFailure)")
+			default:
+				add(yamlnode.Value(spec, "restartPolicy"), "spec.template.spec.restartPolicy", fmt.Sprintf("%q não é aceito no Job (Never ou OnFailure)", policy))
+			}
+		}
+	}
+	return out
+}
+
+// joinPath monta o caminho do campo; chaves com '.' ou '/' (labels, chaves de config) vão entre colchetes.
+func joinPath(p, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", p, key)
+	}
+	if p == "" {
+		return key
+	}
+	return p + "." + key
+}
@@ -0,0 +1,233 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"ih-ingestion/internal/yamlnode"
+)
+
+// kustomization é o que a resolução da árvore usa de um kustomization.yaml.
+type kustomization struct {
+	file       string
+	namespace  string
+	namePrefix string
+	nameSuffix string
+	entries    []entry // resources, bases e components, na ordem do arquivo
+}
+
+type entry struct {
+	field string
+	path  string
+	line  int
+}
+
+var errNoKustomization = errors.New("sem kustomization.yaml")
+
+func loadKustomization(dir string) (*kustomization, error) {
+	for _, name := range KustomizationFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var n yaml.Node
+		if err := yaml.Unmarshal(data, &n); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		k := &kustomization{file: path}
+		if len(n.Content) == 0 {
+			return k, nil
+		}
+		root := n.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s: documento não é um mapa", path)
+		}
+		k.namespace = yamlnode.Scalar(root, "namespace")
+		k.namePrefix = yamlnode.Scalar(root, "namePrefix")
+		k.nameSuffix = yamlnode.Scalar(root, "nameSuffix")
+		for _, field := range []string{"resources", "bases", "components"} {
+			list := yamlnode.Value(root, field)
+			if list == nil || list.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, item := range list.Content {
+				k.entries = append(k.entries, entry{field: field, path: item.Value, line: item.Line})
+			}
+		}
+		return k, nil
+	}
+	return nil, errNoKustomization
+}
+
+// isRemote: URLs e repositórios git não são resolvidos offline.
+func isRemote(path string) bool {
+	return strings.Contains(path, "://") || strings.HasPrefix(path, "git@") || strings.HasPrefix(path, "github.com/")
+}
+
+// transform é o efeito acumulado das kustomizations sobre os recursos de baixo:
+// o namespace de fora prevalece e os prefixos/sufixos se acumulam.
+type transform struct {
+	namespace string
+	prefix    string
+	suffix    string
+}
+
+func (t transform) apply(k *kustomization) transform {
+	out := transform{
+		namespace: t.namespace,
+		prefix:    t.prefix + k.namePrefix,
+		suffix:    k.nameSuffix + t.suffix,
+	}
+	if out.namespace == "" {
+		out.namespace = k.namespace
+	}
+	return out
+}
+
+// clusterScoped são os kinds que ignoram o namespace da kustomization.
+var clusterScoped = map[string]bool{
+	"Namespace":                true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"CustomResourceDefinition": true,
+	"PersistentVolume":         true,
+	"StorageClass":             true,
+}
+
+// tree resolve uma árvore de kustomization a partir da raiz.
+type tree struct {
+	c   *checker
+	ids map[string]string // grupo|kind|namespace|nome → arquivo que declarou
+}
+
+func newTree(c *checker) *tree {
+	return &tree{c: c, ids: map[string]string{}}
+}
+
+// Tree resolve a kustomization de dir e tudo o que ela inclui: cada recurso listado
+// precisa existir (pasta com kustomization.yaml ou arquivo), nenhum recurso pode
+// aparecer duas vezes no bundle (mesmo grupo, kind, namespace e nome depois de
+// namespace/namePrefix/nameSuffix) e os nomes finais respeitam MaxNameLength.
+// Os arquivos alcançados também passam pelos schemas.
+func Tree(dir string) Result {
+	res := Result{Kustomizations: 1}
+	newTree(newChecker(&res)).walk(filepath.Clean(dir), transform{}, nil, nil)
+	return res
+}
+
+// from é a entrada que incluiu dir (nil na raiz); stack são as pastas acima, para detectar ciclos.
+func (t *tree) walk(dir string, outer transform, stack []string, from *Problem) {
+	problem := func(p Problem) { t.c.res.Problems = append(t.c.res.Problems, p) }
+
+	for _, s := range stack {
+		if s == dir {
+			p := *from
+			p.Msg = fmt.Sprintf("ciclo de kustomization: %s", strings.Join(append(stack, dir), " → "))
+			problem(p)
+			return
+		}
+	}
+
+	k, err := loadKustomization(dir)
+	if err != nil {
+		p := Problem{File: dir}
+		if from != nil {
+			p = *from
+		}
+		if errors.Is(err, errNoKustomization) {
+			p.Msg = fmt.Sprintf("pasta %s não tem kustomization.yaml", dir)
+		} else {
+			p.Msg = err.Error()
+		}
+		problem(p)
+		return
+	}
+	t.c.file(k.file)
+
+	tf := outer.apply(k)
+	stack = append(stack, dir)
+	listed := map[string]int{}
+
+	for _, e := range k.entries {
+		at := Problem{File: k.file, Line: e.line, Path: e.field}
+		if isRemote(e.path) {
+			continue
+		}
+		path := filepath.Join(dir, e.path)
+		if line, dup := listed[path]; dup {
+			at.Msg = fmt.Sprintf("%s listado mais de uma vez (linha %d)", e.path, line)
+			problem(at)
+			continue
+		}
+		listed[path] = e.line
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			at.Msg = fmt.Sprintf("%s não existe", e.path)
+			problem(at)
+			continue
+		}
+		if fi.IsDir() {
+			t.walk(path, tf, stack, &at)
+			continue
+		}
+		if e.field != "resources" {
+			at.Msg = fmt.Sprintf("%s deve ser uma pasta com kustomization.yaml", e.path)
+			problem(at)
+			continue
+		}
+		t.resources(path, tf)
+	}
+}
+
+// resources registra os recursos de um arquivo com o nome e o namespace finais.
+func (t *tree) resources(path string, tf transform) {
+	for _, d := range t.c.file(path) {
+		if d.kind == "" || d.name == "" || d.kind == "Kustomization" {
+			continue // já reportado pelos schemas
+		}
+
+		name := tf.prefix + d.name + tf.suffix
+		namespace := d.namespace
+		if tf.namespace != "" {
+			namespace = tf.namespace
+		}
+		if clusterScoped[d.kind] {
+			namespace = ""
+		}
+
+		if name != d.name && len(name) > MaxNameLength(d.kind) {
+			t.c.res.Problems = append(t.c.res.Problems, Problem{File: path, Line: d.nameLine, Path: "metadata.name",
+				Msg: fmt.Sprintf("%q vira %q com namePrefix/nameSuffix e passa de %d caracteres para %s", d.name, name, MaxNameLength(d.kind), d.kind)})
+		}
+
+		group, _, _ := strings.Cut(d.apiVersion, "/")
+		if !strings.Contains(d.apiVersion, "/") {
+			group = "" // grupo core (v1)
+		}
+		id := strings.Join([]string{group, d.kind, namespace, name}, "|")
+		if prev, dup := t.ids[id]; dup {
+			t.c.res.Problems = append(t.c.res.Problems, Problem{File: path, Line: d.line,
+				Msg: fmt.Sprintf("%s %s duplicado no bundle (também em %s)", d.kind, qualified(namespace, name), prev)})
+			continue
+		}
+		t.ids[id] = path
+	}
+}
+
+func qualified(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
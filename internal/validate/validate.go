@@ -0,0 +1,280 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/konfig"
+
+	"ih-ingestion/internal/yamlnode"
+)
+
+// Problem é uma falha encontrada em um arquivo gerado.
+type Problem struct {
+	File string
+	Line int    // 0 = arquivo inteiro
+	Path string // campo (ex: spec.template.spec.containers[0].image)
+	Msg  string
+}
+
+func (p Problem) String() string {
+	loc := p.File
+	if p.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	if p.Path != "" {
+		return fmt.Sprintf("%s: %s: %s", loc, p.Path, p.Msg)
+	}
+	return fmt.Sprintf("%s: %s", loc, p.Msg)
+}
+
+// Result resume a validação de uma pasta.
+type Result struct {
+	Files          int // arquivos YAML lidos
+	Resources      int // documentos validados contra um schema
+	Kustomizations int // árvores de kustomization resolvidas
+	Bundle         int // recursos no bundle (Build)
+	Problems       []Problem
+}
+
+// KustomizationFiles são os nomes aceitos pelo kustomize, na ordem de busca.
+var KustomizationFiles = konfig.RecognizedKustomizationFileNames()
+
+func isKustomizationFile(path string) bool {
+	base := filepath.Base(path)
+	for _, k := range KustomizationFiles {
+		if base == k {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxNameLength é o limite de metadata.name por kind. O Job fica em 63 porque o nome
+// vira o valor do label job-name dos pods; os demais seguem o limite de DNS subdomain.
+func MaxNameLength(kind string) int {
+	if kind == "Job" {
+		return 63
+	}
+	return 253
+}
+
+// document é um recurso lido de um arquivo.
+type document struct {
+	root       *yaml.Node
+	apiVersion string
+	kind       string
+	name       string
+	namespace  string
+	line       int
+	nameLine   int
+}
+
+// readDocuments lê os documentos de um arquivo YAML. Documentos vazios e sem
+// apiVersion/kind (ex: manifesto da wave) são ignorados; kustomization.yaml sem
+// apiVersion/kind é tratado como Kustomization, como faz o kustomize.
+func readDocuments(path string) ([]document, []Problem) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []Problem{{File: path, Msg: err.Error()}}
+	}
+	return parseDocuments(path, data)
+}
+
+// parseDocuments é o readDocuments de um conteúdo já lido (path só identifica o arquivo).
+func parseDocuments(path string, data []byte) ([]document, []Problem) {
+	var docs []document
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var n yaml.Node
+		if err := dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return docs, []Problem{{File: path, Msg: "YAML inválido: " + err.Error()}}
+		}
+		if len(n.Content) == 0 {
+			continue
+		}
+		root := n.Content[0]
+		if root.Kind != yaml.MappingNode {
+			if root.ShortTag() == "!!null" {
+				continue
+			}
+			return docs, []Problem{{File: path, Line: root.Line, Msg: "documento não é um mapa"}}
+		}
+
+		d := document{
+			root:       root,
+			apiVersion: yamlnode.Scalar(root, "apiVersion"),
+			kind:       yamlnode.Scalar(root, "kind"),
+			line:       root.Line,
+		}
+		if meta := yamlnode.Value(root, "metadata"); meta != nil && meta.Kind == yaml.MappingNode {
+			d.name = yamlnode.Scalar(meta, "name")
+			if n := yamlnode.Value(meta, "name"); n != nil {
+				d.nameLine = n.Line
+			}
+			d.namespace = yamlnode.Scalar(meta, "namespace")
+		}
+		if d.kind == "" && isKustomizationFile(path) {
+			d.kind = "Kustomization"
+			if d.apiVersion == "" {
+				d.apiVersion = kindVersions["Kustomization"]
+			}
+		}
+		if d.apiVersion == "" && d.kind == "" {
+			continue
+		}
+		if isKustomizationFile(path) && d.kind != "Kustomization" {
+			// Component e afins: fora dos schemas embutidos
+			continue
+		}
+		docs = append(docs, d)
+	}
+}
+
+// checker valida cada arquivo uma vez e guarda os documentos para a resolução das árvores.
+type checker struct {
+	res  *Result
+	docs map[string][]document
+}
+
+func newChecker(res *Result) *checker {
+	return &checker{res: res, docs: map[string][]document{}}
+}
+
+// file valida path contra os schemas (só na primeira vez) e devolve seus documentos.
+func (c *checker) file(path string) []document {
+	if docs, ok := c.docs[path]; ok {
+		return docs
+	}
+	docs, problems := readDocuments(path)
+	for _, d := range docs {
+		ps, validated := checkDocument(path, d)
+		problems = append(problems, ps...)
+		if validated {
+			c.res.Resources++
+		}
+	}
+	c.docs[path] = docs
+	c.res.Files++
+	c.res.Problems = append(c.res.Problems, problems...)
+	return docs
+}
+
+func checkDocument(path string, d document) ([]Problem, bool) {
+	var problems []Problem
+	add := func(line int, field, msg string) {
+		problems = append(problems, Problem{File: path, Line: line, Path: field, Msg: msg})
+	}
+
+	if d.apiVersion == "" || d.kind == "" {
+		add(d.line, "", "apiVersion e kind são obrigatórios")
+		return problems, false
+	}
+
+	var out []violation
+	if d.kind == "Kustomization" {
+		if d.apiVersion != kindVersions[d.kind] {
+			add(d.line, "apiVersion", fmt.Sprintf("%s não suportada para %s (esperado %s)", d.apiVersion, d.kind, kindVersions[d.kind]))
+			return problems, false
+		}
+		out = validateKustomization(d)
+	} else {
+		s := schemas[d.apiVersion+"/"+d.kind]
+		if s == nil {
+			if want, known := kindVersions[d.kind]; known {
+				add(d.line, "apiVersion", fmt.Sprintf("%s não suportada para %s (esperado %s)", d.apiVersion, d.kind, want))
+			} else if d.name == "" {
+				add(d.line, "metadata.name", "obrigatório")
+			}
+			return problems, false
+		}
+		out = append(validateDocument(s, d), objectRules(d)...)
+	}
+
+	reported := map[violation]bool{}
+	for _, v := range out {
+		// o mesmo nó pode falhar em mais de um ramo do schema
+		if !reported[v] {
+			reported[v] = true
+			add(v.line, v.path, v.msg)
+		}
+	}
+	if d.kind != "Kustomization" && len(d.name) > MaxNameLength(d.kind) {
+		add(d.nameLine, "metadata.name", fmt.Sprintf("%q passa de %d caracteres para %s", d.name, MaxNameLength(d.kind), d.kind))
+	}
+	return problems, true
+}
+
+// Dir valida a pasta root: resolve cada árvore de kustomization cuja raiz não é
+// incluída por outra kustomization da pasta e valida também os arquivos YAML que
+// nenhuma árvore alcança.
+func Dir(root string) (Result, error) {
+	root = filepath.Clean(root)
+	var files []string
+	kdirs := map[string]bool{}
+
+	err := filepath.WalkDir(root, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.IsDir() {
+			if p != root && strings.HasPrefix(e.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isKustomizationFile(p) {
+			kdirs[filepath.Dir(p)] = true
+		} else if ext := filepath.Ext(p); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	// raízes: kustomizations que nenhuma outra inclui
+	referenced := map[string]bool{}
+	for dir := range kdirs {
+		k, err := loadKustomization(dir)
+		if err != nil {
+			continue // o problema sai na validação do arquivo
+		}
+		for _, e := range k.entries {
+			if fi, err := os.Stat(filepath.Join(dir, e.path)); err == nil && fi.IsDir() {
+				referenced[filepath.Join(dir, e.path)] = true
+			}
+		}
+	}
+	var roots []string
+	for dir := range kdirs {
+		if !referenced[dir] {
+			roots = append(roots, dir)
+		}
+	}
+	sort.Strings(roots)
+
+	var res Result
+	c := newChecker(&res)
+	for _, dir := range roots {
+		res.Kustomizations++
+		newTree(c).walk(dir, transform{}, nil, nil)
+	}
+	for _, f := range files {
+		c.file(f)
+	}
+	return res, nil
+}
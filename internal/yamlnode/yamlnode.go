@@ -0,0 +1,49 @@
+// Package yamlnode reúne a navegação em árvores yaml.Node usada pelo gerador, pelo
+// kustomize, pelo validate e pelo promote (que editam ou leem os manifestos sem
+// perder ordem e comentários).
+package yamlnode
+
+import "gopkg.in/yaml.v3"
+
+// Key devolve o nó da chave name em um mapping (nil se ausente ou m não é mapping).
+func Key(m *yaml.Node, name string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == name {
+			return m.Content[i]
+		}
+	}
+	return nil
+}
+
+// Value devolve o valor de key em um mapping (nil se ausente ou m não é mapping).
+func Value(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Lookup desce por mappings aninhados (ex: "spec", "template", "spec"); nil se
+// algum nível falta ou não é mapping.
+func Lookup(n *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		n = Value(n, key)
+	}
+	return n
+}
+
+// Scalar devolve o valor escalar em path ("" se ausente ou não escalar).
+func Scalar(n *yaml.Node, path ...string) string {
+	if v := Lookup(n, path...); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}